@@ -20,6 +20,10 @@ func Register() {
 	command := NewCommand()
 
 	cmd.Register(command, &task.DemoScript{})
+	cmd.Register(command, &task.OpenAPIScript{})
+	cmd.Register(command, &task.DeadLetterScript{})
+	cmd.Register(command, &task.MigrateScript{})
+	cmd.Register(command, &task.GenScript{})
 
 	if err := command.Execute(); err != nil {
 		fmt.Println(err)
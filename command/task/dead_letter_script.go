@@ -0,0 +1,204 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"go-framework/config"
+	"go-framework/internal/server"
+	"go-framework/util/mq"
+	"go-framework/util/xconfig"
+	"go-framework/util/xlog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// DeadLetterScript 查看、重放与删除死信
+//
+//	cmd dead-letter topics
+//	cmd dead-letter list <topic> [--offset 0 --limit 20]
+//	cmd dead-letter inspect <topic> <id>
+//	cmd dead-letter replay <topic> [id...] [--all]
+//	cmd dead-letter delete <topic> [id...] [--all]
+type DeadLetterScript struct {
+	confFile string
+	offset   int64
+	limit    int64
+	all      bool
+}
+
+func (s *DeadLetterScript) Command() *cobra.Command {
+	c := &cobra.Command{
+		Use:       "dead-letter <topics|list|inspect|replay|delete> [topic] [id...]",
+		Short:     "死信队列管理",
+		Long:      ``,
+		Args:      cobra.MinimumNArgs(1),
+		ValidArgs: []string{"topics", "list", "inspect", "replay", "delete"},
+	}
+	c.Flags().StringVarP(&s.confFile, "file", "f", "", "配置文件路径")
+	c.Flags().Int64Var(&s.offset, "offset", 0, "list 偏移量")
+	c.Flags().Int64Var(&s.limit, "limit", 20, "list 数量")
+	c.Flags().BoolVar(&s.all, "all", false, "replay、delete 处理主题的全部死信")
+	return c
+}
+
+func (s *DeadLetterScript) Run(cmd *cobra.Command, args []string) {
+	var c config.Conf
+	xconfig.New(&c, s.confFile)
+	// 只需要 redis 与消息中间件的生产者，消费幂等使用 sql 存储时还需要数据库
+	c.Components.Disable = append(c.Components.Disable,
+		server.ComponentTracer, server.ComponentContainer, server.ComponentMQConsumer)
+	if c.MQ.Idempotent.Store != "sql" {
+		c.Components.Disable = append(c.Components.Disable, server.ComponentDB)
+	}
+
+	svcCtx := server.NewSvcContext(c, xlog.NewLogger(c.Log.Path, c.App.Name))
+	ctx := context.Background()
+	err := svcCtx.Start(ctx)
+	if err == nil && svcCtx.DeadLetters == nil {
+		err = errors.New("dead letter queue is not enabled")
+	}
+	if err == nil {
+		err = s.run(ctx, svcCtx, args)
+	}
+
+	stopCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	_ = svcCtx.Lifecycle.Stop(stopCtx)
+
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+func (s *DeadLetterScript) run(ctx context.Context, svcCtx *server.SvcContext, args []string) error {
+	action := args[0]
+	if action == "topics" {
+		return s.topics(ctx, svcCtx.DeadLetters)
+	}
+	if len(args) < 2 {
+		return fmt.Errorf("%s: topic is required", action)
+	}
+	topic, ids := args[1], args[2:]
+
+	switch action {
+	case "list":
+		return s.list(ctx, svcCtx.DeadLetters, topic)
+	case "inspect":
+		if len(ids) != 1 {
+			return errors.New("inspect: exactly one id is required")
+		}
+		return s.inspect(ctx, svcCtx.DeadLetters, topic, ids[0])
+	case "replay":
+		return s.each(ctx, svcCtx.DeadLetters, topic, ids, func(dl *mq.DeadLetter) error {
+			return svcCtx.MQClient.Replay(ctx, svcCtx.DeadLetters, dl)
+		})
+	case "delete":
+		return s.each(ctx, svcCtx.DeadLetters, topic, ids, func(dl *mq.DeadLetter) error {
+			return svcCtx.DeadLetters.Delete(ctx, topic, dl.ID)
+		})
+	default:
+		return fmt.Errorf("unknown action %s", action)
+	}
+}
+
+func (s *DeadLetterScript) topics(ctx context.Context, deadLetters mq.DeadLetterQueue) error {
+	topics, err := deadLetters.Topics(ctx)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TOPIC\tDEAD LETTER TOPIC\tTOTAL")
+	for _, topic := range topics {
+		_, total, err := deadLetters.List(ctx, topic, 0, 0)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\n", topic, mq.DeadLetterTopic(topic), total)
+	}
+	return w.Flush()
+}
+
+func (s *DeadLetterScript) list(ctx context.Context, deadLetters mq.DeadLetterQueue, topic string) error {
+	list, total, err := deadLetters.List(ctx, topic, s.offset, s.limit)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tJOB\tATTEMPTS\tCREATED_AT\tERROR")
+	for _, dl := range list {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", dl.ID, dl.Job, dl.Attempts, dl.CreatedAt.Format(time.DateTime), truncate(dl.Error, 80))
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("共 %d 条\n", total)
+	return nil
+}
+
+func (s *DeadLetterScript) inspect(ctx context.Context, deadLetters mq.DeadLetterQueue, topic, id string) error {
+	dl, err := deadLetters.Get(ctx, topic, id)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(dl, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// each 依次处理指定的死信，--all 时分批处理主题的全部死信
+func (s *DeadLetterScript) each(ctx context.Context, deadLetters mq.DeadLetterQueue, topic string, ids []string, fn func(dl *mq.DeadLetter) error) error {
+	if len(ids) == 0 && !s.all {
+		return errors.New("id is required, or use --all")
+	}
+
+	var list []*mq.DeadLetter
+	if s.all {
+		var offset int64
+		for {
+			batch, _, err := deadLetters.List(ctx, topic, offset, 100)
+			if err != nil {
+				return err
+			}
+			if len(batch) == 0 {
+				break
+			}
+			list = append(list, batch...)
+			offset += int64(len(batch))
+		}
+	}
+	for _, id := range ids {
+		dl, err := deadLetters.Get(ctx, topic, id)
+		if err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+		list = append(list, dl)
+	}
+
+	var errs []error
+	for _, dl := range list {
+		if err := fn(dl); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", dl.ID, err))
+			continue
+		}
+		fmt.Println(dl.ID)
+	}
+	fmt.Printf("成功 %d 条，失败 %d 条\n", len(list)-len(errs), len(errs))
+	return errors.Join(errs...)
+}
+
+func truncate(s string, n int) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	if len([]rune(s)) <= n {
+		return s
+	}
+	return string([]rune(s)[:n]) + "..."
+}
@@ -0,0 +1,121 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"go-framework/config"
+	"go-framework/internal/server"
+	"go-framework/util/xconfig"
+	"go-framework/util/xlog"
+	"go-framework/util/xsql/gen"
+	"os"
+	"time"
+)
+
+// GenScript 根据表结构生成模型、数据访问对象并注册到容器，在项目根目录执行。
+// *.gen.go 每次重新生成，自定义方法写在同目录的 <name>_model.go、<name>_repository.go 中
+//
+//	cmd gen <table...> [--db default] [--name user]
+//	cmd gen <collection...> --db mongo [--sample 100]
+//	cmd gen --register
+type GenScript struct {
+	confFile string
+	db       string
+	name     string
+	sample   int
+	register bool
+}
+
+func (s *GenScript) Command() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "gen <table...>",
+		Short: "根据表结构生成模型与数据访问对象",
+		Long:  ``,
+	}
+	c.Flags().StringVarP(&s.confFile, "file", "f", "", "配置文件路径")
+	c.Flags().StringVar(&s.db, "db", "default", "数据库连接别名，mysql、postgres、clickhouse 或 mongodb")
+	c.Flags().StringVar(&s.name, "name", "", "生成的包名与结构体名，默认使用表名，只能生成一张表时使用")
+	c.Flags().IntVar(&s.sample, "sample", 100, "mongodb 采样的文档数量")
+	c.Flags().BoolVar(&s.register, "register", false, "只重新生成容器注册文件，不连接数据库")
+	return c
+}
+
+func (s *GenScript) Run(cmd *cobra.Command, args []string) {
+	if err := s.run(args); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+func (s *GenScript) run(tables []string) error {
+	g, err := gen.NewGenerator(".")
+	if err != nil {
+		return err
+	}
+	if !s.register {
+		if len(tables) == 0 {
+			return errors.New("gen: table is required")
+		}
+		if s.name != "" && len(tables) > 1 {
+			return errors.New("gen: --name can only be used with one table")
+		}
+		if err = s.generate(g, tables); err != nil {
+			return err
+		}
+	}
+
+	file, err := g.Register()
+	if err != nil {
+		return err
+	}
+	fmt.Println("Generated", file)
+	return nil
+}
+
+func (s *GenScript) generate(g *gen.Generator, tables []string) error {
+	var c config.Conf
+	xconfig.New(&c, s.confFile)
+	// 只需要数据库
+	c.Components.Disable = append(c.Components.Disable,
+		server.ComponentTracer, server.ComponentRedis, server.ComponentMQ, server.ComponentContainer)
+
+	svcCtx := server.NewSvcContext(c, xlog.NewLogger(c.Log.Path, c.App.Name))
+	ctx := context.Background()
+	err := svcCtx.Start(ctx)
+	if err == nil && svcCtx.DBEngine == nil {
+		err = errors.New("database is not enabled")
+	}
+	for i := 0; err == nil && i < len(tables); i++ {
+		err = s.table(ctx, svcCtx, g, tables[i])
+	}
+
+	stopCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	_ = svcCtx.Lifecycle.Stop(stopCtx)
+	return err
+}
+
+func (s *GenScript) table(ctx context.Context, svcCtx *server.SvcContext, g *gen.Generator, name string) error {
+	var (
+		t   *gen.Table
+		err error
+	)
+	if db, ok := svcCtx.DBEngine.Gorm[s.db]; ok {
+		t, err = gen.Inspect(db.WithContext(ctx), name)
+	} else if db, ok := svcCtx.DBEngine.Mongo[s.db]; ok {
+		t, err = gen.InspectMongo(ctx, db.Collection(name), s.sample)
+	} else {
+		err = fmt.Errorf("db %s not found", s.db)
+	}
+	if err != nil {
+		return err
+	}
+
+	files, err := g.Generate(t, s.db, s.name)
+	for _, file := range files {
+		fmt.Println("Generated", file)
+	}
+	return err
+}
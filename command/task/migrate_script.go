@@ -0,0 +1,228 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"go-framework/config"
+	_ "go-framework/internal/migration"
+	"go-framework/internal/server"
+	"go-framework/util/locker"
+	"go-framework/util/xconfig"
+	"go-framework/util/xlog"
+	"go-framework/util/xsql/migrate"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	defaultMigrationDir = "database/migrations"
+	goMigrationDir      = "internal/migration"
+)
+
+// goMigrationTemplate Go 迁移文件模板
+const goMigrationTemplate = `package migration
+
+import (
+	"context"
+	"go-framework/util/xsql/migrate"
+	"gorm.io/gorm"
+)
+
+func init() {
+	migrate.Register(%q, %q, %q, func(ctx context.Context, db *gorm.DB) error {
+		return nil
+	}, func(ctx context.Context, db *gorm.DB) error {
+		return nil
+	})
+}
+`
+
+// MigrateScript 数据库迁移
+//
+//	cmd migrate up [--db default] [--steps 0] [--force] [--no-lock]
+//	cmd migrate down [--db default] [--steps 1] [--no-lock]
+//	cmd migrate redo [--db default] [--steps 1] [--no-lock]
+//	cmd migrate status [--db default]
+//	cmd migrate create <name> [--db default] [--go]
+//
+// up、down、redo 通过 redis 分布式锁避免多个实例同时执行，未启用 redis 时需指定 --no-lock
+type MigrateScript struct {
+	confFile string
+	db       string
+	steps    int
+	force    bool
+	noLock   bool
+	golang   bool
+}
+
+func (s *MigrateScript) Command() *cobra.Command {
+	c := &cobra.Command{
+		Use:       "migrate <up|down|redo|status|create> [name]",
+		Short:     "数据库迁移",
+		Long:      ``,
+		Args:      cobra.MinimumNArgs(1),
+		ValidArgs: []string{"up", "down", "redo", "status", "create"},
+	}
+	c.Flags().StringVarP(&s.confFile, "file", "f", "", "配置文件路径")
+	c.Flags().StringVar(&s.db, "db", "default", "数据库连接别名")
+	c.Flags().IntVar(&s.steps, "steps", 0, "up 执行的数量（默认全部），down、redo 回滚的数量（默认 1）")
+	c.Flags().BoolVar(&s.force, "force", false, "up 时忽略已执行迁移的修改")
+	c.Flags().BoolVar(&s.noLock, "no-lock", false, "不使用分布式锁，确认只有一个实例执行迁移时使用")
+	c.Flags().BoolVar(&s.golang, "go", false, "create 时生成 Go 迁移")
+	return c
+}
+
+func (s *MigrateScript) Run(cmd *cobra.Command, args []string) {
+	var c config.Conf
+	xconfig.New(&c, s.confFile)
+
+	var err error
+	if args[0] == "create" {
+		err = s.create(c, args[1:])
+	} else {
+		err = s.migrate(c, args[0])
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+func (s *MigrateScript) migrate(c config.Conf, action string) error {
+	// 只需要数据库与分布式锁使用的 redis
+	c.Components.Disable = append(c.Components.Disable,
+		server.ComponentTracer, server.ComponentMQ, server.ComponentContainer)
+
+	svcCtx := server.NewSvcContext(c, xlog.NewLogger(c.Log.Path, c.App.Name))
+	ctx := context.Background()
+	err := svcCtx.Start(ctx)
+	if err == nil {
+		err = s.run(ctx, svcCtx, action)
+	}
+
+	stopCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	_ = svcCtx.Lifecycle.Stop(stopCtx)
+	return err
+}
+
+func (s *MigrateScript) run(ctx context.Context, svcCtx *server.SvcContext, action string) error {
+	migrator, err := s.migrator(svcCtx, action != "status")
+	if err != nil {
+		return err
+	}
+
+	var done []*migrate.Migration
+	switch action {
+	case "up":
+		done, err = migrator.Up(ctx, s.steps)
+	case "down":
+		done, err = migrator.Down(ctx, s.steps)
+	case "redo":
+		done, err = migrator.Redo(ctx, s.steps)
+	case "status":
+		return s.status(ctx, migrator)
+	default:
+		return fmt.Errorf("unknown action %s", action)
+	}
+	if err == nil && len(done) == 0 {
+		fmt.Println("Nothing to migrate")
+	}
+	return err
+}
+
+// migrator 创建迁移执行器，lock 为 true 且未指定 --no-lock 时必须能使用分布式锁
+func (s *MigrateScript) migrator(svcCtx *server.SvcContext, lock bool) (*migrate.Migrator, error) {
+	if svcCtx.DBEngine == nil {
+		return nil, errors.New("database is not enabled")
+	}
+	db, ok := svcCtx.DBEngine.Gorm[s.db]
+	if !ok {
+		return nil, fmt.Errorf("db %s not found", s.db)
+	}
+	migrations, err := migrate.Load(filepath.Join(s.dir(svcCtx.Conf), s.db), migrate.Registered(s.db))
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []migrate.Option{
+		migrate.WithTable(svcCtx.Conf.Migration.Table),
+		migrate.WithForce(s.force),
+		migrate.WithLogf(func(format string, args ...interface{}) {
+			fmt.Printf(format+"\n", args...)
+		}),
+	}
+	if lock && !s.noLock {
+		if svcCtx.RedisClient == nil {
+			return nil, errors.New("redis is not enabled for the migration lock, use --no-lock to migrate without it")
+		}
+		alias := svcCtx.Conf.Migration.Redis
+		if alias == "" {
+			alias = "default"
+		}
+		client, ok := svcCtx.RedisClient.Clients()[alias]
+		if !ok {
+			return nil, fmt.Errorf("redis %s not found for the migration lock, use --no-lock to migrate without it", alias)
+		}
+		key := fmt.Sprintf("migrate:%s:%s", svcCtx.Conf.App.Name, s.db)
+		opts = append(opts, migrate.WithLocker(locker.NewMutex(client), key))
+	}
+	return migrate.NewMigrator(db, migrations, opts...)
+}
+
+func (s *MigrateScript) status(ctx context.Context, migrator *migrate.Migrator) error {
+	list, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATE\tAPPLIED_AT")
+	for _, st := range list {
+		appliedAt := ""
+		if st.AppliedAt != nil {
+			appliedAt = st.AppliedAt.Format(time.DateTime)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", st.Version, st.Name, st.State, appliedAt)
+	}
+	return w.Flush()
+}
+
+func (s *MigrateScript) create(c config.Conf, args []string) error {
+	if len(args) != 1 {
+		return errors.New("create: name is required")
+	}
+	version, name := time.Now().Format(migrate.VersionLayout), args[0]
+	if err := migrate.CheckName(name); err != nil {
+		return err
+	}
+
+	if !s.golang {
+		files, err := migrate.Create(filepath.Join(s.dir(c), s.db), version, name)
+		for _, file := range files {
+			fmt.Println("Created", file)
+		}
+		return err
+	}
+
+	if err := os.MkdirAll(goMigrationDir, 0755); err != nil {
+		return err
+	}
+	file := filepath.Join(goMigrationDir, fmt.Sprintf("%s_%s.go", version, name))
+	if err := os.WriteFile(file, []byte(fmt.Sprintf(goMigrationTemplate, s.db, version, name)), 0644); err != nil {
+		return err
+	}
+	fmt.Println("Created", file)
+	return nil
+}
+
+// dir SQL 迁移文件的根目录
+func (s *MigrateScript) dir(c config.Conf) string {
+	if c.Migration.Dir != "" {
+		return c.Migration.Dir
+	}
+	return defaultMigrationDir
+}
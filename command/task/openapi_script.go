@@ -0,0 +1,53 @@
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cobra"
+	"go-framework/config"
+	"go-framework/internal"
+	"go-framework/internal/router"
+	"go-framework/internal/server"
+	"go-framework/util/xconfig"
+	"os"
+)
+
+// OpenAPIScript 生成接口文档并写入文件，不连接任何外部依赖，便于在 CI 中比对文档变更
+type OpenAPIScript struct {
+	confFile string
+	out      string
+}
+
+func (s *OpenAPIScript) Command() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "openapi",
+		Short: "生成 OpenAPI 文档",
+		Long:  ``,
+	}
+	c.Flags().StringVarP(&s.confFile, "file", "f", "", "配置文件路径，为空时使用默认配置")
+	c.Flags().StringVarP(&s.out, "out", "o", "openapi.json", "输出文件路径")
+	return c
+}
+
+func (s *OpenAPIScript) Run(cmd *cobra.Command, args []string) {
+	var c config.Conf
+	if s.confFile != "" {
+		xconfig.New(&c, s.confFile)
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+	app := gin.New()
+	router.Register(app, &internal.AppContent{Svc: &server.SvcContext{Conf: c}})
+
+	spec, err := json.MarshalIndent(router.OpenAPI(app, c), "", "  ")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if err = os.WriteFile(s.out, append(spec, '\n'), 0644); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	fmt.Printf("OpenAPI 文档已写入 %s\n", s.out)
+}
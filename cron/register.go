@@ -7,11 +7,11 @@ import (
 	"go-framework/util/xlog"
 )
 
-func Register(redis *redis.Client, appName string, logger *xlog.Log) {
+// Register 注册定时任务，由调用方负责 Start/Stop
+func Register(redis *redis.Client, appName string, logger *xlog.Log) *cron.Cron {
 	c := cron.StartCronTab(redis, appName, logger)
 
-	c.Register(&task.AutoGenerateMigrateTask{})
 	c.Register(&task.DemoTask{})
 
-	go c.Run()
+	return c
 }
@@ -8,12 +8,14 @@ import (
 )
 
 type Container struct {
+	Generated             // cmd gen 生成的数据访问对象，见 repository_container.gen.go
 	DemoRepository        *demo_repository.DemoRepository
 	DemoMongoDBRepository *demo_repository.DemoMongoDBRepository
 }
 
 func Register(db *databese.Engine, log *xlog.Log) *Container {
 	return &Container{
+		Generated:             registerGenerated(db, log),
 		DemoRepository:        demo_repository.NewDemoRepository(demo_model.NewDemoModel(db), log),
 		DemoMongoDBRepository: demo_repository.NewDemoMongoDBRepository(demo_model.NewDemoMongoDBModel(db), log),
 	}
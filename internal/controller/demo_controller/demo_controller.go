@@ -1,26 +1,27 @@
 package demo_controller
 
 import (
-	"github.com/gin-gonic/gin"
+	"context"
 	"go-framework/internal/container/service"
-	"go-framework/util/xhttp"
+	"go-framework/internal/data/demo_data"
+	"go-framework/util/route"
 	"net/http"
 )
 
-func Demo(svc *service.Container) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		//var param validation.UserRequest
-		// 接收请求参数
-		//if err := c.ShouldBindJSON(&param); err != nil {
-		//	c.JSON(http.StatusOK, xhttp.Error(err))
-		//	return
-		//}
-		res, err := svc.DemoService.Demo(c.Request.Context())
-		//res, err := svc.CmdEventService.GetOne()
-		if err != nil {
-			c.JSON(http.StatusOK, xhttp.Error(err))
-			return
-		}
-		c.JSON(http.StatusOK, xhttp.Data(res))
+type DemoController struct {
+	svc *service.Container
+}
+
+func NewDemoController(svc *service.Container) *DemoController {
+	return &DemoController{svc: svc}
+}
+
+func (ctl *DemoController) Routes() []route.Route {
+	return []route.Route{
+		{Method: http.MethodGet, Path: "/demo", Handler: route.Handle(ctl.Demo)},
 	}
 }
+
+func (ctl *DemoController) Demo(ctx context.Context, req *demo_data.DemoRequest) (*demo_data.DemoResponse, error) {
+	return ctl.svc.DemoService.Demo(ctx, req)
+}
@@ -0,0 +1,39 @@
+package health_controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-framework/util/health"
+	"go-framework/util/xhttp"
+	"net/http"
+)
+
+// Liveness 存活检查，进程能够响应即返回 200
+func Liveness(h *health.Health) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, xhttp.Data(gin.H{"status": health.StatusUp}))
+	}
+}
+
+// Readiness 就绪检查，未启动完成、正在关闭或依赖异常时返回 503
+func Readiness(h *health.Health) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := h.Check(c.Request.Context())
+		if report.Status != health.StatusUp {
+			c.JSON(http.StatusServiceUnavailable, &xhttp.RespData{
+				Code:    http.StatusServiceUnavailable,
+				Status:  http.StatusServiceUnavailable,
+				Message: xhttp.ErrorMessage,
+				Data:    report,
+			})
+			return
+		}
+		c.JSON(http.StatusOK, xhttp.Data(report))
+	}
+}
+
+// Health 返回各依赖检查项的详细报告
+func Health(h *health.Health) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, xhttp.Data(h.Check(c.Request.Context())))
+	}
+}
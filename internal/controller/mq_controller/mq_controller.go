@@ -0,0 +1,114 @@
+package mq_controller
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go-framework/internal/data/mq_data"
+	"go-framework/internal/server"
+	"go-framework/util/mq"
+	"go-framework/util/route"
+	"go-framework/util/xerror"
+	"net/http"
+)
+
+// MQController 消费者管理接口：查看状态、暂停恢复与调整并发
+type MQController struct {
+	svc         *server.SvcContext
+	middlewares []gin.HandlerFunc
+}
+
+// NewMQController 创建消费者管理接口，middlewares 用于鉴权
+func NewMQController(svc *server.SvcContext, middlewares ...gin.HandlerFunc) *MQController {
+	return &MQController{svc: svc, middlewares: middlewares}
+}
+
+func (ctl *MQController) Routes() []route.Route {
+	tags := []string{"mq"}
+	return []route.Route{
+		{Method: http.MethodGet, Path: "/admin/mq/consumers", Middlewares: ctl.middlewares, Handler: route.Handle(ctl.Consumers), Summary: "消费者列表", Tags: tags},
+		{Method: http.MethodGet, Path: "/admin/mq/consumers/:topic", Middlewares: ctl.middlewares, Handler: route.Handle(ctl.Consumer), Summary: "消费者状态", Tags: tags},
+		{Method: http.MethodPost, Path: "/admin/mq/consumers/:topic/pause", Middlewares: ctl.middlewares, Handler: route.Handle(ctl.Pause), Summary: "暂停消费", Tags: tags},
+		{Method: http.MethodPost, Path: "/admin/mq/consumers/:topic/resume", Middlewares: ctl.middlewares, Handler: route.Handle(ctl.Resume), Summary: "恢复消费", Tags: tags},
+		{Method: http.MethodPut, Path: "/admin/mq/consumers/:topic/concurrency", Middlewares: ctl.middlewares, Handler: route.Handle(ctl.SetConcurrency), Summary: "调整消费并发", Tags: tags},
+	}
+}
+
+// Consumers 所有订阅中消费者的运行状态
+func (ctl *MQController) Consumers(ctx context.Context, req *mq_data.ConsumersRequest) (*mq_data.ConsumersResponse, error) {
+	client, err := ctl.client()
+	if err != nil {
+		return nil, err
+	}
+	return &mq_data.ConsumersResponse{Consumers: client.ConsumerStates()}, nil
+}
+
+// Consumer 消费者运行状态
+func (ctl *MQController) Consumer(ctx context.Context, req *mq_data.ConsumerRequest) (*mq.ConsumerState, error) {
+	consumer, err := ctl.consumer(req.Topic)
+	if err != nil {
+		return nil, err
+	}
+	state := consumer.State()
+	return &state, nil
+}
+
+// Pause 暂停消费，处理中的消息继续完成
+func (ctl *MQController) Pause(ctx context.Context, req *mq_data.ConsumerRequest) (*mq.ConsumerState, error) {
+	consumer, err := ctl.consumer(req.Topic)
+	if err != nil {
+		return nil, err
+	}
+	consumer.Pause()
+	ctl.svc.Logger.Infof("队列消费暂停：%s", req.Topic)
+	state := consumer.State()
+	return &state, nil
+}
+
+// Resume 恢复消费
+func (ctl *MQController) Resume(ctx context.Context, req *mq_data.ConsumerRequest) (*mq.ConsumerState, error) {
+	consumer, err := ctl.consumer(req.Topic)
+	if err != nil {
+		return nil, err
+	}
+	consumer.Resume()
+	ctl.svc.Logger.Infof("队列消费恢复：%s", req.Topic)
+	state := consumer.State()
+	return &state, nil
+}
+
+// SetConcurrency 调整消费并发
+func (ctl *MQController) SetConcurrency(ctx context.Context, req *mq_data.ConcurrencyRequest) (*mq.ConsumerState, error) {
+	consumer, err := ctl.consumer(req.Topic)
+	if err != nil {
+		return nil, err
+	}
+	if err = consumer.SetConcurrency(req.Concurrency); err != nil {
+		return nil, xerror.BadRequest(http.StatusBadRequest, err.Error())
+	}
+	ctl.svc.Logger.Infof("队列消费并发调整：%s %d", req.Topic, req.Concurrency)
+	state := consumer.State()
+	return &state, nil
+}
+
+func (ctl *MQController) client() (*mq.Client, error) {
+	if ctl.svc.MQClient == nil {
+		return nil, xerror.ServiceUnavailable(http.StatusServiceUnavailable, "mq 未启用")
+	}
+	return ctl.svc.MQClient, nil
+}
+
+func (ctl *MQController) consumer(topic string) (mq.ConsumerControl, error) {
+	client, err := ctl.client()
+	if err != nil {
+		return nil, err
+	}
+	consumer, err := client.Consumer(topic)
+	if errors.Is(err, mq.ErrConsumerNotFound) {
+		return nil, xerror.NotFound(http.StatusNotFound, "消费者不存在")
+	}
+	if err != nil {
+		return nil, xerror.NotFound(http.StatusNotFound, err.Error())
+	}
+	return consumer, nil
+}
@@ -3,7 +3,7 @@ package tool_data
 import (
 	"go-framework/config"
 	"go-framework/internal/container/repository"
-	"go-framework/util/mq/rocketmq"
+	"go-framework/util/mq"
 	"go-framework/util/xlog"
 	"go-framework/util/xredis"
 )
@@ -13,5 +13,5 @@ type SvcContext struct {
 	RedisClient *xredis.RedisClient
 	Logger      *xlog.Log
 	Repo        *repository.Container
-	MQClient    *rocketmq.Client
+	MQClient    *mq.Client
 }
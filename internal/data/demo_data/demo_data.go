@@ -0,0 +1,9 @@
+package demo_data
+
+// DemoRequest 示例请求参数
+type DemoRequest struct {
+}
+
+// DemoResponse 示例响应数据
+type DemoResponse struct {
+}
@@ -0,0 +1,25 @@
+package mq_data
+
+import (
+	"go-framework/util/mq"
+)
+
+// ConsumersRequest 消费者列表请求参数
+type ConsumersRequest struct {
+}
+
+// ConsumerRequest 消费者请求参数
+type ConsumerRequest struct {
+	Topic string `uri:"topic" binding:"required"` // 队列主题
+}
+
+// ConcurrencyRequest 调整消费者并发请求参数
+type ConcurrencyRequest struct {
+	Topic       string `uri:"topic" binding:"required"`              // 队列主题
+	Concurrency int    `json:"concurrency" binding:"required,min=1"` // 并发数
+}
+
+// ConsumersResponse 消费者列表响应数据
+type ConsumersResponse struct {
+	Consumers []mq.ConsumerState `json:"consumers"`
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"github.com/gin-gonic/gin"
+	"go-framework/util/xhttp"
+	"net/http"
+	"strings"
+)
+
+// AdminMiddleware 管理接口鉴权，校验请求头 Authorization: Bearer <token>，token 为空时拒绝所有请求
+func AdminMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		auth := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if token == "" || subtle.ConstantTimeCompare([]byte(auth), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, xhttp.ErrMsg("未授权", http.StatusUnauthorized).SetCode(http.StatusUnauthorized))
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,14 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-framework/util/xsql/resolver"
+)
+
+// DBSessionMiddleware 每个请求创建一个读写会话，请求中执行写操作之后的读操作使用主库
+func DBSessionMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(resolver.NewSession(c.Request.Context()))
+		c.Next()
+	}
+}
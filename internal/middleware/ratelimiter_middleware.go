@@ -2,28 +2,45 @@ package middleware
 
 import (
 	"github.com/gin-gonic/gin"
+	"go-framework/internal/server"
 	"go-framework/pkg/aegis/ratelimit"
 	"go-framework/pkg/aegis/ratelimit/bbr"
+	"go-framework/util/limiter"
+	"go-framework/util/metrics"
 	"go-framework/util/xhttp"
 	"net/http"
+	"strconv"
 )
 
-func RateLimiterMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		allow, err := bbr.NewLimiter().Allow()
+// RateLimiterMiddleware 先按 BBR 进行单机自适应限流，再按配置规则进行分布式限流，超出限制时返回 429
+func RateLimiterMiddleware(svc *server.SvcContext) gin.HandlerFunc {
+	bbrLimiter := bbr.NewLimiter()
+	metrics.RegisterLimiter("http", bbrLimiter)
 
+	return func(c *gin.Context) {
+		allow, err := bbrLimiter.Allow()
 		if err != nil {
-			c.JSON(http.StatusOK, xhttp.Error(err))
-			c.Abort()
+			tooManyRequests(c, err)
 			return
 		}
+		defer allow(ratelimit.DoneInfo{})
 
-		defer func() {
-			if err == nil {
-				allow(ratelimit.DoneInfo{})
-			}
-		}()
+		err = svc.Limiter.Allow(c.Request.Context(), limiter.Request{
+			Operation: c.FullPath(),
+			ClientIP:  c.ClientIP(),
+			Header:    c.GetHeader,
+			UserID:    func() string { return c.GetString("user_id") },
+		})
+		if err != nil {
+			tooManyRequests(c, err)
+			return
+		}
 
 		c.Next()
 	}
 }
+
+func tooManyRequests(c *gin.Context, err error) {
+	c.Header("Retry-After", strconv.Itoa(limiter.RetryAfter(err)))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, xhttp.ErrMsg("请求过于频繁，请稍后重试", http.StatusTooManyRequests).SetCode(http.StatusTooManyRequests))
+}
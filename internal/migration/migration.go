@@ -0,0 +1,3 @@
+// Package migration Go 迁移，用于 SQL 无法完成的数据迁移，每个迁移一个文件，在 init 中通过 migrate.Register 注册。
+// 由 cmd migrate create <name> --go 生成，SQL 迁移文件位于配置的 migration.dir 目录下
+package migration
@@ -23,7 +23,7 @@ type DBModel struct {
 }
 
 func (m *DBModel) Model() *gorm.DB {
-	if m.DB().Gorm[m.Connection()] == nil {
+	if m.DB() == nil || m.DB().Gorm[m.Connection()] == nil {
 		panic(fmt.Sprintf("db【%s】connection is not initialized", m.Connection()))
 	}
 	return m.DB().Gorm[m.Connection()].Table(m.Table())
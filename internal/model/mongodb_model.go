@@ -1,6 +1,7 @@
 package model
 
 import (
+	"fmt"
 	"go-framework/util/xsql/databese"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -22,5 +23,8 @@ func NewMongoDBModel(db *databese.Engine, database, tableName string) *MongoDBMo
 }
 
 func (m *MongoDBModel) Model() *mongo.Collection {
+	if m.DB() == nil || m.DB().Mongo[m.Connection()] == nil {
+		panic(fmt.Sprintf("mongodb【%s】connection is not initialized", m.Connection()))
+	}
 	return m.DB().Mongo[m.Connection()].Collection(m.Table())
 }
@@ -1,9 +1,11 @@
 package mq
 
-import "go-framework/util/mq/rocketmq"
+import xmq "go-framework/util/mq"
 
-func ConsumerHandler(client *rocketmq.Client) {
-	//rocketmq.ConsumerMessage(client, &queues.OrderQueue{}, rocketmq.WithConcurrency(10), rocketmq.WithRetryTimes(3)) // 订单队列消费者
-	//rocketmq.ConsumerMessage(client, &queues.OrderQueue{}, rocketmq.WithConcurrency(10), rocketmq.WithRetryTimes(0)) // 订单队列消费者
-	//rocketmq.ConsumerMessage(client, &queues.ShopQueue{}, rocketmq.WithConcurrency(3))                               // 商家队列消费者
+func ConsumerHandler(client *xmq.Client) {
+	//_ = client.Subscribe(&queues.OrderQueue{}, xmq.WithConcurrency(10), xmq.WithRetryTimes(3)) // 订单队列消费者
+	//_ = client.Subscribe(&queues.OrderQueue{}, xmq.WithConcurrency(10), xmq.WithRetryTimes(0)) // 订单队列消费者
+	//_ = client.Subscribe(&queues.ShopQueue{}, xmq.WithConcurrency(3))                          // 商家队列消费者
+	//_ = client.Subscribe(&queues.ShopQueue{}, xmq.WithRetryPolicy(xmq.ExponentialRetry{Base: time.Second, Max: time.Minute, Jitter: 0.2, MaxAttempts: 5})) // 指数退避重试，失败 5 次后进入死信队列
+	//_ = client.Subscribe(&queues.OrderQueue{}, xmq.WithConcurrency(10), xmq.WithOrdered(xmq.OrderedBlock))                  // 顺序消费，同一订单的消息按发送顺序处理，发送时通过 xmq.WithShardingKey 指定订单 ID
 }
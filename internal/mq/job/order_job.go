@@ -1,9 +1,9 @@
 package job
 
 import (
+	"context"
 	"fmt"
 	"go-framework/util/mq/queue"
-	"reflect"
 	"time"
 )
 
@@ -12,11 +12,12 @@ var _ queue.Job = (*OrderJob)(nil)
 type OrderJob struct {
 }
 
+// Name 任务名称需保持稳定，不使用反射获取的类型名，避免重命名后无法消费已发送的消息
 func (o *OrderJob) Name() string {
-	return reflect.TypeOf(*o).String()
+	return "job.OrderJob"
 }
 
-func (o *OrderJob) Execute(bytes []byte) error {
+func (o *OrderJob) Execute(ctx context.Context, bytes []byte) error {
 	fmt.Println(string(bytes))
 	time.Sleep(time.Second * 5)
 	//fmt.Println("准备进行报错")
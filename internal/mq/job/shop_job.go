@@ -1,21 +1,22 @@
 package job
 
 import (
-	"fmt"
-	"go-framework/util/mq/queue"
-	"reflect"
+	"context"
+	"go-framework/util/mq"
 )
 
-var _ queue.Job = (*ShopJob)(nil)
-
-type ShopJob struct {
-}
-
-func (o *ShopJob) Name() string {
-	return reflect.TypeOf(*o).String()
+// ShopPayload 商家消息
+type ShopPayload struct {
+	ShopId int64  `json:"shop_id"`
+	Name   string `json:"name"`
 }
 
-func (o *ShopJob) Execute(bytes []byte) error {
-	fmt.Println(string(bytes))
+// ShopJob 类型化任务示例，名称固定为原类型名，已发送的消息不受结构体重命名影响
+//
+//	_ = mq.Dispatch(ctx, svc.MQClient, job.ShopJob, job.ShopPayload{ShopId: 1})
+var ShopJob = mq.NewJob("job.ShopJob", func(ctx context.Context, payload ShopPayload) error {
+	if logger := mq.LoggerFromContext(ctx); logger != nil {
+		logger.Infof("shop %d %s", payload.ShopId, payload.Name)
+	}
 	return nil
-}
+})
@@ -2,9 +2,9 @@ package mq
 
 import (
 	"go-framework/internal/mq/queues"
-	"go-framework/util/mq/rocketmq"
+	xmq "go-framework/util/mq"
 )
 
-func RegisterQueue(client *rocketmq.Client) {
+func RegisterQueue(client *xmq.Client) {
 	client.AddQueue(&queues.OrderQueue{}, &queues.ShopQueue{})
 }
@@ -20,7 +20,7 @@ func (o *ShopQueue) GroupId() string {
 
 func (o *ShopQueue) Enqueue() []queue.Job {
 	var jobs []queue.Job
-	jobs = append(jobs, &job.ShopJob{})
+	jobs = append(jobs, job.ShopJob)
 
 	return jobs
 }
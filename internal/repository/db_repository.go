@@ -5,6 +5,7 @@ import (
 	"errors"
 	"go-framework/internal/model"
 	"go-framework/util/xlog"
+	"go-framework/util/xsql/transaction"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
@@ -46,10 +47,14 @@ func (r *DBRepository) SharedLock() *DBRepository {
 	return r
 }
 
-func (r *DBRepository) db() *gorm.DB {
+// db 优先使用 NewDB 传入的事务，其次使用 ctx 中的事务
+func (r *DBRepository) db(ctx context.Context) *gorm.DB {
 	if r.tx != nil && r.tx[r.Model.Connection()] != nil {
 		return r.tx[r.Model.Connection()].Table(r.Model.Table())
 	}
+	if tx, ok := transaction.DB(ctx, r.Model.Connection()); ok {
+		return tx.Table(r.Model.Table())
+	}
 	return r.Model.Model()
 }
 
@@ -96,7 +101,7 @@ func (r *DBRepository) Count(ctx context.Context, condition string, args []inter
 // Exists 判断数据是否存在
 func (r *DBRepository) Exists(ctx context.Context, condition interface{}, args []interface{}) (bool, error) {
 	result := make(map[string]interface{})
-	err := r.db().WithContext(ctx).Where(condition, args...).Take(&result).Error
+	err := r.db(ctx).WithContext(ctx).Where(condition, args...).Take(&result).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return false, nil
 	}
@@ -108,30 +113,30 @@ func (r *DBRepository) Exists(ctx context.Context, condition interface{}, args [
 
 // Create 创建数据
 func (r *DBRepository) Create(ctx context.Context, value interface{}) error {
-	return r.db().WithContext(ctx).Create(value).Error
+	return r.db(ctx).WithContext(ctx).Create(value).Error
 }
 
 // CreateOrUpdate 创建或更新数据
 func (r *DBRepository) CreateOrUpdate(ctx context.Context, values interface{}, conflict clause.OnConflict) error {
-	query := r.db()
+	query := r.db(ctx)
 	query.Clauses(conflict)
 	return query.WithContext(ctx).Create(values).Error
 }
 
 // Update 更新数据
 func (r *DBRepository) Update(ctx context.Context, condition interface{}, args []interface{}, values interface{}) error {
-	return r.db().WithContext(ctx).Where(condition, args...).Updates(values).Error
+	return r.db(ctx).WithContext(ctx).Where(condition, args...).Updates(values).Error
 }
 
 // Delete 删除数据
 func (r *DBRepository) Delete(ctx context.Context, condition interface{}, conds []interface{}) error {
 	var values interface{}
-	return r.db().WithContext(ctx).Where(condition, conds...).Delete(values).Error
+	return r.db(ctx).WithContext(ctx).Where(condition, conds...).Delete(values).Error
 }
 
 // QueryBuilder 构建查询条件
 func (r *DBRepository) QueryBuilder(ctx context.Context, condition string, args []interface{}, options ...map[string]interface{}) *gorm.DB {
-	query := r.db().Where(condition, args...)
+	query := r.db(ctx).Where(condition, args...)
 	if len(options) != 0 && len(options[0]) != 0 {
 		option := options[0]
 
@@ -194,10 +199,10 @@ func (r *DBRepository) QueryBuilder(ctx context.Context, condition string, args
 
 // ExecSql 执行原生sql
 func (r *DBRepository) ExecSql(ctx context.Context, sql string, args ...interface{}) error {
-	return r.db().WithContext(ctx).Exec(sql, args...).Error
+	return r.db(ctx).WithContext(ctx).Exec(sql, args...).Error
 }
 
 // ScanStruct 执行原生sql并将结果映射到结构体
 func (r *DBRepository) ScanStruct(ctx context.Context, dest interface{}, sql string, args ...interface{}) error {
-	return r.db().WithContext(ctx).Raw(sql, args...).Scan(dest).Error
+	return r.db(ctx).WithContext(ctx).Raw(sql, args...).Scan(dest).Error
 }
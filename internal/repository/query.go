@@ -0,0 +1,286 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-framework/util/xhttp"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 锁类型
+const (
+	lockNone = iota
+	lockForUpdate
+	lockShared
+)
+
+// condition 查询条件
+type condition struct {
+	or    bool
+	query interface{}
+	args  []interface{}
+}
+
+// join 连表条件
+type join struct {
+	query string
+	args  []interface{}
+}
+
+// Query 类型化查询构建器，链式设置条件后通过 Find、First、Page 等方法执行
+type Query[T any] struct {
+	repo       *Repository[T]
+	alias      string
+	conditions []condition
+	joins      []join
+	selects    []string
+	omits      []string
+	orders     []interface{}
+	group      string
+	having     []condition
+	limit      int
+	offset     int
+	locked     int
+	scopes     []func(*gorm.DB) *gorm.DB
+}
+
+// Alias 设置表名别名，如 "users u"
+func (q *Query[T]) Alias(alias string) *Query[T] {
+	q.alias = alias
+	return q
+}
+
+// Where 添加 AND 条件，参数同 gorm.DB.Where
+func (q *Query[T]) Where(query interface{}, args ...interface{}) *Query[T] {
+	q.conditions = append(q.conditions, condition{query: query, args: args})
+	return q
+}
+
+// Or 添加 OR 条件
+func (q *Query[T]) Or(query interface{}, args ...interface{}) *Query[T] {
+	q.conditions = append(q.conditions, condition{or: true, query: query, args: args})
+	return q
+}
+
+// Join 添加连表条件，如 "LEFT JOIN orders o ON o.user_id = u.id"
+func (q *Query[T]) Join(query string, args ...interface{}) *Query[T] {
+	q.joins = append(q.joins, join{query: query, args: args})
+	return q
+}
+
+// Select 指定查询字段
+func (q *Query[T]) Select(fields ...string) *Query[T] {
+	q.selects = append(q.selects, fields...)
+	return q
+}
+
+// Omit 排除字段，用于创建和更新
+func (q *Query[T]) Omit(fields ...string) *Query[T] {
+	q.omits = append(q.omits, fields...)
+	return q
+}
+
+// Order 添加排序，如 "id desc"
+func (q *Query[T]) Order(order interface{}) *Query[T] {
+	q.orders = append(q.orders, order)
+	return q
+}
+
+// Group 设置分组
+func (q *Query[T]) Group(group string) *Query[T] {
+	q.group = group
+	return q
+}
+
+// Having 添加分组过滤条件
+func (q *Query[T]) Having(query interface{}, args ...interface{}) *Query[T] {
+	q.having = append(q.having, condition{query: query, args: args})
+	return q
+}
+
+// Limit 设置查询条数
+func (q *Query[T]) Limit(limit int) *Query[T] {
+	q.limit = limit
+	return q
+}
+
+// Offset 设置跳过条数
+func (q *Query[T]) Offset(offset int) *Query[T] {
+	q.offset = offset
+	return q
+}
+
+// Paginate 按页码设置查询条数与跳过条数，页码从 1 开始
+func (q *Query[T]) Paginate(page, pageSize int) *Query[T] {
+	if page < 1 {
+		page = 1
+	}
+	q.limit = pageSize
+	q.offset = (page - 1) * pageSize
+	return q
+}
+
+// LockForUpdate 更新锁，仅在事务中生效
+func (q *Query[T]) LockForUpdate() *Query[T] {
+	q.locked = lockForUpdate
+	return q
+}
+
+// SharedLock 共享锁，仅在事务中生效
+func (q *Query[T]) SharedLock() *Query[T] {
+	q.locked = lockShared
+	return q
+}
+
+// Scopes 添加自定义的 gorm 查询函数
+func (q *Query[T]) Scopes(scopes ...func(*gorm.DB) *gorm.DB) *Query[T] {
+	q.scopes = append(q.scopes, scopes...)
+	return q
+}
+
+// Find 查询多条数据
+func (q *Query[T]) Find(ctx context.Context) ([]T, error) {
+	var list []T
+	err := q.build(ctx, true).Find(&list).Error
+	return list, err
+}
+
+// First 查询单条数据，未设置排序时按主键排序，不存在时返回 nil
+func (q *Query[T]) First(ctx context.Context) (*T, error) {
+	var dest T
+	query := q.build(ctx, true)
+	var err error
+	if len(q.orders) != 0 || q.repo.IsMyCat {
+		err = query.Take(&dest).Error
+	} else {
+		err = query.First(&dest).Error
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &dest, nil
+}
+
+// Count 查询数据条数，忽略排序与分页
+func (q *Query[T]) Count(ctx context.Context) (int64, error) {
+	var count int64
+	err := q.build(ctx, false).Count(&count).Error
+	return count, err
+}
+
+// Exists 判断数据是否存在
+func (q *Query[T]) Exists(ctx context.Context) (bool, error) {
+	var dest T
+	err := q.build(ctx, false).Take(&dest).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Page 分页查询，返回的分页数据可直接作为响应或通过 Response 转换为 xhttp.Paginate。
+// pageSize 必须大于 0，分页只作用于本次查询，不修改 q 已设置的条数与跳过条数
+func (q *Query[T]) Page(ctx context.Context, page, pageSize int) (*xhttp.Page[T], error) {
+	if pageSize <= 0 {
+		return nil, fmt.Errorf("repository: invalid page size %d", pageSize)
+	}
+	if page < 1 {
+		page = 1
+	}
+	total, err := q.Count(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]T, 0)
+	if total > 0 && int64((page-1)*pageSize) < total {
+		paged := *q
+		if list, err = paged.Paginate(page, pageSize).Find(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return xhttp.NewPage(list, int(total), page, pageSize), nil
+}
+
+// Update 按条件更新非零值字段，返回影响的行数
+func (q *Query[T]) Update(ctx context.Context, value T) (int64, error) {
+	result := q.build(ctx, false).Updates(&value)
+	return result.RowsAffected, result.Error
+}
+
+// UpdateColumns 按条件更新指定字段，返回影响的行数
+func (q *Query[T]) UpdateColumns(ctx context.Context, values map[string]interface{}) (int64, error) {
+	result := q.build(ctx, false).Updates(values)
+	return result.RowsAffected, result.Error
+}
+
+// Delete 按条件删除，未设置条件时返回 gorm.ErrMissingWhereClause
+func (q *Query[T]) Delete(ctx context.Context) (int64, error) {
+	result := q.build(ctx, false).Delete(new(T))
+	return result.RowsAffected, result.Error
+}
+
+// build 构建 gorm 查询，paging 为 false 时忽略排序与分页（用于统计、更新与删除）
+func (q *Query[T]) build(ctx context.Context, paging bool) *gorm.DB {
+	query, inTx := q.repo.db(ctx)
+	query = query.WithContext(ctx)
+	if q.alias != "" {
+		query = query.Table(q.alias)
+	}
+	for _, j := range q.joins {
+		query = query.Joins(j.query, j.args...)
+	}
+	for _, c := range q.conditions {
+		if c.or {
+			query = query.Or(c.query, c.args...)
+		} else {
+			query = query.Where(c.query, c.args...)
+		}
+	}
+	if len(q.selects) != 0 {
+		query = query.Select(q.selects)
+	}
+	if len(q.omits) != 0 {
+		query = query.Omit(q.omits...)
+	}
+	if q.group != "" {
+		query = query.Group(q.group)
+		for _, h := range q.having {
+			query = query.Having(h.query, h.args...)
+		}
+	}
+	if len(q.scopes) != 0 {
+		query = query.Scopes(q.scopes...)
+	}
+
+	if paging {
+		for _, order := range q.orders {
+			query = query.Order(order)
+		}
+		if q.limit > 0 {
+			query = query.Limit(q.limit)
+		}
+		if q.offset > 0 {
+			query = query.Offset(q.offset)
+		}
+	}
+
+	if !inTx {
+		return query
+	}
+	switch q.locked {
+	case lockForUpdate:
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	case lockShared:
+		query = query.Clauses(clause.Locking{
+			Strength: "SHARE",
+			Table:    clause.Table{Name: clause.CurrentTable},
+		})
+	}
+	return query
+}
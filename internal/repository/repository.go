@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"go-framework/internal/model"
+	"go-framework/util/xhttp"
+	"go-framework/util/xlog"
+	"go-framework/util/xsql/transaction"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository 类型化数据访问对象【mysql、Clickhouse、Hologres】，T 为表对应的结构体
+type Repository[T any] struct {
+	Model   model.DBModelImpl
+	Log     *xlog.Log
+	IsMyCat bool
+	tx      map[string]*gorm.DB
+}
+
+// NewRepository 创建类型化数据访问对象
+func NewRepository[T any](model model.DBModelImpl, log *xlog.Log) *Repository[T] {
+	return &Repository[T]{Model: model, Log: log}
+}
+
+// NewDB 创建使用事务连接的数据访问对象
+func (r *Repository[T]) NewDB(txm map[string]*gorm.DB) *Repository[T] {
+	return &Repository[T]{Model: r.Model, Log: r.Log, IsMyCat: r.IsMyCat, tx: txm}
+}
+
+// db 优先使用 NewDB 传入的事务，其次使用 ctx 中的事务，返回是否在事务中
+func (r *Repository[T]) db(ctx context.Context) (*gorm.DB, bool) {
+	if r.tx != nil && r.tx[r.Model.Connection()] != nil {
+		return r.tx[r.Model.Connection()].Table(r.Model.Table()), true
+	}
+	if tx, ok := transaction.DB(ctx, r.Model.Connection()); ok {
+		return tx.Table(r.Model.Table()), true
+	}
+	return r.Model.Model(), false
+}
+
+func (r *Repository[T]) conn(ctx context.Context) *gorm.DB {
+	db, _ := r.db(ctx)
+	return db.WithContext(ctx)
+}
+
+// Query 创建查询构建器
+func (r *Repository[T]) Query() *Query[T] {
+	return &Query[T]{repo: r}
+}
+
+// Where 以条件创建查询构建器
+func (r *Repository[T]) Where(query interface{}, args ...interface{}) *Query[T] {
+	return r.Query().Where(query, args...)
+}
+
+// Find 查询多条数据
+func (r *Repository[T]) Find(ctx context.Context, query interface{}, args ...interface{}) ([]T, error) {
+	return r.Where(query, args...).Find(ctx)
+}
+
+// First 查询单条数据，不存在时返回 nil
+func (r *Repository[T]) First(ctx context.Context, query interface{}, args ...interface{}) (*T, error) {
+	return r.Where(query, args...).First(ctx)
+}
+
+// Page 分页查询
+func (r *Repository[T]) Page(ctx context.Context, page, pageSize int, query interface{}, args ...interface{}) (*xhttp.Page[T], error) {
+	return r.Where(query, args...).Page(ctx, page, pageSize)
+}
+
+// Create 创建数据，自增主键回填到 value
+func (r *Repository[T]) Create(ctx context.Context, value *T) error {
+	return r.conn(ctx).Create(value).Error
+}
+
+// CreateInBatches 按批量大小分批创建数据
+func (r *Repository[T]) CreateInBatches(ctx context.Context, values []*T, batchSize int) error {
+	if len(values) == 0 {
+		return nil
+	}
+	return r.conn(ctx).CreateInBatches(values, batchSize).Error
+}
+
+// Upsert 创建数据，冲突时按 conflict 更新，如 clause.OnConflict{Columns: []clause.Column{{Name: "id"}}, UpdateAll: true}
+func (r *Repository[T]) Upsert(ctx context.Context, conflict clause.OnConflict, values ...*T) error {
+	if len(values) == 0 {
+		return nil
+	}
+	return r.conn(ctx).Clauses(conflict).Create(values).Error
+}
+
+// Update 按条件更新非零值字段，返回影响的行数
+func (r *Repository[T]) Update(ctx context.Context, value T, query interface{}, args ...interface{}) (int64, error) {
+	return r.Where(query, args...).Update(ctx, value)
+}
+
+// Delete 按条件删除，返回影响的行数
+func (r *Repository[T]) Delete(ctx context.Context, query interface{}, args ...interface{}) (int64, error) {
+	return r.Where(query, args...).Delete(ctx)
+}
+
+// Raw 执行原生sql并将结果映射到 T
+func (r *Repository[T]) Raw(ctx context.Context, sql string, args ...interface{}) ([]T, error) {
+	var list []T
+	err := r.conn(ctx).Raw(sql, args...).Scan(&list).Error
+	return list, err
+}
@@ -4,15 +4,42 @@ import (
 	"github.com/gin-gonic/gin"
 	"go-framework/internal"
 	"go-framework/internal/controller/demo_controller"
+	"go-framework/internal/controller/health_controller"
+	"go-framework/internal/controller/mq_controller"
 	"go-framework/internal/middleware"
+	"go-framework/util/metrics"
+	"go-framework/util/openapi"
+	"go-framework/util/route"
 )
 
 func Register(app *gin.Engine, appCxt *internal.AppContent) {
+	// 健康检查与指标采集不经过限流与链路追踪
+	app.GET("/healthz", health_controller.Liveness(appCxt.Svc.Health))
+	app.GET("/readyz", health_controller.Readiness(appCxt.Svc.Health))
+	app.GET("/health", health_controller.Health(appCxt.Svc.Health))
+	app.GET("/metrics", gin.WrapH(metrics.Handler()))
+
 	app.Use(
+		middleware.MetricsMiddleware(),
 		middleware.OTELMiddleware(appCxt.Svc),
 		middleware.RecoveryMiddleware(appCxt.Svc),
-		middleware.RateLimiterMiddleware(),
+		middleware.RateLimiterMiddleware(appCxt.Svc),
+		middleware.DBSessionMiddleware(),
+	)
+
+	route.Register(app,
+		demo_controller.NewDemoController(appCxt.Service),
 	)
 
-	app.GET("/demo", demo_controller.Demo(appCxt.Service))
+	// 消费者管理接口
+	if conf := appCxt.Svc.Conf.MQ.Admin; conf.Enable {
+		route.Register(app, mq_controller.NewMQController(appCxt.Svc, middleware.AdminMiddleware(conf.Token)))
+	}
+
+	// 接口文档需在所有路由注册完成后生成
+	if conf := appCxt.Svc.Conf.OpenAPI; conf.Enable {
+		if err := openapi.Register(app, OpenAPI(app, appCxt.Svc.Conf), conf.SwaggerUI); err != nil {
+			panic(err)
+		}
+	}
 }
@@ -0,0 +1,22 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-framework/config"
+	"go-framework/util/openapi"
+)
+
+const defaultOpenAPIVersion = "1.0.0"
+
+// OpenAPI 根据已注册的路由生成接口文档
+func OpenAPI(app *gin.Engine, c config.Conf) *openapi.Document {
+	version := c.OpenAPI.Version
+	if version == "" {
+		version = defaultOpenAPIVersion
+	}
+	title := c.App.Name
+	if title == "" {
+		title = "api"
+	}
+	return openapi.Generate(app.Routes(), openapi.Info{Title: title, Version: version})
+}
@@ -0,0 +1,17 @@
+package server
+
+// 组件名称，可在配置 components.disable 中禁用
+const (
+	ComponentTracer     = "tracer"
+	ComponentDB         = "xsql"
+	ComponentRedis      = "xredis"
+	ComponentMQ         = "mq"
+	ComponentMQConsumer = "mq.consumer"
+	ComponentOutbox     = "outbox"
+	ComponentContainer  = "container"
+	ComponentApp        = "app"
+	ComponentCron       = "cron"
+	ComponentGrpc       = "grpc"
+	ComponentHttp       = "http"
+	ComponentHealth     = "health"
+)
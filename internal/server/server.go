@@ -2,19 +2,37 @@ package server
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"github.com/go-redis/redis/v8"
 	"go-framework/config"
 	"go-framework/internal/container/common/tool"
 	"go-framework/internal/container/grpc"
 	"go-framework/internal/container/repository"
 	"go-framework/internal/data/common_data/tool_data"
 	"go-framework/internal/mq"
+	redislimit "go-framework/pkg/aegis/ratelimit/redis"
+	"go-framework/util/health"
+	"go-framework/util/lifecycle"
+	"go-framework/util/limiter"
+	xmq "go-framework/util/mq"
+	"go-framework/util/mq/deadletter"
+	"go-framework/util/mq/idempotent"
+	"go-framework/util/mq/kafka"
+	"go-framework/util/mq/memory"
+	"go-framework/util/mq/outbox"
+	"go-framework/util/mq/queue"
+	"go-framework/util/mq/rabbitmq"
+	"go-framework/util/mq/redisstream"
 	"go-framework/util/mq/rocketmq"
 	"go-framework/util/thread"
 	"go-framework/util/tracer"
+	"go-framework/util/types"
 	"go-framework/util/xlog"
 	"go-framework/util/xredis"
 	"go-framework/util/xsql"
 	"go-framework/util/xsql/databese"
+	"time"
 )
 
 type SvcContext struct {
@@ -23,26 +41,286 @@ type SvcContext struct {
 	DBEngine    *databese.Engine
 	RedisClient *xredis.RedisClient
 	Logger      *xlog.Log
-	MQClient    *rocketmq.Client
+	MQClient    *xmq.Client
+	DeadLetters xmq.DeadLetterQueue
+	Outbox      *outbox.Producer
+	Idempotency *xmq.Idempotency
 	Repo        *repository.Container
 	Tool        *tool.Container
 	Grpc        *grpc.Container
+	Tracer      *tracer.Tracer
+	Lifecycle   *lifecycle.Manager
+	Components  *lifecycle.Registry
+	Health      *health.Health
+	Limiter     *limiter.Limiter
+
+	outboxRelay *outbox.Relay
 }
 
+// NewSvcContext 创建服务上下文并注册基础组件，组件在 Start 时按依赖顺序初始化
 func NewSvcContext(c config.Conf, logger *xlog.Log) *SvcContext {
-
 	svc := &SvcContext{
-		Conf:        c,
-		Logger:      logger,
-		Ctx:         context.Background(),
-		DBEngine:    xsql.NewClient(c.DB),
-		RedisClient: xredis.NewClient(c.Redis),
+		Conf:      c,
+		Logger:    logger,
+		Ctx:       context.Background(),
+		Lifecycle: lifecycle.NewManager(logger),
+		Health:    health.New(),
 	}
-	svc.MQClient = rocketmq.NewClient(c, logger, svc.RedisClient.Default(), mq.RegisterQueue)
+	svc.Components = lifecycle.NewRegistry(svc.Lifecycle, c.Components.Disable...)
+
+	svc.Components.Register(
+		lifecycle.Component{Name: ComponentTracer, Start: svc.startTracer, Stop: svc.stopTracer},
+		lifecycle.Component{Name: ComponentDB, Start: svc.startDB, Stop: svc.stopDB},
+		lifecycle.Component{Name: ComponentRedis, Start: svc.startRedis, Stop: svc.stopRedis},
+		lifecycle.Component{Name: ComponentMQ, Depends: []string{ComponentRedis}, Start: svc.startMQ, Stop: svc.stopMQ},
+		lifecycle.Component{
+			Name:    ComponentOutbox,
+			Depends: []string{ComponentDB, ComponentRedis, ComponentMQ},
+			Start:   svc.startOutbox,
+			Stop:    svc.stopOutbox,
+		},
+		lifecycle.Component{
+			Name:    ComponentContainer,
+			Depends: []string{ComponentDB, ComponentRedis, ComponentMQ},
+			Start:   svc.startContainer,
+		},
+		lifecycle.Component{
+			Name:    ComponentMQConsumer,
+			Depends: []string{ComponentMQ, ComponentContainer},
+			Start:   svc.startMQConsumer,
+			Stop:    svc.stopMQConsumer,
+		},
+	)
+
+	return svc
+}
+
+// Start 按依赖顺序启动所有已注册且未禁用的组件，启动错误汇总后返回
+func (svc *SvcContext) Start(ctx context.Context) error {
+	return svc.Components.Start(ctx)
+}
+
+func (svc *SvcContext) startTracer(ctx context.Context) error {
+	var err error
+	svc.Tracer, err = tracer.New(svc.Conf.App.Name, svc.Conf.App.Env, svc.Conf.Trace.Endpoint, svc.Conf.Trace.UrlPath)
+	return err
+}
+
+func (svc *SvcContext) stopTracer(ctx context.Context) error {
+	return svc.Tracer.Shutdown(ctx)
+}
+
+func (svc *SvcContext) startDB(ctx context.Context) error {
+	var err error
+	svc.DBEngine, err = xsql.Open(svc.Conf.DB)
+	if err != nil {
+		return err
+	}
+
+	for alias, db := range svc.DBEngine.Gorm {
+		svc.Health.Register("db:"+alias, health.GormChecker(db))
+	}
+	for alias, db := range svc.DBEngine.Mongo {
+		svc.Health.Register("mongodb:"+alias, health.MongoChecker(db))
+	}
+	return nil
+}
+
+func (svc *SvcContext) stopDB(ctx context.Context) error {
+	return svc.DBEngine.Close()
+}
+
+func (svc *SvcContext) startRedis(ctx context.Context) error {
+	var err error
+	svc.RedisClient, err = xredis.Open(svc.Conf.Redis)
+	if err != nil {
+		return err
+	}
+
+	for alias, client := range svc.RedisClient.Clients() {
+		svc.Health.Register("redis:"+alias, health.RedisChecker(client))
+	}
+	return nil
+}
+
+func (svc *SvcContext) stopRedis(ctx context.Context) error {
+	return svc.RedisClient.Close()
+}
+
+// startMQ 注册队列，并为队列用到的每个消息中间件创建客户端
+func (svc *SvcContext) startMQ(ctx context.Context) error {
+	svc.MQClient = xmq.NewClient(svc.Logger, svc.Conf.MQ.Default, svc.Conf.MQ.Queues)
+	mq.RegisterQueue(svc.MQClient)
+
+	consumers := make(map[string]xmq.ConsumerConfig, len(svc.Conf.MQ.Consumers))
+	for topic, c := range svc.Conf.MQ.Consumers {
+		consumers[topic] = xmq.ConsumerConfig{
+			Concurrency:    c.Concurrency,
+			MaxConcurrency: c.MaxConcurrency,
+			Paused:         c.Paused,
+			Ordered:        c.Ordered,
+			OrderedFailure: c.OrderedFailure,
+			BatchLimit:     c.BatchLimit,
+		}
+	}
+	svc.MQClient.SetConsumerConfig(consumers)
+
+	for _, name := range svc.MQClient.BrokerNames() {
+		broker, err := svc.newBroker(name, svc.MQClient.Queues(name))
+		if err != nil {
+			return fmt.Errorf("mq %s: %w", name, err)
+		}
+		svc.MQClient.AddBroker(name, broker)
+		svc.Health.Register("mq:"+name, health.CheckerFunc(broker.Ping))
+	}
+
+	// 死信队列，未启用 redis 时超过重试次数的消息被丢弃
+	if client, err := svc.redisClient(svc.Conf.MQ.DeadLetter.Redis); err == nil {
+		svc.DeadLetters = deadletter.NewRedis(client, svc.Conf.MQ.DeadLetter.Prefix)
+		svc.MQClient.SetDeadLetterQueue(svc.DeadLetters)
+	}
+
+	return svc.newIdempotency()
+}
+
+// newIdempotency 创建消费幂等存储，未配置时不启用
+func (svc *SvcContext) newIdempotency() error {
+	conf := svc.Conf.MQ.Idempotent
+
+	var store xmq.IdempotencyStore
+	switch conf.Store {
+	case "":
+		return nil
+	case "redis":
+		client, err := svc.redisClient(conf.Redis)
+		if err != nil {
+			return fmt.Errorf("mq idempotent: %w", err)
+		}
+		store = idempotent.NewRedis(client, conf.Prefix)
+	case "sql":
+		if svc.DBEngine == nil {
+			return errors.New("mq idempotent: xsql is not enabled")
+		}
+		dbName := conf.DB
+		if dbName == "" {
+			dbName = "default"
+		}
+		db, ok := svc.DBEngine.Gorm[dbName]
+		if !ok {
+			return fmt.Errorf("mq idempotent: db %s not found", dbName)
+		}
+		s := idempotent.NewSQL(db, conf.Table)
+		if conf.AutoMigrate {
+			if err := s.Migrate(); err != nil {
+				return fmt.Errorf("mq idempotent: migrate: %w", err)
+			}
+		}
+		store = s
+	default:
+		return fmt.Errorf("mq idempotent: unknown store %s", conf.Store)
+	}
+
+	svc.Idempotency = xmq.NewIdempotency(store, time.Duration(conf.Lease)*time.Second, time.Duration(conf.Retention)*time.Hour)
+	svc.MQClient.SetIdempotency(svc.Idempotency)
+	return nil
+}
+
+func (svc *SvcContext) stopMQ(ctx context.Context) error {
+	return svc.MQClient.Close()
+}
+
+// startOutbox 创建 outbox 生产者并启动 relay，未启用时不创建
+func (svc *SvcContext) startOutbox(ctx context.Context) error {
+	conf := svc.Conf.MQ.Outbox
+	if !conf.Enable {
+		return nil
+	}
+
+	dbName := conf.DB
+	if dbName == "" {
+		dbName = "default"
+	}
+	db, ok := svc.DBEngine.Gorm[dbName]
+	if !ok {
+		return fmt.Errorf("outbox: db %s not found", dbName)
+	}
+	if conf.AutoMigrate {
+		if err := outbox.Migrate(db, conf.Table); err != nil {
+			return fmt.Errorf("outbox: migrate: %w", err)
+		}
+	}
+	client, err := svc.redisClient(conf.Redis)
+	if err != nil {
+		return fmt.Errorf("outbox: %w", err)
+	}
+
+	svc.outboxRelay, err = outbox.NewRelay(conf, db, svc.MQClient, client, svc.Logger)
+	if err != nil {
+		return err
+	}
+	svc.Outbox = outbox.NewProducer(svc.MQClient, types.DB(dbName), conf.Table)
+	svc.outboxRelay.Start()
+	return nil
+}
+
+func (svc *SvcContext) stopOutbox(ctx context.Context) error {
+	if svc.outboxRelay == nil {
+		return nil
+	}
+	return svc.outboxRelay.Stop(ctx)
+}
+
+// newBroker 按名称创建消息中间件，queues 为路由到该中间件的队列
+func (svc *SvcContext) newBroker(name string, queues []queue.Queue) (xmq.Broker, error) {
+	conf := svc.Conf.MQ
+
+	var (
+		driver xmq.Driver
+		err    error
+	)
+	switch name {
+	case xmq.BrokerRocketMQ:
+		if svc.RedisClient == nil {
+			return nil, errors.New("redis is required")
+		}
+		client, err := rocketmq.New(svc.Conf, svc.Logger, svc.RedisClient.Default(), func(client *rocketmq.Client) {
+			client.AddQueue(queues...)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	case xmq.BrokerKafka:
+		driver, err = kafka.New(conf.Kafka)
+	case xmq.BrokerRabbitMQ:
+		driver, err = rabbitmq.New(conf.RabbitMQ)
+	case xmq.BrokerRedis:
+		var client *redis.Client
+		client, err = svc.redisClient(conf.Redis.Redis)
+		if err != nil {
+			return nil, err
+		}
+		driver, err = redisstream.New(client, conf.Redis)
+	case xmq.BrokerMemory:
+		driver, err = memory.New(conf.Memory)
+	default:
+		return nil, fmt.Errorf("unknown broker %s", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return xmq.NewBroker(name, driver, queues, svc.Logger, xmq.WithGroupName(func(groupId string) string {
+		return xmq.GroupName(groupId, svc.Conf.App.Env, conf.Env)
+	}))
+}
+
+// startContainer 组装仓储、工具与 grpc 客户端，未启用的组件以 nil 注入
+func (svc *SvcContext) startContainer(ctx context.Context) error {
 	svc.Repo = repository.Register(svc.DBEngine, svc.Logger)
 
 	svc.Tool = tool.Register(&tool_data.SvcContext{
-		Conf:        c,
+		Conf:        svc.Conf,
 		Logger:      svc.Logger,
 		RedisClient: svc.RedisClient,
 		Repo:        svc.Repo,
@@ -51,17 +329,55 @@ func NewSvcContext(c config.Conf, logger *xlog.Log) *SvcContext {
 
 	xsql.SetNotifier(svc.Tool.DingtalkTool)
 
-	svc.MQClient.SetNotifier(svc.Tool.DingtalkTool)
-	svc.MQClient.ConsumerRun(mq.ConsumerHandler)
-	// 客户端
-	grpcClient := grpc.Register(c, svc.Ctx)
+	if svc.MQClient != nil {
+		svc.MQClient.SetNotifier(svc.Tool.DingtalkTool)
+	}
 
 	// grpc客户端
-	svc.Grpc = grpcClient
+	svc.Grpc = grpc.Register(svc.Conf, svc.Ctx)
+
+	thread.SetNotifier(svc.Conf.App.Name, svc.Conf.App.Env, svc.Conf.App.ServerNumber, svc.Tool.DingtalkTool.AlarmRobot)
 
-	tracer.NewOpentelemetry(c.App.Name, c.App.Env, c.Trace.Endpoint, c.Trace.UrlPath)
+	return svc.newLimiter()
+}
 
-	thread.SetNotifier(c.App.Name, c.App.Env, c.App.ServerNumber, svc.Tool.DingtalkTool.AlarmRobot)
+// newLimiter 创建分布式限流器，未配置规则或未启用 redis 时不限流
+func (svc *SvcContext) newLimiter() error {
+	if len(svc.Conf.RateLimit.Rules) == 0 || svc.RedisClient == nil {
+		return nil
+	}
 
-	return svc
+	client, err := svc.redisClient(svc.Conf.RateLimit.Redis)
+	if err != nil {
+		return fmt.Errorf("ratelimit: %w", err)
+	}
+
+	svc.Limiter, err = limiter.New(client, svc.Conf.RateLimit, svc.Conf.App.Key, redislimit.WithErrorHandler(func(err error) {
+		svc.Logger.Errorf("ratelimit: redis unavailable, request allowed: %+v", err)
+	}))
+	return err
+}
+
+// redisClient 按别名获取 redis 客户端，别名为空时使用 default
+func (svc *SvcContext) redisClient(alias string) (*redis.Client, error) {
+	if svc.RedisClient == nil {
+		return nil, errors.New("redis is not enabled")
+	}
+	if alias == "" {
+		alias = "default"
+	}
+	client, ok := svc.RedisClient.Clients()[alias]
+	if !ok {
+		return nil, fmt.Errorf("redis %s not found", alias)
+	}
+	return client, nil
+}
+
+func (svc *SvcContext) startMQConsumer(ctx context.Context) error {
+	svc.MQClient.ConsumerRun(mq.ConsumerHandler)
+	return nil
+}
+
+func (svc *SvcContext) stopMQConsumer(ctx context.Context) error {
+	return svc.MQClient.Shutdown(ctx)
 }
@@ -2,11 +2,12 @@ package demo_service
 
 import (
 	"context"
+	"go-framework/internal/data/demo_data"
 	"go-framework/internal/server"
 )
 
 type DemoServiceImpl interface {
-	Demo(c context.Context) (interface{}, error)
+	Demo(c context.Context, req *demo_data.DemoRequest) (*demo_data.DemoResponse, error)
 }
 
 type DemoService struct {
@@ -17,7 +18,7 @@ func NewDemoService(svc *server.SvcContext) *DemoService {
 	return &DemoService{svc: svc}
 }
 
-func (s *DemoService) Demo(ctx context.Context) (interface{}, error) {
+func (s *DemoService) Demo(ctx context.Context, req *demo_data.DemoRequest) (*demo_data.DemoResponse, error) {
 
-	return make(map[string]interface{}), nil
+	return &demo_data.DemoResponse{}, nil
 }
@@ -1,26 +1,38 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/judwhite/go-svc"
 	"go-framework/config"
+	"go-framework/cron"
 	"go-framework/internal"
 	"go-framework/internal/router"
 	"go-framework/internal/server"
+	grpcmiddleware "go-framework/pkg/grpc/middleware"
+	grpcserver "go-framework/pkg/grpc/server"
 	"go-framework/util/binder"
+	utilcron "go-framework/util/cron"
+	"go-framework/util/lifecycle"
 	"go-framework/util/xconfig"
 	"go-framework/util/xlog"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"go.opentelemetry.io/otel/propagation"
+	"net"
+	"net/http"
 	"os"
 	"path/filepath"
 	"sync"
 	"syscall"
+	"time"
 )
 
+const defaultShutdownTimeout = 30 * time.Second
+
 var confFile = flag.String("file", "", "input file path")
 
 type logicProgram struct {
@@ -53,17 +65,104 @@ func (p *logicProgram) Start() error {
 	logger := xlog.NewLogger(c.Log.Path, c.App.Name)
 
 	p.svcContext = server.NewSvcContext(c, logger)
+	registerComponents(p.svcContext)
 
-	appCxt := internal.Register(p.svcContext)
-
-	go func() {
-		newApp(c, appCxt)
-	}()
+	return p.svcContext.Start(context.Background())
+}
 
-	return nil
+// registerComponents 注册应用层组件，就绪标记最后启动、最先关闭，关闭时先摘除流量再停止http服务
+func registerComponents(svcCtx *server.SvcContext) {
+	var appCxt *internal.AppContent
+	var crontab *utilcron.Cron
+	var rpcServer *grpcserver.Server
+	var httpServer *http.Server
+
+	svcCtx.Components.Register(
+		lifecycle.Component{
+			Name:    server.ComponentApp,
+			Depends: []string{server.ComponentContainer},
+			Start: func(ctx context.Context) error {
+				appCxt = internal.Register(svcCtx)
+				return nil
+			},
+		},
+		lifecycle.Component{
+			Name:    server.ComponentCron,
+			Depends: []string{server.ComponentRedis, server.ComponentApp},
+			Start: func(ctx context.Context) error {
+				crontab = cron.Register(svcCtx.RedisClient.Default(), svcCtx.Conf.App.Name, svcCtx.Logger)
+				crontab.Start()
+				return nil
+			},
+			Stop: func(ctx context.Context) error {
+				return crontab.Stop(ctx)
+			},
+		},
+		lifecycle.Component{
+			Name:    server.ComponentGrpc,
+			Depends: []string{server.ComponentApp},
+			Start: func(ctx context.Context) error {
+				if svcCtx.Conf.Server.Rpc.Addr == "" {
+					return nil
+				}
+				rpcServer = grpcserver.NewServer(svcCtx.Conf, svcCtx,
+					grpcserver.WithMiddleware(grpcmiddleware.RateLimiter),
+					grpcserver.WithStreamMiddleware(grpcmiddleware.StreamRateLimiter),
+				)
+				return rpcServer.Start(ctx)
+			},
+			Stop: func(ctx context.Context) error {
+				if rpcServer == nil {
+					return nil
+				}
+				return rpcServer.Stop(ctx)
+			},
+		},
+		lifecycle.Component{
+			Name:    server.ComponentHttp,
+			Depends: []string{server.ComponentApp},
+			Start: func(ctx context.Context) error {
+				httpServer = newApp(svcCtx.Conf, appCxt)
+				lis, err := net.Listen("tcp", httpServer.Addr)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(gin.DefaultWriter, "Listening and serving HTTP on %s\n", httpServer.Addr)
+				go func() {
+					if err := httpServer.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+						svcCtx.Logger.Errorf("http server exited: %+v", err)
+					}
+				}()
+				return nil
+			},
+			Stop: func(ctx context.Context) error {
+				return httpServer.Shutdown(ctx)
+			},
+		},
+		lifecycle.Component{
+			Name:    server.ComponentHealth,
+			Depends: []string{server.ComponentApp},
+			Start: func(ctx context.Context) error {
+				svcCtx.Health.MarkReady()
+				return nil
+			},
+			Stop: func(ctx context.Context) error {
+				svcCtx.Health.Shutdown()
+				delay := time.Duration(svcCtx.Conf.Server.ShutdownDelay) * time.Second
+				if delay <= 0 {
+					return nil
+				}
+				select {
+				case <-time.After(delay):
+				case <-ctx.Done():
+				}
+				return nil
+			},
+		},
+	)
 }
 
-func newApp(c config.Conf, appCxt *internal.AppContent) {
+func newApp(c config.Conf, appCxt *internal.AppContent) *http.Server {
 	// 创建并配置验证器
 	r := gin.New()
 
@@ -73,17 +172,27 @@ func newApp(c config.Conf, appCxt *internal.AppContent) {
 
 	router.Register(r, appCxt)
 
-	err := r.Run(c.Server.Http.Addr)
-
-	if err != nil {
-		panic(err)
+	return &http.Server{
+		Addr:    c.Server.Http.Addr,
+		Handler: r,
 	}
 }
 
+// Stop 停止接收流量，等待处理中的请求、消息与任务完成后按依赖逆序释放资源
 func (p *logicProgram) Stop() error {
+	var err error
 	p.once.Do(func() {
-		//defer p.svcContext.RedisClient.Close()
-		//defer p.svcContext.DBEngine.Close()
+		if p.svcContext == nil {
+			return
+		}
+		timeout := defaultShutdownTimeout
+		if p.svcContext.Conf.Server.ShutdownTimeout > 0 {
+			timeout = time.Duration(p.svcContext.Conf.Server.ShutdownTimeout) * time.Second
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		err = p.svcContext.Lifecycle.Stop(ctx)
 	})
-	return nil
+	return err
 }
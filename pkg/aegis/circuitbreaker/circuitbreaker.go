@@ -0,0 +1,64 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrNotAllowed error not allowed.
+var ErrNotAllowed = errors.New("circuitbreaker: not allowed for circuit open")
+
+// State .
+type State int32
+
+const (
+	// StateClosed when circuit breaker closed, request allowed.
+	StateClosed State = iota
+	// StateOpen when circuit breaker open, request not allowed.
+	StateOpen
+	// StateHalfOpen when circuit breaker half open, probe requests allowed.
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreaker is a circuit breaker.
+type CircuitBreaker interface {
+	// Allow returns ErrNotAllowed when the request should be rejected.
+	Allow() error
+	// MarkSuccess records a successful request.
+	MarkSuccess()
+	// MarkFailed records a failed request.
+	MarkFailed()
+}
+
+// Group holds one circuit breaker per key, e.g. per endpoint or operation.
+type Group struct {
+	new      func() CircuitBreaker
+	breakers sync.Map
+}
+
+// NewGroup creates a group that lazily builds breakers with fn.
+func NewGroup(fn func() CircuitBreaker) *Group {
+	return &Group{new: fn}
+}
+
+// Get returns the circuit breaker of key, creating it if absent.
+func (g *Group) Get(key string) CircuitBreaker {
+	if cb, ok := g.breakers.Load(key); ok {
+		return cb.(CircuitBreaker)
+	}
+	cb, _ := g.breakers.LoadOrStore(key, g.new())
+	return cb.(CircuitBreaker)
+}
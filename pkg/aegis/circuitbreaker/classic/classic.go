@@ -0,0 +1,206 @@
+package classic
+
+import (
+	"go-framework/pkg/aegis/circuitbreaker"
+	"go-framework/pkg/aegis/pkg/window"
+	"sync"
+	"time"
+)
+
+// Option is classic breaker option function.
+type Option func(*options)
+
+// options is breaker configuration.
+type options struct {
+	ratio       float64
+	request     int64
+	openTimeout time.Duration
+	halfOpen    int64
+	bucket      int
+	window      time.Duration
+}
+
+// WithFailureRatio with the failure ratio that trips the breaker, default 0.5.
+func WithFailureRatio(r float64) Option {
+	return func(c *options) {
+		c.ratio = r
+	}
+}
+
+// WithRequest with the minimum number of requests in a window before the
+// breaker may trip, default 20.
+func WithRequest(r int64) Option {
+	return func(c *options) {
+		c.request = r
+	}
+}
+
+// WithOpenTimeout with how long the breaker stays open before probing, default 5s.
+func WithOpenTimeout(d time.Duration) Option {
+	return func(c *options) {
+		c.openTimeout = d
+	}
+}
+
+// WithHalfOpenRequests with the number of probe requests allowed in half-open
+// state, all of them must succeed to close the breaker, default 5.
+func WithHalfOpenRequests(n int64) Option {
+	return func(c *options) {
+		c.halfOpen = n
+	}
+}
+
+// WithWindow with the duration size of the statistical window.
+func WithWindow(d time.Duration) Option {
+	return func(c *options) {
+		c.window = d
+	}
+}
+
+// WithBucket set the bucket number in a window duration.
+func WithBucket(b int) Option {
+	return func(c *options) {
+		c.bucket = b
+	}
+}
+
+// Breaker is a closed/open/half-open circuit breaker.
+//
+// Closed: requests are allowed, the breaker opens once the window holds at
+// least request samples and the failure ratio reaches ratio.
+// Open: requests are rejected until openTimeout elapses, then half-open.
+// HalfOpen: up to halfOpen probe requests are allowed, any failure opens the
+// breaker again and halfOpen successes close it.
+type Breaker struct {
+	opt  options
+	stat window.RollingCounter
+
+	mu       sync.Mutex
+	state    circuitbreaker.State
+	openedAt time.Time
+	probes   int64
+	passed   int64
+}
+
+var _ circuitbreaker.CircuitBreaker = (*Breaker)(nil)
+
+// NewBreaker return a classic Breaker with options.
+func NewBreaker(opts ...Option) *Breaker {
+	opt := options{
+		ratio:       0.5,
+		request:     20,
+		openTimeout: 5 * time.Second,
+		halfOpen:    5,
+		bucket:      10,
+		window:      10 * time.Second,
+	}
+	for _, o := range opts {
+		o(&opt)
+	}
+	return &Breaker{
+		opt:   opt,
+		stat:  newCounter(opt),
+		state: circuitbreaker.StateClosed,
+	}
+}
+
+func newCounter(opt options) window.RollingCounter {
+	return window.NewRollingCounter(window.RollingCounterOpts{
+		Size:           opt.bucket,
+		BucketDuration: time.Duration(int64(opt.window) / int64(opt.bucket)),
+	})
+}
+
+// State returns the current state of breaker.
+func (b *Breaker) State() circuitbreaker.State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance(time.Now())
+	return b.state
+}
+
+// Allow request if error returns nil.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance(time.Now())
+	switch b.state {
+	case circuitbreaker.StateOpen:
+		return circuitbreaker.ErrNotAllowed
+	case circuitbreaker.StateHalfOpen:
+		if b.probes >= b.opt.halfOpen {
+			return circuitbreaker.ErrNotAllowed
+		}
+		b.probes++
+	}
+	return nil
+}
+
+// MarkSuccess mark request is success.
+func (b *Breaker) MarkSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitbreaker.StateClosed:
+		b.stat.Add(1)
+	case circuitbreaker.StateHalfOpen:
+		b.passed++
+		if b.passed >= b.opt.halfOpen {
+			b.close()
+		}
+	}
+}
+
+// MarkFailed mark request is failed.
+func (b *Breaker) MarkFailed() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitbreaker.StateClosed:
+		b.stat.Add(0)
+		if b.tripped() {
+			b.open(time.Now())
+		}
+	case circuitbreaker.StateHalfOpen:
+		b.open(time.Now())
+	}
+}
+
+// advance moves an open breaker to half-open once openTimeout elapsed.
+func (b *Breaker) advance(now time.Time) {
+	if b.state == circuitbreaker.StateOpen && now.Sub(b.openedAt) >= b.opt.openTimeout {
+		b.state = circuitbreaker.StateHalfOpen
+		b.probes, b.passed = 0, 0
+	}
+}
+
+func (b *Breaker) tripped() bool {
+	var success, total int64
+	b.stat.Reduce(func(iterator window.Iterator) float64 {
+		for iterator.Next() {
+			bucket := iterator.Bucket()
+			total += bucket.Count
+			for _, p := range bucket.Points {
+				success += int64(p)
+			}
+		}
+		return 0
+	})
+	if total < b.opt.request {
+		return false
+	}
+	return float64(total-success)/float64(total) >= b.opt.ratio
+}
+
+func (b *Breaker) open(now time.Time) {
+	b.state = circuitbreaker.StateOpen
+	b.openedAt = now
+}
+
+func (b *Breaker) close() {
+	b.state = circuitbreaker.StateClosed
+	b.stat = newCounter(b.opt)
+}
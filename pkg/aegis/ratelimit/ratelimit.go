@@ -2,6 +2,8 @@ package ratelimit
 
 import (
 	"errors"
+	"fmt"
+	"time"
 )
 
 var (
@@ -22,3 +24,26 @@ type DoneInfo struct {
 type Limiter interface {
 	Allow() (DoneFunc, error)
 }
+
+// ExceededError is returned by limiters that know when the next request
+// may be allowed. errors.Is(err, ErrLimitExceed) reports true for it.
+type ExceededError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrLimitExceed, e.RetryAfter)
+}
+
+func (e *ExceededError) Is(target error) bool {
+	return target == ErrLimitExceed
+}
+
+// RetryAfter returns the wait duration carried by err, if any.
+func RetryAfter(err error) (time.Duration, bool) {
+	var e *ExceededError
+	if errors.As(err, &e) {
+		return e.RetryAfter, true
+	}
+	return 0, false
+}
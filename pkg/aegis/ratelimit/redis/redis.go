@@ -0,0 +1,115 @@
+package redis
+
+import (
+	"context"
+	goredis "github.com/go-redis/redis/v8"
+	"go-framework/pkg/aegis/ratelimit"
+	"time"
+)
+
+const (
+	defaultPrefix  = "ratelimit"
+	defaultTimeout = 100 * time.Millisecond
+)
+
+// algorithm takes one permit for key, returning whether it was allowed and
+// how long the caller should wait before retrying.
+type algorithm interface {
+	take(ctx context.Context, key string) (bool, time.Duration, error)
+}
+
+type options struct {
+	prefix       string
+	timeout      time.Duration
+	errorHandler func(error)
+}
+
+// Option is redis limiter option.
+type Option func(*options)
+
+// WithPrefix sets the redis key prefix.
+func WithPrefix(prefix string) Option {
+	return func(o *options) {
+		o.prefix = prefix
+	}
+}
+
+// WithTimeout bounds each redis round trip.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithErrorHandler is called when redis is unavailable. The limiter fails
+// open in that case so that a redis outage does not reject all traffic.
+func WithErrorHandler(fn func(error)) Option {
+	return func(o *options) {
+		o.errorHandler = fn
+	}
+}
+
+// Limiter is a distributed limiter backed by redis. Every key is counted
+// independently across all instances sharing the same redis.
+type Limiter struct {
+	client goredis.Scripter
+	alg    algorithm
+	opts   options
+}
+
+func newLimiter(client goredis.Scripter, alg algorithm, opts []Option) *Limiter {
+	o := options{
+		prefix:  defaultPrefix,
+		timeout: defaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Limiter{client: client, alg: alg, opts: o}
+}
+
+// Take takes one permit for key. It returns a *ratelimit.ExceededError
+// when the limit is exceeded.
+func (l *Limiter) Take(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, l.opts.timeout)
+	defer cancel()
+
+	ok, retryAfter, err := l.alg.take(ctx, l.opts.prefix+":"+key)
+	if err != nil {
+		if l.opts.errorHandler != nil {
+			l.opts.errorHandler(err)
+		}
+		return nil
+	}
+	if !ok {
+		return &ratelimit.ExceededError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// Key binds the limiter to a fixed key so it can be used as ratelimit.Limiter.
+func (l *Limiter) Key(key string) ratelimit.Limiter {
+	return &keyLimiter{limiter: l, key: key}
+}
+
+type keyLimiter struct {
+	limiter *Limiter
+	key     string
+}
+
+func (k *keyLimiter) Allow() (ratelimit.DoneFunc, error) {
+	if err := k.limiter.Take(context.Background(), k.key); err != nil {
+		return nil, err
+	}
+	return func(ratelimit.DoneInfo) {}, nil
+}
+
+func toResult(v interface{}) (bool, time.Duration) {
+	res, _ := v.([]interface{})
+	if len(res) != 2 {
+		return true, 0
+	}
+	allowed, _ := res[0].(int64)
+	retry, _ := res[1].(int64)
+	return allowed == 1, time.Duration(retry) * time.Millisecond
+}
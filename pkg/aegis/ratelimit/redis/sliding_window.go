@@ -0,0 +1,61 @@
+package redis
+
+import (
+	"context"
+	goredis "github.com/go-redis/redis/v8"
+	"math/rand"
+	"strconv"
+	"time"
+)
+
+// slidingWindowScript counts requests within the last window using a sorted
+// set scored by timestamp. KEYS[1] window; ARGV limit, window(ms), member.
+// The clock is read from redis so that skew between instances does not
+// move the window.
+var slidingWindowScript = goredis.NewScript(`
+redis.replicate_commands()
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+redis.call("ZREMRANGEBYSCORE", KEYS[1], 0, now - window)
+if redis.call("ZCARD", KEYS[1]) < limit then
+	redis.call("ZADD", KEYS[1], now, ARGV[3])
+	redis.call("PEXPIRE", KEYS[1], window)
+	return {1, 0}
+end
+
+local retry = window
+local oldest = redis.call("ZRANGE", KEYS[1], 0, 0, "WITHSCORES")
+if oldest[2] then
+	retry = math.max(1, tonumber(oldest[2]) + window - now)
+end
+return {0, retry}
+`)
+
+type slidingWindow struct {
+	client goredis.Scripter
+	limit  int
+	window time.Duration
+}
+
+// NewSlidingWindow creates a sliding window limiter allowing limit requests
+// within any window.
+func NewSlidingWindow(client goredis.Scripter, limit int, window time.Duration, opts ...Option) *Limiter {
+	if window <= 0 {
+		window = time.Second
+	}
+	return newLimiter(client, &slidingWindow{client: client, limit: limit, window: window}, opts)
+}
+
+func (w *slidingWindow) take(ctx context.Context, key string) (bool, time.Duration, error) {
+	// the member only needs to be unique, the score comes from the redis clock
+	member := strconv.FormatInt(time.Now().UnixNano(), 36) + "-" + strconv.FormatInt(rand.Int63(), 36)
+	v, err := slidingWindowScript.Run(ctx, w.client, []string{key}, w.limit, w.window.Milliseconds(), member).Result()
+	if err != nil {
+		return false, 0, err
+	}
+	ok, retry := toResult(v)
+	return ok, retry, nil
+}
@@ -0,0 +1,68 @@
+package redis
+
+import (
+	"context"
+	goredis "github.com/go-redis/redis/v8"
+	"time"
+)
+
+// tokenBucketScript refills the bucket by the elapsed time and takes one
+// token. KEYS[1] bucket; ARGV rate(tokens/s), burst. The clock is read from
+// redis so that skew between instances does not affect the refill.
+var tokenBucketScript = goredis.NewScript(`
+redis.replicate_commands()
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local data = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(data[1])
+local ts = tonumber(data[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) * 1000 / rate)
+end
+
+redis.call("HMSET", KEYS[1], "tokens", tokens, "ts", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return {allowed, retry}
+`)
+
+type tokenBucket struct {
+	client goredis.Scripter
+	rate   float64
+	burst  int
+}
+
+// NewTokenBucket creates a token bucket limiter refilling rate tokens per
+// second up to burst tokens.
+func NewTokenBucket(client goredis.Scripter, rate float64, burst int, opts ...Option) *Limiter {
+	if burst <= 0 {
+		burst = int(rate)
+	}
+	if burst <= 0 {
+		burst = 1
+	}
+	return newLimiter(client, &tokenBucket{client: client, rate: rate, burst: burst}, opts)
+}
+
+func (b *tokenBucket) take(ctx context.Context, key string) (bool, time.Duration, error) {
+	v, err := tokenBucketScript.Run(ctx, b.client, []string{key}, b.rate, b.burst).Result()
+	if err != nil {
+		return false, 0, err
+	}
+	ok, retry := toResult(v)
+	return ok, retry, nil
+}
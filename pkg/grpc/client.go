@@ -2,6 +2,7 @@ package grpc
 
 import (
 	"context"
+	"go-framework/pkg/middleware/circuitbreaker"
 	"go-framework/pkg/registry"
 	"go-framework/pkg/registry/etcd"
 	"go-framework/pkg/rpc"
@@ -33,7 +34,11 @@ func NewClient(c *rpc.ClientConf, ctx context.Context) *googleGrpc.ClientConn {
 		endpoint = EtcdEndpointPrefix + endpoint
 	}
 
-	conn, err := grpc.DialWithInsecure(ctx, c.Insecure, grpc.WithEndpoint(endpoint), grpc.WithDiscovery(dis))
+	opts := []grpc.ClientOption{grpc.WithEndpoint(endpoint), grpc.WithDiscovery(dis)}
+	if c.Breaker {
+		opts = append(opts, grpc.WithMiddleware(circuitbreaker.Client()))
+	}
+	conn, err := grpc.DialWithInsecure(ctx, c.Insecure, opts...)
 	if err != nil {
 		panic(err)
 	}
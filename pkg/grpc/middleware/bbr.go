@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"context"
+	"go-framework/internal/server"
+	"go-framework/pkg/aegis/ratelimit"
+	"go-framework/pkg/aegis/ratelimit/bbr"
+	"go-framework/util/metrics"
+	"google.golang.org/grpc"
+)
+
+var (
+	_ Middleware       = BBR
+	_ StreamMiddleware = StreamBBR
+)
+
+// BBR 按 CPU 使用率与处理中请求数进行单机自适应限流，超出限制时返回 ResourceExhausted
+func BBR(svc *server.SvcContext) grpc.UnaryServerInterceptor {
+	limiter := bbr.NewLimiter()
+	metrics.RegisterLimiter("grpc", limiter)
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		done, err := limiter.Allow()
+		if err != nil {
+			return nil, ResourceExhausted(ctx, err)
+		}
+		reply, err := handler(ctx, req)
+		done(ratelimit.DoneInfo{Err: err})
+		return reply, err
+	}
+}
+
+// StreamBBR 流式调用的自适应限流，以整个流的处理时长计入统计
+func StreamBBR(svc *server.SvcContext) grpc.StreamServerInterceptor {
+	limiter := bbr.NewLimiter()
+	metrics.RegisterLimiter("grpc_stream", limiter)
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		done, err := limiter.Allow()
+		if err != nil {
+			return ResourceExhausted(ss.Context(), err)
+		}
+		err = handler(srv, ss)
+		done(ratelimit.DoneInfo{Err: err})
+		return err
+	}
+}
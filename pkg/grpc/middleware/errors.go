@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"go-framework/internal/server"
+	"go-framework/util/xerror"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	_ Middleware       = Errors
+	_ StreamMiddleware = StreamErrors
+)
+
+// Errors 将处理函数返回的错误转换为 gRPC 状态
+func Errors(svc *server.SvcContext) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		reply, err := handler(ctx, req)
+		if err != nil {
+			return reply, FromError(err).Err()
+		}
+		return reply, nil
+	}
+}
+
+// StreamErrors 流式调用的错误转换
+func StreamErrors(svc *server.SvcContext) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := handler(srv, ss); err != nil {
+			return FromError(err).Err()
+		}
+		return nil
+	}
+}
+
+// FromError 错误转换为 gRPC 状态：xerror 按 Code 映射状态码并携带 ErrorInfo 详情，
+// context 的取消与超时分别对应 Canceled 与 DeadlineExceeded，其余错误保持原状态
+func FromError(err error) *status.Status {
+	if err == nil {
+		return status.New(codes.OK, "")
+	}
+	if se := new(xerror.Error); errors.As(err, &se) {
+		return se.GRPCStatus()
+	}
+	if st, ok := status.FromError(err); ok {
+		return st
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.New(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.New(codes.DeadlineExceeded, err.Error())
+	}
+	return status.New(codes.Unknown, err.Error())
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"go-framework/internal/server"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"time"
+)
+
+var (
+	_ Middleware       = Logging
+	_ StreamMiddleware = StreamLogging
+)
+
+// Logging 记录一元调用的访问日志
+func Logging(svc *server.SvcContext) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		reply, err := handler(ctx, req)
+		accessLog(ctx, svc, "unary", info.FullMethod, start, err)
+		return reply, err
+	}
+}
+
+// StreamLogging 记录流式调用的访问日志
+func StreamLogging(svc *server.SvcContext) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		accessLog(ss.Context(), svc, "stream", info.FullMethod, start, err)
+		return err
+	}
+}
+
+// accessLog 以键值对输出访问日志，服务端错误记为 error 级别，其余为 info 级别
+func accessLog(ctx context.Context, svc *server.SvcContext, kind, method string, start time.Time, err error) {
+	st := FromError(err)
+	md, _ := metadata.FromIncomingContext(ctx)
+	fields := []any{
+		"kind", "grpc." + kind,
+		"operation", method,
+		"code", st.Code().String(),
+		"peer", clientIP(ctx, md),
+		"latency", time.Since(start).Seconds(),
+	}
+	if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.HasTraceID() {
+		fields = append(fields, "trace_id", spanCtx.TraceID().String())
+	}
+	if err == nil {
+		svc.Logger.Info(fields...)
+		return
+	}
+
+	fields = append(fields, "error", st.Message())
+	switch st.Code() {
+	case codes.Unknown, codes.Internal, codes.DataLoss, codes.Unavailable, codes.DeadlineExceeded:
+		svc.Logger.Error(fields...)
+	default:
+		svc.Logger.Info(fields...)
+	}
+}
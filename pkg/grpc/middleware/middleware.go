@@ -5,4 +5,18 @@ import (
 	"google.golang.org/grpc"
 )
 
+// Middleware 根据服务上下文创建一元拦截器
 type Middleware func(svc *server.SvcContext) grpc.UnaryServerInterceptor
+
+// StreamMiddleware 根据服务上下文创建流拦截器
+type StreamMiddleware func(svc *server.SvcContext) grpc.StreamServerInterceptor
+
+// Unary 内置一元拦截器，由外到内依次为：链路追踪、访问日志、错误转换、异常恢复、BBR 限流、参数校验
+func Unary() []Middleware {
+	return []Middleware{Tracing, Logging, Errors, Recovery, BBR, Validator}
+}
+
+// Stream 内置流拦截器，顺序与 Unary 一致
+func Stream() []StreamMiddleware {
+	return []StreamMiddleware{StreamTracing, StreamLogging, StreamErrors, StreamRecovery, StreamBBR, StreamValidator}
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"context"
+	"go-framework/internal/server"
+	"go-framework/util/limiter"
+	"go-framework/util/xerror/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	_ Middleware       = RateLimiter
+	_ StreamMiddleware = StreamRateLimiter
+)
+
+// RateLimiter 按配置规则进行分布式限流，超出限制时返回 ResourceExhausted，
+// 并通过 retry-after 响应头与 RetryInfo 错误详情携带重试等待时间
+func RateLimiter(svc *server.SvcContext) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := allow(ctx, svc, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamRateLimiter 流式调用的分布式限流，在建立流时检查一次
+func StreamRateLimiter(svc *server.SvcContext) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := allow(ss.Context(), svc, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func allow(ctx context.Context, svc *server.SvcContext, method string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	err := svc.Limiter.Allow(ctx, limiter.Request{
+		Operation: method,
+		ClientIP:  clientIP(ctx, md),
+		Header: func(key string) string {
+			if values := md.Get(key); len(values) > 0 {
+				return values[0]
+			}
+			return ""
+		},
+	})
+	if err != nil {
+		return ResourceExhausted(ctx, err)
+	}
+	return nil
+}
+
+// ResourceExhausted 将限流错误转换为 gRPC 状态
+func ResourceExhausted(ctx context.Context, err error) error {
+	retryAfter := limiter.RetryAfter(err)
+	_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(retryAfter)))
+
+	st := status.New(codes.ResourceExhausted, err.Error())
+	if detailed, detailErr := st.WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(time.Duration(retryAfter) * time.Second),
+	}); detailErr == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// clientIP 优先取 x-forwarded-for 中的第一个地址，其次为对端地址
+func clientIP(ctx context.Context, md metadata.MD) string {
+	if values := md.Get("x-forwarded-for"); len(values) > 0 {
+		if ip := strings.TrimSpace(strings.Split(values[0], ",")[0]); ip != "" {
+			return ip
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err == nil {
+			return host
+		}
+		return p.Addr.String()
+	}
+	return ""
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"go-framework/internal/server"
+	"go-framework/util/xerror"
+	"google.golang.org/grpc"
+	"runtime"
+)
+
+var (
+	_ Middleware       = Recovery
+	_ StreamMiddleware = StreamRecovery
+)
+
+// Recovery 捕获处理函数的 panic，记录日志并发送钉钉告警，返回 xerror.InternalServer
+func Recovery(svc *server.SvcContext) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (reply interface{}, err error) {
+		defer recoverPanic(ctx, svc, info.FullMethod, &err)
+		return handler(ctx, req)
+	}
+}
+
+// StreamRecovery 流式调用的异常恢复
+func StreamRecovery(svc *server.SvcContext) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer recoverPanic(ss.Context(), svc, info.FullMethod, &err)
+		return handler(srv, ss)
+	}
+}
+
+func recoverPanic(ctx context.Context, svc *server.SvcContext, method string, err *error) {
+	p := recover()
+	if p == nil {
+		return
+	}
+
+	buf := make([]byte, 2048)
+	n := runtime.Stack(buf, false)
+	pc, file, line, _ := runtime.Caller(2)
+	fn := runtime.FuncForPC(pc)
+	errMsg := fmt.Sprintf("method: %s; \nmessage: %+v; \nline: %s:%d; function: %s; \nstackTrace: %s", method, p, file, line, fn.Name(), buf[:n])
+	svc.Logger.Errorf(errMsg)
+
+	if svc.Tool != nil {
+		err2 := svc.Tool.DingtalkTool.SendAlarm(ctx, errMsg)
+		if err2 != nil {
+			svc.Logger.Errorf("Error sending alarm %+v", err2)
+		}
+	}
+
+	if svc.Conf.App.Env == "local" {
+		fmt.Println(errMsg)
+	}
+	*err = xerror.InternalServer(500, "系统异常")
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"context"
+	"go-framework/internal/server"
+	"go-framework/pkg/transport"
+	transportgrpc "go-framework/pkg/transport/grpc"
+	"go-framework/util/tracer"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"strings"
+)
+
+const instrumentationName = "go-framework/pkg/grpc"
+
+var (
+	_ Middleware       = Tracing
+	_ StreamMiddleware = StreamTracing
+)
+
+// Tracing 从请求元数据中提取链路上下文并创建服务端 span，
+// 业务代码可通过 transport.FromServerContext 获取调用信息，通过 tracer.Span 创建子 span
+func Tracing(svc *server.SvcContext) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, span := startSpan(ctx, info.FullMethod)
+		defer span.End()
+
+		reply, err := handler(ctx, req)
+		endSpan(span, err)
+		return reply, err
+	}
+}
+
+// StreamTracing 流式调用的链路追踪
+func StreamTracing(svc *server.SvcContext) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, span := startSpan(ss.Context(), info.FullMethod)
+		defer span.End()
+
+		err := handler(srv, transportgrpc.NewWrappedStream(ctx, ss))
+		endSpan(span, err)
+		return err
+	}
+}
+
+func startSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	if tr, ok := transport.FromServerContext(ctx); ok {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, tr.RequestHeader())
+	}
+
+	t := otel.Tracer(instrumentationName)
+	ctx, span := t.Start(ctx, strings.TrimPrefix(method, "/"), trace.WithSpanKind(trace.SpanKindServer))
+	ctx = context.WithValue(ctx, tracer.TracerKey, t)
+
+	// 与 http 响应头 TraceID 一致，应答时携带 trace-id
+	if tr, ok := transport.FromServerContext(ctx); ok && span.SpanContext().HasTraceID() {
+		tr.ReplyHeader().Set("trace-id", span.SpanContext().TraceID().String())
+	}
+
+	service, rpcMethod := splitMethod(method)
+	span.SetAttributes(
+		attribute.String("rpc.system", "grpc"),
+		attribute.String("rpc.service", service),
+		attribute.String("rpc.method", rpcMethod),
+	)
+	return ctx, span
+}
+
+func endSpan(span trace.Span, err error) {
+	st := FromError(err)
+	span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(st.Code())))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, st.Message())
+	}
+}
+
+// splitMethod 将 /package.Service/Method 拆分为服务名与方法名
+func splitMethod(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "", fullMethod
+}
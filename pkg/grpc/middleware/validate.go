@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"context"
+	"go-framework/internal/server"
+	"go-framework/util/xerror"
+	"google.golang.org/grpc"
+	"net/http"
+)
+
+var (
+	_ Middleware       = Validator
+	_ StreamMiddleware = StreamValidator
+)
+
+// validator 由 protoc-gen-validate 等工具生成的校验方法
+type validator interface {
+	Validate() error
+}
+
+// Validator 校验实现了 Validate 方法的请求，失败时返回 InvalidArgument
+func Validator(svc *server.SvcContext) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := validate(req); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamValidator 校验流中接收到的每一条消息
+func StreamValidator(svc *server.SvcContext) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &validateStream{ServerStream: ss})
+	}
+}
+
+type validateStream struct {
+	grpc.ServerStream
+}
+
+func (s *validateStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return validate(m)
+}
+
+func validate(req interface{}) error {
+	if v, ok := req.(validator); ok {
+		if err := v.Validate(); err != nil {
+			return xerror.BadRequest(http.StatusBadRequest, err.Error())
+		}
+	}
+	return nil
+}
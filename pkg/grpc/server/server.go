@@ -9,8 +9,12 @@ import (
 	"go-framework/pkg/grpc/config"
 	"go-framework/pkg/grpc/middleware"
 	"go-framework/pkg/registry"
+	transportgrpc "go-framework/pkg/transport/grpc"
+	"go-framework/util/health"
 	"go-framework/util/helper"
+	"go-framework/util/metrics"
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"io"
 	"net"
 	"os"
@@ -25,49 +29,147 @@ type Server struct {
 	RpcServer   *grpc.Server
 	config      config.Config
 	middlewares []middleware.Middleware
+	registrar   registry.Registrar
+	instance    *registry.ServiceInstance
+	health      *health.Health
 }
 
-func NewServer(c interface{}, svc *server.SvcContext, middlewares ...middleware.Middleware) *Server {
+// Option grpc 服务选项
+type Option func(o *options)
+
+type options struct {
+	unary  []middleware.Middleware
+	stream []middleware.StreamMiddleware
+}
+
+// WithMiddleware 追加一元拦截器，位于内置拦截器之后
+func WithMiddleware(m ...middleware.Middleware) Option {
+	return func(o *options) {
+		o.unary = append(o.unary, m...)
+	}
+}
+
+// WithStreamMiddleware 追加流拦截器，位于内置拦截器之后
+func WithStreamMiddleware(m ...middleware.StreamMiddleware) Option {
+	return func(o *options) {
+		o.stream = append(o.stream, m...)
+	}
+}
+
+// NewServer 创建 grpc 服务，一元与流式调用均依次经过指标、调用信息、内置拦截器与自定义拦截器
+func NewServer(c interface{}, svc *server.SvcContext, opts ...Option) *Server {
 	var con config.Config
 	err := helper.UnMarshalWithInterface(c, &con)
 	if err != nil {
 		panic(err)
 	}
 
-	var serverOpt []grpc.ServerOption
+	o := options{
+		unary:  middleware.Unary(),
+		stream: middleware.Stream(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
 
-	for _, mid := range middlewares {
-		serverOpt = append(serverOpt, grpc.ChainUnaryInterceptor(mid(svc)))
+	endpoint := GrpcHost + con.Server.Rpc.Addr
+	unary := []grpc.UnaryServerInterceptor{
+		metrics.UnaryServerInterceptor(),
+		transportgrpc.UnaryServerInterceptor(endpoint),
+	}
+	for _, mid := range o.unary {
+		unary = append(unary, mid(svc))
+	}
+	stream := []grpc.StreamServerInterceptor{
+		metrics.StreamServerInterceptor(),
+		transportgrpc.StreamServerInterceptor(endpoint),
+	}
+	for _, mid := range o.stream {
+		stream = append(stream, mid(svc))
+	}
+
+	serverOpt := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
 	}
 
 	s := &Server{
 		RpcServer: grpc.NewServer(serverOpt...),
 		config:    con,
+		health:    svc.Health,
 	}
 
+	// 标准 grpc.health.v1 健康检查服务
+	healthpb.RegisterHealthServer(s.RpcServer, health.NewGRPCServer(svc.Health))
+
 	return s
 }
 
 func (s *Server) Run() error {
+	lis, err := s.listen()
+	if err != nil {
+		return err
+	}
+
+	// 运行grpc服务
+	err = s.RpcServer.Serve(lis)
+
+	return err
+}
+
+// Start 监听端口并在后台运行grpc服务
+func (s *Server) Start(ctx context.Context) error {
+	lis, err := s.listen()
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		if err := s.RpcServer.Serve(lis); err != nil {
+			fmt.Fprintf(DefaultWriter, "GRPC server exited: %v \n", err)
+		}
+	}()
+	return nil
+}
+
+// Stop 注销服务后停止接收新请求，等待处理中的请求完成，超时后强制关闭
+func (s *Server) Stop(ctx context.Context) error {
+	var err error
+	if s.registrar != nil {
+		err = s.registrar.Deregister(ctx, s.instance)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.RpcServer.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.RpcServer.Stop()
+		err = errors.Join(err, ctx.Err())
+	}
+	return err
+}
+
+func (s *Server) listen() (net.Listener, error) {
 	if s.config.Server.Rpc.Mode == "etcd" {
 		err := s.registryEtcd()
 		if err != nil {
-			return errors.New("when connecting to etcd using gRPC, etcd is throwing an error. message: " + err.Error())
+			return nil, errors.New("when connecting to etcd using gRPC, etcd is throwing an error. message: " + err.Error())
 		}
 	}
 
 	// 监听端口
 	lis, err := net.Listen("tcp", s.config.Server.Rpc.Addr)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	fmt.Fprintf(DefaultWriter, "Listening and serving GRPC on %s \n", s.config.Server.Rpc.Addr)
-
-	// 运行grpc服务
-	err = s.RpcServer.Serve(lis)
-
-	return err
+	return lis, nil
 }
 
 func (s *Server) registryEtcd() error {
@@ -88,7 +190,14 @@ func (s *Server) registryEtcd() error {
 		Endpoints: s.getNodeEndpoints(),
 	}
 
-	return r.Register(context.Background(), &ins)
+	err = r.Register(context.Background(), &ins)
+	if err != nil {
+		return err
+	}
+	s.health.Register("etcd", r)
+	s.registrar = r
+	s.instance = &ins
+	return nil
 }
 
 func (s *Server) getNodeEndpoints() []string {
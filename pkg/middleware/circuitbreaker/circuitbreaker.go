@@ -0,0 +1,91 @@
+package circuitbreaker
+
+import (
+	"context"
+	"go-framework/pkg/aegis/circuitbreaker"
+	"go-framework/pkg/aegis/circuitbreaker/sre"
+	"go-framework/pkg/middleware"
+	"go-framework/pkg/transport"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Option is circuit breaker option.
+type Option func(*options)
+
+// WithGroup with circuit breaker group.
+// NOTE: implements generics circuitbreaker.CircuitBreaker
+func WithGroup(g *circuitbreaker.Group) Option {
+	return func(o *options) {
+		o.group = g
+	}
+}
+
+// WithCircuitBreaker with circuit breaker genFunc.
+func WithCircuitBreaker(genBreakerFunc func() circuitbreaker.CircuitBreaker) Option {
+	return func(o *options) {
+		o.group = circuitbreaker.NewGroup(genBreakerFunc)
+	}
+}
+
+// WithFailure with the function deciding whether an error counts as a failure.
+func WithFailure(fn func(error) bool) Option {
+	return func(o *options) {
+		o.failure = fn
+	}
+}
+
+type options struct {
+	group   *circuitbreaker.Group
+	failure func(error) bool
+}
+
+// Client circuitbreaker middleware will return circuitbreaker.ErrNotAllowed
+// when the circuit breaker of the endpoint and operation is open.
+func Client(opts ...Option) middleware.Middleware {
+	opt := &options{
+		group: circuitbreaker.NewGroup(func() circuitbreaker.CircuitBreaker {
+			return sre.NewBreaker()
+		}),
+		failure: IsFailure,
+	}
+	for _, o := range opts {
+		o(opt)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromClientContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			breaker := opt.group.Get(tr.Endpoint() + tr.Operation())
+			if err := breaker.Allow(); err != nil {
+				// rejected
+				// NOTE: when client reject requests locally,
+				// continue to add counter let the drop ratio higher.
+				breaker.MarkFailed()
+				return nil, err
+			}
+			// allowed
+			reply, err := handler(ctx, req)
+			if err != nil && opt.failure(err) {
+				breaker.MarkFailed()
+			} else {
+				breaker.MarkSuccess()
+			}
+			return reply, err
+		}
+	}
+}
+
+// IsFailure reports whether err means the upstream is unhealthy, business
+// errors such as InvalidArgument or NotFound are not counted.
+func IsFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unknown, codes.DeadlineExceeded, codes.ResourceExhausted,
+		codes.Internal, codes.Unavailable, codes.DataLoss:
+		return true
+	default:
+		return false
+	}
+}
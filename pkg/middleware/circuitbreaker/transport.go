@@ -0,0 +1,92 @@
+package circuitbreaker
+
+import (
+	"go-framework/pkg/aegis/circuitbreaker"
+	"go-framework/pkg/aegis/circuitbreaker/sre"
+	"net/http"
+)
+
+// TransportOption is circuit breaker round tripper option.
+type TransportOption func(*transportOptions)
+
+// WithTransportGroup with circuit breaker group.
+func WithTransportGroup(g *circuitbreaker.Group) TransportOption {
+	return func(o *transportOptions) {
+		o.group = g
+	}
+}
+
+// WithTransportKey with the function building the breaker key of a request,
+// default is method, host and path.
+func WithTransportKey(fn func(*http.Request) string) TransportOption {
+	return func(o *transportOptions) {
+		o.key = fn
+	}
+}
+
+// WithTransportFailure with the function deciding whether a response counts
+// as a failure, default is transport errors, 429 and 5xx.
+func WithTransportFailure(fn func(*http.Response, error) bool) TransportOption {
+	return func(o *transportOptions) {
+		o.failure = fn
+	}
+}
+
+type transportOptions struct {
+	group   *circuitbreaker.Group
+	key     func(*http.Request) string
+	failure func(*http.Response, error) bool
+}
+
+// Transport is a http.RoundTripper guarded by circuit breakers.
+type Transport struct {
+	base http.RoundTripper
+	opt  transportOptions
+}
+
+// NewTransport wraps base with circuit breakers keyed per request endpoint,
+// base defaults to http.DefaultTransport. The returned transport holds the
+// breaker state and should be shared between requests.
+func NewTransport(base http.RoundTripper, opts ...TransportOption) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	opt := transportOptions{
+		group: circuitbreaker.NewGroup(func() circuitbreaker.CircuitBreaker {
+			return sre.NewBreaker()
+		}),
+		key:     requestKey,
+		failure: isResponseFailure,
+	}
+	for _, o := range opts {
+		o(&opt)
+	}
+	return &Transport{base: base, opt: opt}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	breaker := t.opt.group.Get(t.opt.key(req))
+	if err := breaker.Allow(); err != nil {
+		breaker.MarkFailed()
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(req)
+	if t.opt.failure(resp, err) {
+		breaker.MarkFailed()
+	} else {
+		breaker.MarkSuccess()
+	}
+	return resp, err
+}
+
+func requestKey(req *http.Request) string {
+	return req.Method + " " + req.URL.Host + req.URL.Path
+}
+
+func isResponseFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
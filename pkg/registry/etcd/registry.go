@@ -69,6 +69,12 @@ func New(client *clientv3.Client, opts ...Option) (r *Registry) {
 	}
 }
 
+// Check checks whether the etcd cluster is reachable.
+func (r *Registry) Check(ctx context.Context) error {
+	_, err := r.kv.Get(ctx, "health")
+	return err
+}
+
 // Register the registration.
 func (r *Registry) Register(ctx context.Context, service *registry.ServiceInstance) error {
 	var key string
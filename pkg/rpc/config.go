@@ -5,6 +5,7 @@ type ClientConf struct {
 	Endpoint  string `json:"endpoint"`
 	Insecure  bool   `json:"insecure"`
 	Namespace string `json:"namespace"`
+	Breaker   bool   `json:"breaker"`
 }
 
 type Etcd struct {
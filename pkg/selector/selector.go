@@ -2,6 +2,7 @@ package selector
 
 import (
 	"context"
+	"go-framework/util/xerror"
 )
 
 // ErrNoAvailable is no available node.
@@ -25,6 +25,13 @@ func WithEndpoint(endpoint string) ClientOption {
 	}
 }
 
+// WithMiddleware with client middleware.
+func WithMiddleware(m ...middleware.Middleware) ClientOption {
+	return func(o *clientOptions) {
+		o.middleware = m
+	}
+}
+
 // WithTimeout with client timeout.
 func WithTimeout(timeout time.Duration) ClientOption {
 	return func(o *clientOptions) {
@@ -0,0 +1,91 @@
+package grpc
+
+import (
+	"context"
+	"go-framework/pkg/transport"
+	"google.golang.org/grpc"
+	grpcmd "google.golang.org/grpc/metadata"
+	"sync"
+)
+
+// UnaryServerInterceptor returns a unary server interceptor which stores the
+// server Transport in context, reply headers set by handlers are sent back.
+func UnaryServerInterceptor(endpoint string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, replyHeader := newServerContext(ctx, endpoint, info.FullMethod)
+		reply, err := handler(ctx, req)
+		if len(replyHeader) > 0 {
+			_ = grpc.SetHeader(ctx, replyHeader)
+		}
+		return reply, err
+	}
+}
+
+// StreamServerInterceptor returns a stream server interceptor which stores the
+// server Transport in the stream context. Reply headers set by handlers are
+// sent before the first message or explicit header, since headers can not be
+// changed once the stream started sending.
+func StreamServerInterceptor(endpoint string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, replyHeader := newServerContext(ss.Context(), endpoint, info.FullMethod)
+		stream := &replyStream{WrappedStream: &WrappedStream{ServerStream: ss, ctx: ctx}, replyHeader: replyHeader}
+		err := handler(srv, stream)
+		stream.flush()
+		return err
+	}
+}
+
+// replyStream flushes the reply header once before anything is sent.
+type replyStream struct {
+	*WrappedStream
+	replyHeader grpcmd.MD
+	once        sync.Once
+}
+
+func (s *replyStream) flush() {
+	s.once.Do(func() {
+		if len(s.replyHeader) > 0 {
+			_ = s.ServerStream.SetHeader(s.replyHeader)
+		}
+	})
+}
+
+// SendHeader sends the reply header together with md.
+func (s *replyStream) SendHeader(md grpcmd.MD) error {
+	s.flush()
+	return s.ServerStream.SendHeader(md)
+}
+
+// SendMsg sends the reply header before the first message.
+func (s *replyStream) SendMsg(m interface{}) error {
+	s.flush()
+	return s.ServerStream.SendMsg(m)
+}
+
+func newServerContext(ctx context.Context, endpoint, operation string) (context.Context, grpcmd.MD) {
+	md, _ := grpcmd.FromIncomingContext(ctx)
+	replyHeader := grpcmd.MD{}
+	tr := &Transport{
+		endpoint:    endpoint,
+		operation:   operation,
+		reqHeader:   headerCarrier(md.Copy()),
+		replyHeader: headerCarrier(replyHeader),
+	}
+	return transport.NewServerContext(ctx, tr), replyHeader
+}
+
+// WrappedStream is a grpc.ServerStream with a replaced context.
+type WrappedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// NewWrappedStream returns a stream whose Context returns ctx.
+func NewWrappedStream(ctx context.Context, stream grpc.ServerStream) grpc.ServerStream {
+	return &WrappedStream{ServerStream: stream, ctx: ctx}
+}
+
+// Context returns the context of stream.
+func (w *WrappedStream) Context() context.Context {
+	return w.ctx
+}
@@ -1,17 +1,95 @@
 package app
 
-import "net/http"
+import (
+	"github.com/gin-gonic/gin/binding"
+	"github.com/julienschmidt/httprouter"
+	"go-framework/util/binder"
+	"go-framework/util/xhttp"
+	"net/http"
+	"sync"
+)
 
 type Engine struct {
-	*Router
+	*RouterGroup
+
+	// Validator 请求参数绑定后的校验器，默认为 binder.Validator
+	Validator binding.StructValidator
+
+	router      *httprouter.Router
+	pool        sync.Pool
+	noRoute     HandlersChain
+	noMethod    HandlersChain
+	allNoRoute  HandlersChain
+	allNoMethod HandlersChain
 }
 
 func New() *Engine {
-	return &Engine{
-		NewRouter(),
+	e := &Engine{
+		Validator: new(binder.Validator),
+		router:    httprouter.New(),
+	}
+	e.RouterGroup = &RouterGroup{basePath: "/", engine: e}
+	e.pool.New = func() any {
+		return &Context{engine: e}
 	}
+	e.router.NotFound = e.fallback(http.StatusNotFound, func() HandlersChain { return e.allNoRoute })
+	e.router.MethodNotAllowed = e.fallback(http.StatusMethodNotAllowed, func() HandlersChain { return e.allNoMethod })
+	e.rebuildFallback()
+	return e
+}
+
+// Use 注册全局中间件，仅对之后注册的路由生效
+func (e *Engine) Use(middleware ...HandlerFunc) {
+	e.RouterGroup.Use(middleware...)
+	e.rebuildFallback()
+}
+
+// NoRoute 路由未匹配时的处理函数，默认返回 404
+func (e *Engine) NoRoute(handlers ...HandlerFunc) {
+	e.noRoute = handlers
+	e.rebuildFallback()
+}
+
+// NoMethod 路由匹配但请求方法不允许时的处理函数，默认返回 405
+func (e *Engine) NoMethod(handlers ...HandlerFunc) {
+	e.noMethod = handlers
+	e.rebuildFallback()
+}
+
+func (e *Engine) rebuildFallback() {
+	e.allNoRoute = e.combineHandlers(e.noRoute)
+	e.allNoMethod = e.combineHandlers(e.noMethod)
+}
+
+func (e *Engine) fallback(status int, handlers func() HandlersChain) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		c := e.pool.Get().(*Context)
+		defer e.pool.Put(c)
+		c.reset(w, req, nil, "", handlers())
+		c.Next()
+		if !c.ResponseWriter.Written() {
+			c.JSON(status, xhttp.ErrMsg(http.StatusText(status), status))
+		}
+	})
+}
+
+// handle 从对象池中取出 Context 并依次执行处理函数链
+func (e *Engine) handle(w http.ResponseWriter, req *http.Request, ps httprouter.Params, path string, handlers HandlersChain) {
+	c := e.pool.Get().(*Context)
+	defer e.pool.Put(c)
+
+	c.reset(w, req, ps, path, handlers)
+	c.Next()
+	// 只设置了状态码而没有写入响应体时，写入状态码
+	c.writer.writeHeaderNow()
+}
+
+// ServeHTTP 实现 http.Handler
+func (e *Engine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	e.router.ServeHTTP(w, req)
 }
 
-func (a *Engine) Run(addr string) {
-	http.ListenAndServe(addr, a)
+// Run 监听并启动 http 服务，阻塞直至服务退出
+func (e *Engine) Run(addr string) error {
+	return http.ListenAndServe(addr, e)
 }
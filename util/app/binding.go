@@ -0,0 +1,162 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin/binding"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+)
+
+const defaultMultipartMemory = 32 << 20
+
+// Query 获取查询参数
+func (c *Context) Query(key string) string {
+	value, _ := c.GetQuery(key)
+	return value
+}
+
+// DefaultQuery 获取查询参数，不存在时返回默认值
+func (c *Context) DefaultQuery(key, defaultValue string) string {
+	if value, ok := c.GetQuery(key); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// GetQuery 获取查询参数及其是否存在
+func (c *Context) GetQuery(key string) (string, bool) {
+	if values, ok := c.GetQueryArray(key); ok {
+		return values[0], true
+	}
+	return "", false
+}
+
+// QueryArray 获取同名的多个查询参数
+func (c *Context) QueryArray(key string) []string {
+	values, _ := c.GetQueryArray(key)
+	return values
+}
+
+// GetQueryArray 获取同名的多个查询参数及其是否存在
+func (c *Context) GetQueryArray(key string) ([]string, bool) {
+	c.initQueryCache()
+	values, ok := c.query[key]
+	return values, ok && len(values) > 0
+}
+
+func (c *Context) initQueryCache() {
+	if c.query != nil {
+		return
+	}
+	if c.Request != nil && c.Request.URL != nil {
+		c.query = c.Request.URL.Query()
+	} else {
+		c.query = url.Values{}
+	}
+}
+
+// PostForm 获取表单参数
+func (c *Context) PostForm(key string) string {
+	value, _ := c.GetPostForm(key)
+	return value
+}
+
+// DefaultPostForm 获取表单参数，不存在时返回默认值
+func (c *Context) DefaultPostForm(key, defaultValue string) string {
+	if value, ok := c.GetPostForm(key); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// GetPostForm 获取表单参数及其是否存在
+func (c *Context) GetPostForm(key string) (string, bool) {
+	if err := c.parseForm(); err != nil {
+		return "", false
+	}
+	values, ok := c.Request.PostForm[key]
+	if !ok || len(values) == 0 {
+		return "", false
+	}
+	return values[0], true
+}
+
+// ShouldBind 按 Content-Type 选择绑定方式：JSON、表单，GET 等无请求体的请求绑定查询参数
+func (c *Context) ShouldBind(obj any) error {
+	if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodDelete || c.Request.Method == http.MethodHead {
+		return c.ShouldBindQuery(obj)
+	}
+
+	contentType, _, _ := mime.ParseMediaType(c.Request.Header.Get("Content-Type"))
+	switch contentType {
+	case binding.MIMEJSON:
+		return c.ShouldBindJSON(obj)
+	case binding.MIMEPOSTForm, binding.MIMEMultipartPOSTForm:
+		return c.ShouldBindForm(obj)
+	default:
+		return c.ShouldBindQuery(obj)
+	}
+}
+
+// ShouldBindJSON 绑定 JSON 请求体并校验
+func (c *Context) ShouldBindJSON(obj any) error {
+	if c.Request == nil || c.Request.Body == nil {
+		return errors.New("app: invalid request")
+	}
+	if err := json.NewDecoder(c.Request.Body).Decode(obj); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return c.validate(obj)
+}
+
+// ShouldBindQuery 绑定查询参数（form 标签）并校验
+func (c *Context) ShouldBindQuery(obj any) error {
+	c.initQueryCache()
+	if err := binding.MapFormWithTag(obj, c.query, "form"); err != nil {
+		return err
+	}
+	return c.validate(obj)
+}
+
+// ShouldBindForm 绑定表单与查询参数（form 标签）并校验
+func (c *Context) ShouldBindForm(obj any) error {
+	if err := c.parseForm(); err != nil {
+		return err
+	}
+	if err := binding.MapFormWithTag(obj, c.Request.Form, "form"); err != nil {
+		return err
+	}
+	return c.validate(obj)
+}
+
+// ShouldBindUri 绑定路径参数（uri 标签）并校验
+func (c *Context) ShouldBindUri(obj any) error {
+	params := make(map[string][]string, len(c.Params))
+	for _, p := range c.Params {
+		params[p.Key] = []string{p.Value}
+	}
+	if err := binding.MapFormWithTag(obj, params, "uri"); err != nil {
+		return err
+	}
+	return c.validate(obj)
+}
+
+func (c *Context) parseForm() error {
+	if c.Request.PostForm != nil {
+		return nil
+	}
+	if err := c.Request.ParseMultipartForm(defaultMultipartMemory); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		return err
+	}
+	return nil
+}
+
+func (c *Context) validate(obj any) error {
+	if c.engine == nil || c.engine.Validator == nil {
+		return nil
+	}
+	return c.engine.Validator.ValidateStruct(obj)
+}
@@ -2,15 +2,111 @@ package app
 
 import (
 	"go-framework/util/app/render"
+	"go-framework/util/xhttp"
 	"net/http"
 )
 
+// Next 执行处理函数链中的后续处理函数，仅应在中间件中调用
+func (c *Context) Next() {
+	c.index++
+	for c.index < int8(len(c.handlers)) {
+		c.handlers[c.index](c)
+		c.index++
+	}
+}
+
+// Abort 阻止执行后续处理函数，不会中断当前处理函数
+func (c *Context) Abort() {
+	c.index = abortIndex
+}
+
+// IsAborted 是否已中止
+func (c *Context) IsAborted() bool {
+	return c.index >= abortIndex
+}
+
+// AbortWithStatus 写入状态码并中止
+func (c *Context) AbortWithStatus(code int) {
+	c.ResponseWriter.WriteHeader(code)
+	c.writer.writeHeaderNow()
+	c.Abort()
+}
+
+// AbortWithResponse 渲染响应并中止
+func (c *Context) AbortWithResponse(resp xhttp.Response) {
+	c.Abort()
+	c.Response(resp)
+}
+
+// Set 保存请求级别的键值
+func (c *Context) Set(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.keys == nil {
+		c.keys = make(map[string]any)
+	}
+	c.keys[key] = value
+}
+
+// Get 获取请求级别的键值
+func (c *Context) Get(key string) (value any, exists bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	value, exists = c.keys[key]
+	return
+}
+
+// MustGet 获取请求级别的键值，不存在时 panic
+func (c *Context) MustGet(key string) any {
+	if value, exists := c.Get(key); exists {
+		return value
+	}
+	panic("app: key \"" + key + "\" does not exist")
+}
+
+// Status 设置响应状态码
+func (c *Context) Status(code int) {
+	c.ResponseWriter.WriteHeader(code)
+}
+
+// Header 设置响应头，value 为空时删除
+func (c *Context) Header(key, value string) {
+	if value == "" {
+		c.ResponseWriter.Header().Del(key)
+		return
+	}
+	c.ResponseWriter.Header().Set(key, value)
+}
+
 func (c *Context) JSON(code int, data any) {
 	c.render(code, render.Json{Data: data})
 }
 
-func (c *Context) render(code int, json render.Render) {
-	err := json.Render(c.ResponseWriter)
+// String 渲染纯文本
+func (c *Context) String(code int, s string) {
+	c.Status(code)
+	c.ResponseWriter.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = c.ResponseWriter.Write([]byte(s))
+}
+
+// Response 以 200 状态码渲染统一响应结构
+func (c *Context) Response(resp xhttp.Response) {
+	c.JSON(http.StatusOK, resp)
+}
+
+// Success 渲染成功响应
+func (c *Context) Success(data any) {
+	c.Response(xhttp.Data(data))
+}
+
+// Fail 渲染错误响应
+func (c *Context) Fail(err error, status ...int) {
+	c.Response(xhttp.Error(err, status...))
+}
+
+func (c *Context) render(code int, r render.Render) {
+	c.Status(code)
+	err := r.Render(c.ResponseWriter)
 	if err != nil {
 		c.Error()
 	}
@@ -4,26 +4,53 @@ import (
 	"github.com/julienschmidt/httprouter"
 	"net/http"
 	"net/url"
+	"sync"
 )
 
+// HandlerFunc 请求处理函数，中间件与业务处理函数共用该类型，中间件通过 Context.Next 调用后续处理函数
 type HandlerFunc func(*Context)
 
+// HandlersChain 处理函数链
+type HandlersChain []HandlerFunc
+
+// MiddlewareFunc 包装式中间件，通过 Middleware 转换为 HandlerFunc 后使用
 type MiddlewareFunc func(HandlerFunc) HandlerFunc
 
+// Middleware 将包装式中间件转换为链式中间件，next 即执行 Context.Next
+func Middleware(mw MiddlewareFunc) HandlerFunc {
+	return func(c *Context) {
+		mw(func(c *Context) { c.Next() })(c)
+	}
+}
+
 type Context struct {
 	Request        *http.Request
-	ResponseWriter http.ResponseWriter
-	path           string
-	pnames         []string
-	pvalues        []string
-	query          url.Values
+	ResponseWriter ResponseWriter
 	Params         httprouter.Params
+
+	engine   *Engine
+	writer   responseWriter
+	path     string
+	handlers HandlersChain
+	index    int8
+	query    url.Values
+	mu       sync.RWMutex
+	keys     map[string]any
 }
 
-type Response struct {
-	Writer http.ResponseWriter
+func (c *Context) reset(w http.ResponseWriter, req *http.Request, ps httprouter.Params, path string, handlers HandlersChain) {
+	c.writer.reset(w)
+	c.ResponseWriter = &c.writer
+	c.Request = req
+	c.Params = ps
+	c.path = path
+	c.handlers = handlers
+	c.index = -1
+	c.query = nil
+	c.keys = nil
 }
 
+// Path 匹配到的路由模板，如 /users/:id，未匹配时为空
 func (c *Context) Path() string {
 	return c.path
 }
@@ -36,17 +63,16 @@ func (c *Context) GetRequest() *http.Request {
 	return c.Request
 }
 
+// Param 获取路径参数
 func (c *Context) Param(name string) string {
-	for i, n := range c.pnames {
-		if i < len(c.pvalues) {
-			if n == name {
-				return c.pvalues[i]
-			}
-		}
-	}
-	return ""
+	return c.Params.ByName(name)
 }
 
+// ParamNames 路径参数名称
 func (c *Context) ParamNames() []string {
-	return c.pnames
+	names := make([]string, 0, len(c.Params))
+	for _, p := range c.Params {
+		names = append(names, p.Key)
+	}
+	return names
 }
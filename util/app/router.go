@@ -3,114 +3,107 @@ package app
 import (
 	"github.com/julienschmidt/httprouter"
 	"net/http"
+	"path"
+	"strings"
 )
 
-type Router struct {
-	router      *httprouter.Router
-	middlewares []MiddlewareFunc
-}
+const abortIndex int8 = 63
 
-func NewRouter() *Router {
-	return &Router{router: httprouter.New(), middlewares: []MiddlewareFunc{}}
+var anyMethods = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodHead, http.MethodOptions,
 }
 
-func (r *Router) Use(mw MiddlewareFunc) {
-	r.middlewares = append(r.middlewares, mw)
+// RouterGroup 路由分组，处理函数链按 全局中间件 -> 外层分组中间件 -> 内层分组中间件 -> 路由处理函数 的顺序执行
+type RouterGroup struct {
+	handlers HandlersChain
+	basePath string
+	engine   *Engine
 }
 
-func (r *Router) addRoute(method string, path string, handle HandlerFunc) {
-	finalHandler := func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		ctx := &Context{
-			ResponseWriter: w,
-			Request:        req,
-			Params:         ps,
-		}
-		handle(ctx)
-	}
-	for i := len(r.middlewares) - 1; i >= 0; i-- {
-		finalHandler = adaptMiddleware(r.middlewares[i], finalHandler)
-	}
-	r.router.Handle(method, path, finalHandler)
+// Use 注册分组中间件，仅对之后注册的路由生效
+func (g *RouterGroup) Use(middleware ...HandlerFunc) {
+	g.handlers = append(g.handlers, middleware...)
 }
 
-func adaptMiddleware(mw MiddlewareFunc, handle httprouter.Handle) httprouter.Handle {
-	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		newHandle := mw(func(ctx *Context) {
-			handle(w, req, ps)
-		})
-		newHandle(&Context{ResponseWriter: w, Request: req, Params: ps})
+// Group 创建子分组，子分组继承当前分组的前缀与中间件
+func (g *RouterGroup) Group(prefix string, handlers ...HandlerFunc) *RouterGroup {
+	return &RouterGroup{
+		handlers: g.combineHandlers(handlers),
+		basePath: g.calculateAbsolutePath(prefix),
+		engine:   g.engine,
 	}
 }
 
-func (r *Router) GET(path string, handle HandlerFunc) {
-	r.addRoute("GET", path, handle)
+// BasePath 分组的路由前缀
+func (g *RouterGroup) BasePath() string {
+	return g.basePath
 }
 
-func (r *Router) POST(path string, handle HandlerFunc) {
-	r.addRoute("POST", path, handle)
-}
+// Handle 注册任意请求方法的路由
+func (g *RouterGroup) Handle(method, relativePath string, handlers ...HandlerFunc) {
+	absolutePath := g.calculateAbsolutePath(relativePath)
+	chain := g.combineHandlers(handlers)
+	engine := g.engine
 
-func (r *Router) PUT(path string, handle HandlerFunc) {
-	r.addRoute("PUT", path, handle)
+	engine.router.Handle(method, absolutePath, func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		engine.handle(w, req, ps, absolutePath, chain)
+	})
 }
 
-func (r *Router) DELETE(path string, handle HandlerFunc) {
-	r.addRoute("DELETE", path, handle)
+func (g *RouterGroup) GET(path string, handlers ...HandlerFunc) {
+	g.Handle(http.MethodGet, path, handlers...)
 }
 
-type Group struct {
-	prefix      string
-	middlewares []MiddlewareFunc
-	parent      *Router
+func (g *RouterGroup) POST(path string, handlers ...HandlerFunc) {
+	g.Handle(http.MethodPost, path, handlers...)
 }
 
-func (r *Router) Group(prefix string) *Group {
-	return &Group{
-		prefix: prefix,
-		parent: r,
-	}
+func (g *RouterGroup) PUT(path string, handlers ...HandlerFunc) {
+	g.Handle(http.MethodPut, path, handlers...)
 }
 
-func (g *Group) Use(mw MiddlewareFunc) {
-	g.middlewares = append(g.middlewares, mw)
+func (g *RouterGroup) PATCH(path string, handlers ...HandlerFunc) {
+	g.Handle(http.MethodPatch, path, handlers...)
 }
 
-func (g *Group) addRoute(method, path string, handle HandlerFunc) {
-	finalPath := g.prefix + path
-	finalHandler := handle
-
-	// Combine group middlewares and router middlewares
-	allMiddlewares := append(g.middlewares, g.parent.middlewares...)
-	for i := len(allMiddlewares) - 1; i >= 0; i-- {
-		finalHandler = allMiddlewares[i](finalHandler)
-	}
+func (g *RouterGroup) DELETE(path string, handlers ...HandlerFunc) {
+	g.Handle(http.MethodDelete, path, handlers...)
+}
 
-	adaptedHandler := adaptHandler(finalHandler)
-	g.parent.router.Handle(method, finalPath, adaptedHandler)
+func (g *RouterGroup) HEAD(path string, handlers ...HandlerFunc) {
+	g.Handle(http.MethodHead, path, handlers...)
 }
 
-// adaptHandler converts a app.HandlerFunc (our custom type) into httprouter.Handle
-// which is the type expected by httprouter.
-func adaptHandler(h HandlerFunc) httprouter.Handle {
-	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		ctx := &Context{
-			ResponseWriter: w,
-			Request:        req,
-			Params:         ps,
-		}
-		h(ctx)
-	}
+func (g *RouterGroup) OPTIONS(path string, handlers ...HandlerFunc) {
+	g.Handle(http.MethodOptions, path, handlers...)
 }
 
-func (g *Group) GET(path string, handle HandlerFunc) {
-	g.addRoute("GET", path, handle)
+// Any 为所有常用请求方法注册同一路由
+func (g *RouterGroup) Any(path string, handlers ...HandlerFunc) {
+	for _, method := range anyMethods {
+		g.Handle(method, path, handlers...)
+	}
 }
 
-func (g *Group) POST(path string, handle HandlerFunc) {
-	g.addRoute("POST", path, handle)
+func (g *RouterGroup) combineHandlers(handlers HandlersChain) HandlersChain {
+	size := len(g.handlers) + len(handlers)
+	if size >= int(abortIndex) {
+		panic("app: too many handlers")
+	}
+	merged := make(HandlersChain, size)
+	copy(merged, g.handlers)
+	copy(merged[len(g.handlers):], handlers)
+	return merged
 }
 
-// Implement ServeHTTP to satisfy http.Handler interface
-func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	r.router.ServeHTTP(w, req)
+func (g *RouterGroup) calculateAbsolutePath(relativePath string) string {
+	if relativePath == "" {
+		return g.basePath
+	}
+	finalPath := path.Join(g.basePath, relativePath)
+	if strings.HasSuffix(relativePath, "/") && !strings.HasSuffix(finalPath, "/") {
+		return finalPath + "/"
+	}
+	return finalPath
 }
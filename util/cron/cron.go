@@ -1,11 +1,13 @@
 package cron
 
 import (
+	"context"
 	"fmt"
 	"github.com/go-redis/redis/v8"
 	"github.com/go-redsync/redsync/v4"
 	"github.com/go-redsync/redsync/v4/redis/goredis/v8"
 	"github.com/robfig/cron/v3"
+	"go-framework/util/metrics"
 	"go-framework/util/xlog"
 	"time"
 )
@@ -83,6 +85,22 @@ func (c *Cron) Run() {
 	c.cronClient.Run()
 }
 
+// Start starts the cron scheduler in its own goroutine.
+func (c *Cron) Start() {
+	c.cronClient.Start()
+}
+
+// Stop stops the scheduler from firing new tasks and waits for running tasks
+// to complete or ctx to be done, whichever happens first.
+func (c *Cron) Stop(ctx context.Context) error {
+	select {
+	case <-c.cronClient.Stop().Done():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (c *Cron) lock(h *Handler) (bool, error) {
 	now := time.Now()
 	d := h.schedule.Next(now).Sub(now)
@@ -100,18 +118,27 @@ func (c *Cron) handle(h *Handler) func() {
 	return func() {
 		defer func() {
 			if err := recover(); err != nil {
+				metrics.CronPanicTotal.WithLabelValues(h.name).Inc()
 				c.Config.logger.Errorf("task panic:%s %s %s\n", h.cron, h.name, err)
 			}
 		}()
 		s, err := c.lock(h)
 		if err != nil {
+			metrics.CronSkipTotal.WithLabelValues(h.name).Inc()
 			c.Config.logger.Errorf("can't run task:%s %s %s\n", h.cron, h.name, err.Error())
 			return
 		}
 		if !s {
+			metrics.CronSkipTotal.WithLabelValues(h.name).Inc()
 			c.Config.logger.Errorf("task skipped by another instance:%s %s\n", h.cron, h.name)
 			return
 		}
+
+		metrics.CronRunTotal.WithLabelValues(h.name).Inc()
+		start := time.Now()
+		defer func() {
+			metrics.CronDuration.WithLabelValues(h.name).Observe(time.Since(start).Seconds())
+		}()
 		h.handle()
 	}
 }
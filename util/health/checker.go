@@ -0,0 +1,34 @@
+package health
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"gorm.io/gorm"
+)
+
+// GormChecker 检查数据库连接
+func GormChecker(db *gorm.DB) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	})
+}
+
+// MongoChecker 检查 MongoDB 连接
+func MongoChecker(db *mongo.Database) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		return db.Client().Ping(ctx, readpref.Primary())
+	})
+}
+
+// RedisChecker 检查 Redis 连接
+func RedisChecker(client *redis.Client) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	})
+}
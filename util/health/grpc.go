@@ -0,0 +1,78 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+const watchInterval = 5 * time.Second
+
+var _ healthpb.HealthServer = (*GRPCServer)(nil)
+
+// GRPCServer 标准 grpc.health.v1 服务，空服务名对应整体就绪状态，其余服务名对应同名检查项
+type GRPCServer struct {
+	healthpb.UnimplementedHealthServer
+	health *Health
+}
+
+// NewGRPCServer 创建 grpc 健康检查服务
+func NewGRPCServer(h *Health) *GRPCServer {
+	return &GRPCServer{health: h}
+}
+
+// Check 返回指定服务的健康状态
+func (s *GRPCServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	servingStatus, err := s.servingStatus(ctx, req.GetService())
+	if err != nil {
+		return nil, err
+	}
+	return &healthpb.HealthCheckResponse{Status: servingStatus}, nil
+}
+
+// Watch 定期检查指定服务，状态变化时推送
+func (s *GRPCServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	for {
+		servingStatus, err := s.servingStatus(stream.Context(), req.GetService())
+		if err != nil {
+			servingStatus = healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+		}
+		if servingStatus != last {
+			last = servingStatus
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: servingStatus}); err != nil {
+				return status.Error(codes.Canceled, "stream has ended")
+			}
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return status.Error(codes.Canceled, "stream has ended")
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *GRPCServer) servingStatus(ctx context.Context, service string) (healthpb.HealthCheckResponse_ServingStatus, error) {
+	if service == "" {
+		if s.health.Check(ctx).Status != StatusUp {
+			return healthpb.HealthCheckResponse_NOT_SERVING, nil
+		}
+		return healthpb.HealthCheckResponse_SERVING, nil
+	}
+
+	err := s.health.CheckOne(ctx, service)
+	if errors.Is(err, ErrCheckerNotFound) {
+		return healthpb.HealthCheckResponse_SERVICE_UNKNOWN, status.Error(codes.NotFound, "unknown service")
+	}
+	if err != nil || s.health.Status() != nil {
+		return healthpb.HealthCheckResponse_NOT_SERVING, nil
+	}
+	return healthpb.HealthCheckResponse_SERVING, nil
+}
@@ -0,0 +1,191 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp   = "UP"
+	StatusDown = "DOWN"
+
+	defaultTimeout = 3 * time.Second
+)
+
+var (
+	// ErrNotReady 服务尚未启动完成
+	ErrNotReady = errors.New("health: service is not ready")
+	// ErrShuttingDown 服务正在关闭
+	ErrShuttingDown = errors.New("health: service is shutting down")
+	// ErrCheckerNotFound 检查项不存在
+	ErrCheckerNotFound = errors.New("health: checker not found")
+)
+
+// Checker 依赖检查项
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc 函数形式的检查项
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Result 单个检查项的结果
+type Result struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report 健康检查报告
+type Report struct {
+	Status string            `json:"status"`
+	Ready  bool              `json:"ready"`
+	Error  string            `json:"error,omitempty"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Health 健康检查注册中心
+type Health struct {
+	mu       sync.RWMutex
+	checkers map[string]Checker
+	timeout  time.Duration
+	ready    atomic.Bool
+	stopping atomic.Bool
+}
+
+// New 创建健康检查注册中心，默认未就绪，需在启动完成后调用 MarkReady
+func New() *Health {
+	return &Health{
+		checkers: make(map[string]Checker),
+		timeout:  defaultTimeout,
+	}
+}
+
+// Register 注册检查项，同名检查项会被覆盖
+func (h *Health) Register(name string, checker Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checkers[name] = checker
+}
+
+// Names 已注册的检查项名称
+func (h *Health) Names() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	names := make([]string, 0, len(h.checkers))
+	for name := range h.checkers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MarkReady 标记服务启动完成
+func (h *Health) MarkReady() {
+	h.ready.Store(true)
+}
+
+// Shutdown 标记服务开始关闭，此后就绪检查始终失败
+func (h *Health) Shutdown() {
+	h.stopping.Store(true)
+}
+
+// Live 存活检查，进程能够响应即视为存活
+func (h *Health) Live() bool {
+	return true
+}
+
+// Status 服务状态，未启动完成或正在关闭时返回对应错误
+func (h *Health) Status() error {
+	if h.stopping.Load() {
+		return ErrShuttingDown
+	}
+	if !h.ready.Load() {
+		return ErrNotReady
+	}
+	return nil
+}
+
+// CheckOne 执行指定检查项
+func (h *Health) CheckOne(ctx context.Context, name string) error {
+	h.mu.RLock()
+	checker, ok := h.checkers[name]
+	h.mu.RUnlock()
+	if !ok {
+		return ErrCheckerNotFound
+	}
+	return h.check(ctx, checker).err
+}
+
+// Check 并发执行所有检查项并生成报告，服务状态与所有检查项均正常时 Status 为 UP
+func (h *Health) Check(ctx context.Context) Report {
+	h.mu.RLock()
+	checkers := make(map[string]Checker, len(h.checkers))
+	for name, checker := range h.checkers {
+		checkers[name] = checker
+	}
+	h.mu.RUnlock()
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	report := Report{Status: StatusUp, Ready: true, Checks: make(map[string]Result, len(checkers))}
+	for name, checker := range checkers {
+		wg.Add(1)
+		go func(name string, checker Checker) {
+			defer wg.Done()
+			out := h.check(ctx, checker)
+
+			result := Result{Status: StatusUp, Duration: out.duration.String()}
+			if out.err != nil {
+				result.Status = StatusDown
+				result.Error = out.err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if out.err != nil {
+				report.Status = StatusDown
+			}
+		}(name, checker)
+	}
+	wg.Wait()
+
+	if err := h.Status(); err != nil {
+		report.Ready = false
+		report.Status = StatusDown
+		report.Error = err.Error()
+	}
+	return report
+}
+
+type outcome struct {
+	err      error
+	duration time.Duration
+}
+
+func (h *Health) check(ctx context.Context, checker Checker) (out outcome) {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	defer func() {
+		if p := recover(); p != nil {
+			out.err = errors.New("health: checker panic")
+		}
+		out.duration = time.Since(start)
+	}()
+	out.err = checker.Check(ctx)
+	return out
+}
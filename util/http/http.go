@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"go-framework/pkg/middleware/circuitbreaker"
 	"go-framework/util/helper"
 	"go-framework/util/tracer"
 	"io"
@@ -13,6 +14,9 @@ import (
 	"time"
 )
 
+// breakerTransport 共享熔断状态的默认传输，按 方法+域名+路径 熔断
+var breakerTransport = circuitbreaker.NewTransport(http.DefaultTransport)
+
 // Client http.Client
 type Client struct {
 	domain string
@@ -43,6 +47,13 @@ func WithTransport(transport http.RoundTripper) Options {
 	}
 }
 
+// WithCircuitBreaker 使用带熔断的默认传输，自定义传输可通过 WithTransport(circuitbreaker.NewTransport(t)) 包装
+func WithCircuitBreaker() Options {
+	return func(o *Option) {
+		o.transport = breakerTransport
+	}
+}
+
 // WithHeaders 设置HTTP请求头
 func WithHeaders(headers map[string]string) Options {
 	return func(o *Option) {
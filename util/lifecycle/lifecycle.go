@@ -0,0 +1,94 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-framework/util/xlog"
+	"sync"
+)
+
+// Hook 生命周期钩子，OnStart 在启动时按注册顺序执行，OnStop 在关闭时按注册的逆序执行
+type Hook struct {
+	Name    string
+	OnStart func(ctx context.Context) error
+	OnStop  func(ctx context.Context) error
+}
+
+// Manager 生命周期管理器
+type Manager struct {
+	mu      sync.Mutex
+	logger  *xlog.Log
+	hooks   []Hook
+	started int
+	stopped bool
+}
+
+// NewManager 创建生命周期管理器
+func NewManager(logger *xlog.Log) *Manager {
+	return &Manager{logger: logger}
+}
+
+// Append 注册钩子，先注册的先启动、后关闭
+func (m *Manager) Append(hooks ...Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hooks...)
+}
+
+// appendStarted 注册已在外部启动完成的钩子，关闭时先于其之前启动的钩子执行
+func (m *Manager) appendStarted(hook Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pending := append([]Hook{hook}, m.hooks[m.started:]...)
+	m.hooks = append(m.hooks[:m.started], pending...)
+	m.started++
+}
+
+// Start 按注册顺序执行 OnStart，任意钩子失败时关闭已启动的钩子并返回错误
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for m.started < len(m.hooks) {
+		hook := m.hooks[m.started]
+		if hook.OnStart != nil {
+			if err := hook.OnStart(ctx); err != nil {
+				startErr := fmt.Errorf("lifecycle: start %s: %w", hook.Name, err)
+				return errors.Join(startErr, m.stop(ctx))
+			}
+		}
+		m.started++
+	}
+	return nil
+}
+
+// Stop 按注册的逆序执行已启动钩子的 OnStop，单个钩子失败不影响后续钩子关闭
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stop(ctx)
+}
+
+func (m *Manager) stop(ctx context.Context) error {
+	if m.stopped {
+		return nil
+	}
+	m.stopped = true
+
+	var errs []error
+	for i := m.started - 1; i >= 0; i-- {
+		hook := m.hooks[i]
+		if hook.OnStop == nil {
+			continue
+		}
+		if err := hook.OnStop(ctx); err != nil {
+			m.logger.Errorf("lifecycle: stop %s error: %+v", hook.Name, err)
+			errs = append(errs, fmt.Errorf("lifecycle: stop %s: %w", hook.Name, err))
+			continue
+		}
+		m.logger.Infof("lifecycle: %s stopped", hook.Name)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,169 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Component 组件，按声明的依赖顺序启动，按启动的逆序关闭
+type Component struct {
+	Name    string
+	Depends []string
+	Start   func(ctx context.Context) error
+	Stop    func(ctx context.Context) error
+}
+
+// Registry 组件注册中心
+type Registry struct {
+	mu         sync.Mutex
+	manager    *Manager
+	components []Component
+	index      map[string]int
+	disabled   map[string]struct{}
+	enabled    map[string]struct{}
+}
+
+// NewRegistry 创建组件注册中心，已启动组件的关闭钩子交由 manager 管理；disabled 中的组件及依赖它们的组件不会启动
+func NewRegistry(manager *Manager, disabled ...string) *Registry {
+	r := &Registry{
+		manager:  manager,
+		index:    make(map[string]int),
+		disabled: make(map[string]struct{}),
+		enabled:  make(map[string]struct{}),
+	}
+	for _, name := range disabled {
+		r.disabled[name] = struct{}{}
+	}
+	return r
+}
+
+// Register 注册组件，同名组件重复注册时 panic
+func (r *Registry) Register(components ...Component) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, c := range components {
+		if _, ok := r.index[c.Name]; ok {
+			panic(fmt.Sprintf("lifecycle: component %s already registered", c.Name))
+		}
+		r.index[c.Name] = len(r.components)
+		r.components = append(r.components, c)
+	}
+}
+
+// Enabled 组件是否已成功启动
+func (r *Registry) Enabled(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.enabled[name]
+	return ok
+}
+
+// Start 按依赖顺序启动所有组件。
+// 单个组件启动失败不会中断其它组件的启动，依赖它的组件会被跳过，
+// 所有错误汇总后返回，此时已启动的组件会被逆序关闭。
+func (r *Registry) Start(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	order, err := r.resolve()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	failed := make(map[string]struct{})
+	skipped := make(map[string]struct{})
+	for _, i := range order {
+		c := r.components[i]
+		if _, ok := r.disabled[c.Name]; ok {
+			skipped[c.Name] = struct{}{}
+			r.manager.logger.Infof("lifecycle: component %s disabled", c.Name)
+			continue
+		}
+
+		if dep, ok := firstOf(c.Depends, failed); ok {
+			failed[c.Name] = struct{}{}
+			errs = append(errs, fmt.Errorf("lifecycle: component %s: dependency %s failed to start", c.Name, dep))
+			continue
+		}
+		if dep, ok := firstOf(c.Depends, skipped); ok {
+			skipped[c.Name] = struct{}{}
+			r.manager.logger.Infof("lifecycle: component %s skipped, dependency %s disabled", c.Name, dep)
+			continue
+		}
+
+		if c.Start != nil {
+			if err := c.Start(ctx); err != nil {
+				failed[c.Name] = struct{}{}
+				errs = append(errs, fmt.Errorf("lifecycle: component %s: %w", c.Name, err))
+				continue
+			}
+		}
+		r.enabled[c.Name] = struct{}{}
+		r.manager.appendStarted(Hook{Name: c.Name, OnStop: c.Stop})
+		r.manager.logger.Infof("lifecycle: component %s started", c.Name)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	startErr := errors.Join(errs...)
+	r.manager.logger.Errorf("%+v", startErr)
+	return errors.Join(startErr, r.manager.Stop(ctx))
+}
+
+// resolve 拓扑排序，无依赖约束的组件保持注册顺序
+func (r *Registry) resolve() ([]int, error) {
+	indegree := make([]int, len(r.components))
+	dependents := make([][]int, len(r.components))
+	for i, c := range r.components {
+		for _, dep := range c.Depends {
+			j, ok := r.index[dep]
+			if !ok {
+				return nil, fmt.Errorf("lifecycle: component %s depends on unknown component %s", c.Name, dep)
+			}
+			indegree[i]++
+			dependents[j] = append(dependents[j], i)
+		}
+	}
+
+	order := make([]int, 0, len(r.components))
+	visited := make([]bool, len(r.components))
+	for len(order) < len(r.components) {
+		next := -1
+		for i := range r.components {
+			if !visited[i] && indegree[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next == -1 {
+			var cycle []string
+			for i, c := range r.components {
+				if !visited[i] {
+					cycle = append(cycle, c.Name)
+				}
+			}
+			return nil, fmt.Errorf("lifecycle: circular dependency between components %v", cycle)
+		}
+		visited[next] = true
+		order = append(order, next)
+		for _, i := range dependents[next] {
+			indegree[i]--
+		}
+	}
+	return order, nil
+}
+
+func firstOf(names []string, set map[string]struct{}) (string, bool) {
+	for _, name := range names {
+		if _, ok := set[name]; ok {
+			return name, true
+		}
+	}
+	return "", false
+}
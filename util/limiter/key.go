@@ -0,0 +1,77 @@
+package limiter
+
+import (
+	"context"
+	"go-framework/util/auth/jwt"
+	"strings"
+	"sync"
+)
+
+// 内置的限流 key
+const (
+	KeyRoute = "route"
+	KeyIP    = "ip"
+	KeyUser  = "user"
+)
+
+// Request 提取限流 key 所需的请求信息
+type Request struct {
+	Operation string                  // http 路由模板或 grpc 完整方法名
+	ClientIP  string                  // 客户端 IP
+	Header    func(key string) string // 请求头
+	UserID    func() string           // 上游中间件已解析的用户 ID，可为空
+
+	secret string
+}
+
+// KeyFunc 从请求中提取限流 key，返回空字符串时跳过该规则
+type KeyFunc func(ctx context.Context, req Request) string
+
+var (
+	keyFuncsLock sync.RWMutex
+	keyFuncs     = map[string]KeyFunc{
+		KeyRoute: func(ctx context.Context, req Request) string { return req.Operation },
+		KeyIP:    func(ctx context.Context, req Request) string { return req.ClientIP },
+		KeyUser:  userKey,
+	}
+)
+
+// RegisterKeyFunc 注册自定义限流 key，需在创建 Limiter 之前调用
+func RegisterKeyFunc(name string, fn KeyFunc) {
+	keyFuncsLock.Lock()
+	defer keyFuncsLock.Unlock()
+	keyFuncs[name] = fn
+}
+
+func lookupKeyFunc(name string) (KeyFunc, bool) {
+	keyFuncsLock.RLock()
+	defer keyFuncsLock.RUnlock()
+	fn, ok := keyFuncs[name]
+	return fn, ok
+}
+
+// userKey 优先使用上游已解析的用户 ID，否则解析 Authorization 中的 JWT，匿名请求返回空
+func userKey(ctx context.Context, req Request) string {
+	if req.UserID != nil {
+		if id := req.UserID(); id != "" {
+			return id
+		}
+	}
+	if req.Header == nil || req.secret == "" {
+		return ""
+	}
+
+	token := strings.TrimSpace(req.Header("Authorization"))
+	if len(token) > 7 && strings.EqualFold(token[:7], "Bearer ") {
+		token = strings.TrimSpace(token[7:])
+	}
+	if token == "" {
+		return ""
+	}
+
+	data, err := jwt.ParseToken(token, req.secret)
+	if err != nil {
+		return ""
+	}
+	return data.UserId
+}
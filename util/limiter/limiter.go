@@ -0,0 +1,135 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"go-framework/pkg/aegis/ratelimit"
+	redislimit "go-framework/pkg/aegis/ratelimit/redis"
+	"go-framework/util/helper"
+	"strings"
+	"time"
+)
+
+// 限流算法
+const (
+	AlgorithmTokenBucket   = "token_bucket"
+	AlgorithmSlidingWindow = "sliding_window"
+)
+
+// Config 分布式限流配置
+type Config struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Rule 限流规则，按路由前缀匹配，匹配到的规则全部生效
+type Rule struct {
+	Name      string  `json:"name"`      // 规则名称，用于区分 redis key，默认为 prefix:key
+	Prefix    string  `json:"prefix"`    // 路由前缀，http 为路由模板，grpc 为完整方法名，为空匹配所有请求
+	Algorithm string  `json:"algorithm"` // token_bucket（默认）、sliding_window
+	Key       string  `json:"key"`       // route（默认）、ip、user 或通过 RegisterKeyFunc 注册的名称
+	Rate      float64 `json:"rate"`      // 令牌桶每秒生成的令牌数
+	Burst     int     `json:"burst"`     // 令牌桶容量，默认等于 rate
+	Limit     int     `json:"limit"`     // 滑动窗口内允许的请求数
+	Window    int     `json:"window"`    // 滑动窗口大小（秒），默认 1
+}
+
+// Limiter 按规则对请求进行分布式限流
+type Limiter struct {
+	rules  []*rule
+	secret string
+}
+
+type rule struct {
+	Rule
+	keyFunc KeyFunc
+	limiter *redislimit.Limiter
+}
+
+// New 根据配置创建限流器，secret 用于解析 JWT 中的用户 ID
+func New(client redis.Scripter, c interface{}, secret string, opts ...redislimit.Option) (*Limiter, error) {
+	var conf Config
+	if err := helper.UnMarshalWithInterface(c, &conf); err != nil {
+		return nil, err
+	}
+
+	l := &Limiter{secret: secret}
+	for _, r := range conf.Rules {
+		if r.Key == "" {
+			r.Key = KeyRoute
+		}
+		if r.Name == "" {
+			r.Name = r.Prefix + ":" + r.Key
+		}
+
+		keyFunc, ok := lookupKeyFunc(r.Key)
+		if !ok {
+			return nil, fmt.Errorf("limiter: rule %s: unknown key %q", r.Name, r.Key)
+		}
+
+		ruleOpts := append([]redislimit.Option{redislimit.WithPrefix("ratelimit:" + r.Name)}, opts...)
+		var limiter *redislimit.Limiter
+		switch r.Algorithm {
+		case "", AlgorithmTokenBucket:
+			if r.Rate <= 0 {
+				return nil, fmt.Errorf("limiter: rule %s: rate must be positive", r.Name)
+			}
+			limiter = redislimit.NewTokenBucket(client, r.Rate, r.Burst, ruleOpts...)
+		case AlgorithmSlidingWindow:
+			if r.Limit <= 0 {
+				return nil, fmt.Errorf("limiter: rule %s: limit must be positive", r.Name)
+			}
+			limiter = redislimit.NewSlidingWindow(client, r.Limit, time.Duration(r.Window)*time.Second, ruleOpts...)
+		default:
+			return nil, fmt.Errorf("limiter: rule %s: unknown algorithm %q", r.Name, r.Algorithm)
+		}
+
+		l.rules = append(l.rules, &rule{Rule: r, keyFunc: keyFunc, limiter: limiter})
+	}
+	return l, nil
+}
+
+// Empty 是否没有任何规则
+func (l *Limiter) Empty() bool {
+	return l == nil || len(l.rules) == 0
+}
+
+// Allow 依次检查匹配的规则，返回第一个触发的 *ratelimit.ExceededError，
+// key 为空的规则（如匿名请求按 user 限流）不参与限流
+func (l *Limiter) Allow(ctx context.Context, req Request) error {
+	if l.Empty() {
+		return nil
+	}
+
+	req.secret = l.secret
+	var exceeded error
+	for _, r := range l.rules {
+		if !strings.HasPrefix(req.Operation, r.Prefix) {
+			continue
+		}
+		key := r.keyFunc(ctx, req)
+		if key == "" {
+			continue
+		}
+		if err := r.limiter.Take(ctx, key); err != nil {
+			exceeded = err
+			break
+		}
+	}
+	return exceeded
+}
+
+// RetryAfter 限流错误建议的重试等待时间，向上取整到秒，至少 1 秒
+func RetryAfter(err error) int {
+	d, ok := ratelimit.RetryAfter(err)
+	if !ok || d <= 0 {
+		return 1
+	}
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// IsExceeded 是否为限流错误
+func IsExceeded(err error) bool {
+	return errors.Is(err, ratelimit.ErrLimitExceed)
+}
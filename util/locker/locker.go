@@ -23,6 +23,11 @@ func (m *Mutex) Lock(key string, opts ...redsync.Option) error {
 	return m.mutex.Lock()
 }
 
+// Extend 延长锁的过期时间，锁已失效时返回 false
+func (m *Mutex) Extend() (bool, error) {
+	return m.mutex.Extend()
+}
+
 // Unlock 解锁
 func (m *Mutex) Unlock() (bool, error) {
 	return m.mutex.Unlock()
@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"go-framework/pkg/aegis/ratelimit/bbr"
+	"sync"
+)
+
+var limiters = newLimiterCollector()
+
+// RegisterLimiter 将 BBR 限流器的 Stat 以 gauge 形式暴露，name 为 limiter 标签，同名覆盖
+func RegisterLimiter(name string, limiter *bbr.BBR) {
+	limiters.add(name, limiter)
+}
+
+type limiterCollector struct {
+	mu       sync.RWMutex
+	limiters map[string]*bbr.BBR
+
+	cpu         *prometheus.Desc
+	inFlight    *prometheus.Desc
+	maxInFlight *prometheus.Desc
+	minRt       *prometheus.Desc
+	maxPass     *prometheus.Desc
+}
+
+func newLimiterCollector() *limiterCollector {
+	labels := []string{"limiter"}
+	return &limiterCollector{
+		limiters:    make(map[string]*bbr.BBR),
+		cpu:         prometheus.NewDesc("bbr_cpu_usage", "BBR 采样的 CPU 使用率（千分比）", labels, nil),
+		inFlight:    prometheus.NewDesc("bbr_in_flight", "BBR 当前处理中的请求数", labels, nil),
+		maxInFlight: prometheus.NewDesc("bbr_max_in_flight", "BBR 估算的最大并发数", labels, nil),
+		minRt:       prometheus.NewDesc("bbr_min_rt_milliseconds", "BBR 窗口内最小响应时间", labels, nil),
+		maxPass:     prometheus.NewDesc("bbr_max_pass", "BBR 窗口内单桶最大通过数", labels, nil),
+	}
+}
+
+func (c *limiterCollector) add(name string, limiter *bbr.BBR) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.limiters[name] = limiter
+}
+
+func (c *limiterCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.cpu
+	ch <- c.inFlight
+	ch <- c.maxInFlight
+	ch <- c.minRt
+	ch <- c.maxPass
+}
+
+func (c *limiterCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for name, limiter := range c.limiters {
+		stat := limiter.Stat()
+		ch <- prometheus.MustNewConstMetric(c.cpu, prometheus.GaugeValue, float64(stat.CPU), name)
+		ch <- prometheus.MustNewConstMetric(c.inFlight, prometheus.GaugeValue, float64(stat.InFlight), name)
+		ch <- prometheus.MustNewConstMetric(c.maxInFlight, prometheus.GaugeValue, float64(stat.MaxInFlight), name)
+		ch <- prometheus.MustNewConstMetric(c.minRt, prometheus.GaugeValue, float64(stat.MinRt), name)
+		ch <- prometheus.MustNewConstMetric(c.maxPass, prometheus.GaugeValue, float64(stat.MaxPass), name)
+	}
+}
@@ -0,0 +1,30 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	// CronRunTotal 定时任务执行次数
+	CronRunTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cron_run_total",
+		Help: "定时任务执行次数",
+	}, []string{"task"})
+
+	// CronSkipTotal 定时任务因未获取到分布式锁而跳过的次数
+	CronSkipTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cron_skip_total",
+		Help: "定时任务跳过次数",
+	}, []string{"task"})
+
+	// CronPanicTotal 定时任务 panic 次数
+	CronPanicTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cron_panic_total",
+		Help: "定时任务 panic 次数",
+	}, []string{"task"})
+
+	// CronDuration 定时任务执行耗时
+	CronDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "cron_duration_seconds",
+		Help:    "定时任务执行耗时",
+		Buckets: []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300},
+	}, []string{"task"})
+)
@@ -0,0 +1,26 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"strconv"
+	"time"
+)
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_server_requests_total",
+		Help: "HTTP 请求总数",
+	}, []string{"method", "route", "code"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_server_request_duration_seconds",
+		Help:    "HTTP 请求耗时",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+// ObserveHTTP 记录一次 HTTP 请求，route 为路由模板而非实际路径，避免标签基数膨胀
+func ObserveHTTP(method, route string, code int, duration time.Duration) {
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(code)).Inc()
+	httpDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
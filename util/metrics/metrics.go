@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+)
+
+// Registry 指标注册中心，业务自定义指标通过 MustRegister 注册
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration,
+		grpcRequests, grpcDuration,
+		dbDuration, dbErrors,
+		redisDuration, redisErrors,
+		MQConsumeTotal, MQConsumeDuration, MQAckTotal, MQRetryTotal, MQDeadLetterTotal,
+		CronRunTotal, CronSkipTotal, CronPanicTotal, CronDuration,
+		limiters,
+	)
+}
+
+// MustRegister 注册自定义指标，重复注册会 panic
+func MustRegister(cs ...prometheus.Collector) {
+	Registry.MustRegister(cs...)
+}
+
+// Handler /metrics 接口
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
@@ -0,0 +1,43 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// 消费结果
+const (
+	ResultSuccess   = "success"
+	ResultFailed    = "failed"
+	ResultDuplicate = "duplicate"
+)
+
+var (
+	// MQConsumeTotal 消息消费数，result 为 success、failed 或 duplicate
+	MQConsumeTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "mq_consume_total",
+		Help: "消息消费数",
+	}, []string{"topic", "result"})
+
+	// MQConsumeDuration 消息处理耗时
+	MQConsumeDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "mq_consume_duration_seconds",
+		Help:    "消息处理耗时",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"topic"})
+
+	// MQAckTotal 消息确认数，result 为 success 或 failed
+	MQAckTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "mq_ack_total",
+		Help: "消息确认数",
+	}, []string{"topic", "result"})
+
+	// MQRetryTotal 消息重投递次数
+	MQRetryTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "mq_retry_total",
+		Help: "消息重投递次数",
+	}, []string{"topic"})
+
+	// MQDeadLetterTotal 进入死信队列的消息数
+	MQDeadLetterTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "mq_dead_letter_total",
+		Help: "进入死信队列的消息数",
+	}, []string{"topic"})
+)
@@ -0,0 +1,325 @@
+package mq
+
+import (
+	"context"
+	"fmt"
+	"go-framework/util/mq/queue"
+	"go-framework/util/tracer"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultBatchSize = 100
+	defaultBatchWait = time.Second
+)
+
+// BatchJob 批量执行的任务，同一任务的消息攒批后一次执行，每条消息单独确认，失败的消息按重试策略单独重试。
+// rocketmq 从拉取的消息中攒批，等待批次的消息不占用消费并发；其他驱动同步处理消息，等待批次的消息占用消费并发，
+// 批次中的消息数不超过 MaxConcurrency，批量大小大于 MaxConcurrency 时需通过 WithBatchLimit 限制每批的消息数，否则每批等待至超时
+type BatchJob interface {
+	queue.Job
+	// BatchOptions 每批的最大消息数与第一条消息到达后的最长等待时间
+	BatchOptions() (size int, wait time.Duration)
+	// ExecuteBatch 批量执行，返回与 payloads 一一对应的结果，nil 表示成功；返回空切片表示全部成功
+	ExecuteBatch(ctx context.Context, payloads []*Payload) []error
+}
+
+// ExecuteBatch 批量执行任务，结果数量与消息数不一致或 panic 时所有消息均视为失败
+func ExecuteBatch(ctx context.Context, job BatchJob, payloads []*Payload) (errs []error) {
+	defer func() {
+		if p := recover(); p != nil {
+			buf := make([]byte, 2048)
+			n := runtime.Stack(buf, false)
+			errs = batchErrors(len(payloads), &PanicError{Value: p, Stack: buf[:n]})
+		}
+	}()
+
+	errs = job.ExecuteBatch(ctx, payloads)
+	switch len(errs) {
+	case 0:
+		return make([]error, len(payloads))
+	case len(payloads):
+		return errs
+	default:
+		return batchErrors(len(payloads), fmt.Errorf("mq: batch job %s returned %d results for %d messages", job.Name(), len(errs), len(payloads)))
+	}
+}
+
+func batchErrors(n int, err error) []error {
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = err
+	}
+	return errs
+}
+
+var _ BatchJob = (*Batch[struct{}])(nil)
+
+// Batch 类型化批量任务，单独执行时（如重放死信）以只有一条消息的批次执行
+type Batch[T any] struct {
+	*Job[T]
+	size    int
+	wait    time.Duration
+	handler func(ctx context.Context, payloads []T) []error
+}
+
+// NewBatchJob 创建类型化批量任务，handler 返回与 payloads 一一对应的结果，返回空切片表示全部成功
+func NewBatchJob[T any](name string, handler func(ctx context.Context, payloads []T) []error, size int, wait time.Duration, opts ...JobOption) *Batch[T] {
+	b := &Batch[T]{size: size, wait: wait, handler: handler}
+	b.Job = NewJob(name, func(ctx context.Context, payload T) error {
+		return b.execute(ctx, []T{payload})[0]
+	}, opts...)
+	return b
+}
+
+// WithKey 设置业务幂等键
+func (b *Batch[T]) WithKey(fn func(payload T) string) *Batch[T] {
+	b.Job.WithKey(fn)
+	return b
+}
+
+// WithShardingKey 设置分区键
+func (b *Batch[T]) WithShardingKey(fn func(payload T) string) *Batch[T] {
+	b.Job.WithShardingKey(fn)
+	return b
+}
+
+// BatchOptions 每批的最大消息数与最长等待时间
+func (b *Batch[T]) BatchOptions() (int, time.Duration) {
+	return b.size, b.wait
+}
+
+// ExecuteBatch 逐条解码后批量执行，解码失败的消息单独返回错误
+func (b *Batch[T]) ExecuteBatch(ctx context.Context, payloads []*Payload) []error {
+	errs := make([]error, len(payloads))
+	values := make([]T, 0, len(payloads))
+	index := make([]int, 0, len(payloads))
+	for i, p := range payloads {
+		v, err := b.payload(p)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		values = append(values, v)
+		index = append(index, i)
+	}
+	if len(values) == 0 {
+		return errs
+	}
+
+	for j, err := range b.execute(ctx, values) {
+		errs[index[j]] = err
+	}
+	return errs
+}
+
+// execute 执行 handler，结果数量不一致时所有消息均视为失败
+func (b *Batch[T]) execute(ctx context.Context, values []T) []error {
+	errs := b.handler(ctx, values)
+	switch len(errs) {
+	case 0:
+		return make([]error, len(values))
+	case len(values):
+		return errs
+	default:
+		return batchErrors(len(values), fmt.Errorf("mq: batch job %s returned %d results for %d messages", b.name, len(errs), len(values)))
+	}
+}
+
+// Batchers 按任务名称创建的攒批执行器，零值可用
+type Batchers struct {
+	mu       sync.Mutex
+	batchers map[string]*Batcher
+}
+
+// Execute 执行任务，批量任务加入所属的批次并等待批次执行完成，limit 为每批的消息数上限，见 Batcher.Do
+func (b *Batchers) Execute(ctx context.Context, job queue.Job, p *Payload, limit int) error {
+	bj, ok := job.(BatchJob)
+	if !ok {
+		return ExecuteJob(ctx, job, p)
+	}
+	return b.batcher(bj).Do(ctx, p, limit)
+}
+
+// Submit 批量任务加入所属的批次后立即返回，批次执行后以该消息的结果调用 done；不是批量任务时返回 false
+func (b *Batchers) Submit(ctx context.Context, job queue.Job, p *Payload, done func(err error)) bool {
+	bj, ok := job.(BatchJob)
+	if !ok {
+		return false
+	}
+	b.batcher(bj).Add(ctx, p, done)
+	return true
+}
+
+func (b *Batchers) batcher(job BatchJob) *Batcher {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.batchers == nil {
+		b.batchers = make(map[string]*Batcher)
+	}
+	batcher, ok := b.batchers[job.Name()]
+	if !ok {
+		batcher = NewBatcher(job)
+		b.batchers[job.Name()] = batcher
+	}
+	return batcher
+}
+
+// Batcher 将同一任务的消息攒批，达到批量大小或等待超时后执行，并将每条消息的结果返回给提交者
+type Batcher struct {
+	job     BatchJob
+	size    int
+	wait    time.Duration
+	mu      sync.Mutex
+	pending *batch
+}
+
+// batch 攒批中的消息
+type batch struct {
+	ctxs     []context.Context
+	payloads []*Payload
+	dones    []func(err error)
+	timer    *time.Timer
+	once     sync.Once
+}
+
+// NewBatcher 创建攒批执行器，批量大小与等待时间未设置时默认 100 条、1 秒
+func NewBatcher(job BatchJob) *Batcher {
+	size, wait := job.BatchOptions()
+	if size <= 0 {
+		size = defaultBatchSize
+	}
+	if wait <= 0 {
+		wait = defaultBatchWait
+	}
+	return &Batcher{job: job, size: size, wait: wait}
+}
+
+// Do 加入当前批次并等待批次执行，返回该消息的执行结果。
+// limit 为每批的消息数上限，小于批量大小时批次达到 limit 条即执行；0 表示按批量大小
+func (b *Batcher) Do(ctx context.Context, p *Payload, limit int) error {
+	errc := make(chan error, 1)
+	b.add(ctx, p, limit, func(err error) { errc <- err })
+	return <-errc
+}
+
+// Add 加入当前批次后立即返回，批次执行后以该消息的结果调用 done
+func (b *Batcher) Add(ctx context.Context, p *Payload, done func(err error)) {
+	b.add(ctx, p, 0, done)
+}
+
+// add 加入当前批次，批次已满时在当前协程执行
+func (b *Batcher) add(ctx context.Context, p *Payload, limit int, done func(err error)) {
+	size := b.size
+	if limit > 0 && limit < size {
+		size = limit
+	}
+
+	b.mu.Lock()
+	bt := b.pending
+	if bt == nil {
+		bt = &batch{}
+		bt.timer = time.AfterFunc(b.wait, func() { b.flush(bt) })
+		b.pending = bt
+	}
+	bt.ctxs = append(bt.ctxs, ctx)
+	bt.payloads = append(bt.payloads, p)
+	bt.dones = append(bt.dones, done)
+	full := len(bt.payloads) >= size
+	if full {
+		b.pending = nil
+	}
+	b.mu.Unlock()
+
+	if full {
+		bt.timer.Stop()
+		b.flush(bt)
+	}
+}
+
+// flush 执行批次，批次只执行一次
+func (b *Batcher) flush(bt *batch) {
+	b.mu.Lock()
+	if b.pending == bt {
+		b.pending = nil
+	}
+	b.mu.Unlock()
+
+	bt.once.Do(func() {
+		ctx, cancel := batchContext(bt.ctxs)
+		defer cancel()
+		ctx, span := startBatch(ctx, b.job.Name(), bt.ctxs)
+		errs := ExecuteBatch(ctx, b.job, bt.payloads)
+		failed := 0
+		for _, err := range errs {
+			if err != nil {
+				failed++
+			}
+		}
+		span.SetAttributes(attribute.Int("messaging.batch.failed_count", failed))
+		span.End()
+		for i, done := range bt.dones {
+			done(errs[i])
+		}
+	})
+}
+
+type batchKey struct{}
+
+// BatchContexts 批量任务执行时批次中每条消息的 ctx，与 payloads 一一对应，
+// 用于获取每条消息的元数据（MetadataFromContext）与日志（LoggerFromContext）
+func BatchContexts(ctx context.Context) []context.Context {
+	ctxs, _ := ctx.Value(batchKey{}).([]context.Context)
+	return ctxs
+}
+
+// batchContext 批次的 ctx 沿用第一条消息 ctx 中的元数据与日志，所有消息的 ctx 都结束后取消
+func batchContext(ctxs []context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctxs[0]))
+	var remaining atomic.Int64
+	remaining.Store(int64(len(ctxs)))
+	stops := make([]func() bool, 0, len(ctxs))
+	done := func() {
+		if remaining.Add(-1) == 0 {
+			cancel()
+		}
+	}
+	for _, c := range ctxs {
+		if c.Err() != nil {
+			done()
+			continue
+		}
+		stops = append(stops, context.AfterFunc(c, done))
+	}
+	return context.WithValue(ctx, batchKey{}, ctxs), func() {
+		for _, stop := range stops {
+			stop()
+		}
+		cancel()
+	}
+}
+
+// startBatch 为批次创建新的 span 并关联每条消息的消费 span
+func startBatch(ctx context.Context, job string, ctxs []context.Context) (context.Context, trace.Span) {
+	links := make([]trace.Link, 0, len(ctxs))
+	for _, c := range ctxs {
+		if sc := trace.SpanContextFromContext(c); sc.IsValid() {
+			links = append(links, trace.Link{SpanContext: sc})
+		}
+	}
+	t := otel.Tracer(instrumentationName)
+	ctx, span := t.Start(ctx, job+" batch",
+		trace.WithNewRoot(),
+		trace.WithLinks(links...),
+		trace.WithAttributes(
+			attribute.String("messaging.job", job),
+			attribute.Int("messaging.batch.message_count", len(ctxs)),
+		))
+	return context.WithValue(ctx, tracer.TracerKey, t), span
+}
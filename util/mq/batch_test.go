@@ -0,0 +1,72 @@
+package mq_test
+
+import (
+	"context"
+	"go-framework/util/mq"
+	"testing"
+	"time"
+)
+
+// newOrderBatchJob 创建记录每批消息的批量任务
+func newOrderBatchJob(name string, size int, wait time.Duration) (*mq.Batch[order], <-chan []order) {
+	batches := make(chan []order, 16)
+	job := mq.NewBatchJob(name, func(ctx context.Context, orders []order) []error {
+		batches <- orders
+		return nil
+	}, size, wait)
+	return job, batches
+}
+
+// dispatchOrders 依次发送 n 条消息
+func dispatchOrders(t *testing.T, b *mq.DriverBroker, job *mq.Batch[order], n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if err := mq.Dispatch(context.Background(), b, job, order{ID: string(rune('a' + i))}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBatchFlushBySize(t *testing.T) {
+	job, batches := newOrderBatchJob("batch_size_order", 3, time.Hour)
+	b, q := newBroker(t, newMemoryDriver(t), job)
+	if err := b.Subscribe(q, mq.WithConcurrency(3)); err != nil {
+		t.Fatal(err)
+	}
+
+	dispatchOrders(t, b, job, 3)
+	if batch := receive(t, batches); len(batch) != 3 {
+		t.Errorf("batch size = %d, want 3", len(batch))
+	}
+}
+
+func TestBatchFlushByInterval(t *testing.T) {
+	wait := 50 * time.Millisecond
+	job, batches := newOrderBatchJob("batch_interval_order", 10, wait)
+	b, q := newBroker(t, newMemoryDriver(t), job)
+	if err := b.Subscribe(q, mq.WithConcurrency(2)); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	dispatchOrders(t, b, job, 2)
+	if batch := receive(t, batches); len(batch) != 2 {
+		t.Errorf("batch size = %d, want 2", len(batch))
+	}
+	if elapsed := time.Since(start); elapsed < wait {
+		t.Errorf("batch flushed after %s, want at least %s", elapsed, wait)
+	}
+}
+
+func TestBatchFlushByLimit(t *testing.T) {
+	job, batches := newOrderBatchJob("batch_limit_order", 10, time.Hour)
+	b, q := newBroker(t, newMemoryDriver(t), job)
+	if err := b.Subscribe(q, mq.WithConcurrency(2), mq.WithBatchLimit(2)); err != nil {
+		t.Fatal(err)
+	}
+
+	dispatchOrders(t, b, job, 2)
+	if batch := receive(t, batches); len(batch) != 2 {
+		t.Errorf("batch size = %d, want 2", len(batch))
+	}
+}
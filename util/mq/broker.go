@@ -0,0 +1,470 @@
+package mq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-framework/util/metrics"
+	"go-framework/util/mq/queue"
+	"go-framework/util/xlog"
+	"runtime"
+	"sync"
+	"time"
+)
+
+const resubscribeInterval = 3 * time.Second
+
+var (
+	// ErrMissingID 消息没有 ID，无法计算幂等键
+	ErrMissingID = errors.New("mq: message has no id")
+	// ErrUnknownJob 消息的任务未在所属主题注册
+	ErrUnknownJob = errors.New("mq: job is not registered")
+)
+
+var (
+	_ Broker           = (*DriverBroker)(nil)
+	_ ConsumerProvider = (*DriverBroker)(nil)
+	_ ConsumerControl  = (*consumer)(nil)
+)
+
+// Notifier 告警通知
+type Notifier interface {
+	SendAlarm(ctx context.Context, msg string) error
+}
+
+// BrokerOption 中间件选项
+type BrokerOption func(b *DriverBroker)
+
+// WithGroupName 设置消费组名称的转换函数，如按环境添加后缀
+func WithGroupName(fn func(groupId string) string) BrokerOption {
+	return func(b *DriverBroker) {
+		b.groupName = fn
+	}
+}
+
+// DriverBroker 基于 Driver 的消息中间件，负责任务消息的编解码、执行与重试
+type DriverBroker struct {
+	name        string
+	driver      Driver
+	registry    *Registry
+	logger      *xlog.Log
+	notifier    Notifier
+	deadLetters DeadLetterQueue
+	idempotency *Idempotency
+	batchers    Batchers
+	groupName   func(groupId string) string
+	consumers   []*consumer
+	mu          sync.RWMutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewBroker 创建消息中间件，queues 为该中间件负责收发的队列
+func NewBroker(name string, driver Driver, queues []queue.Queue, logger *xlog.Log, opts ...BrokerOption) (*DriverBroker, error) {
+	registry := NewRegistry()
+	if err := registry.Add(queues...); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &DriverBroker{
+		name:      name,
+		driver:    driver,
+		registry:  registry,
+		logger:    logger,
+		groupName: func(groupId string) string { return groupId },
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b, nil
+}
+
+// SetNotifier 设置告警通知
+func (b *DriverBroker) SetNotifier(notifier Notifier) {
+	b.notifier = notifier
+}
+
+// SetDeadLetterQueue 设置死信队列，未设置时超过重试策略的消息被丢弃
+func (b *DriverBroker) SetDeadLetterQueue(deadLetters DeadLetterQueue) {
+	b.deadLetters = deadLetters
+}
+
+// SetIdempotency 设置消费幂等，已处理成功的消息不再执行
+func (b *DriverBroker) SetIdempotency(idempotency *Idempotency) {
+	b.idempotency = idempotency
+}
+
+// Publish 发送原始消息，消息头写入链路上下文
+func (b *DriverBroker) Publish(ctx context.Context, msg *Message) (err error) {
+	ctx, span := StartPublish(ctx, b.name, msg)
+	defer func() { EndSpan(span, err) }()
+	return b.driver.Publish(ctx, msg)
+}
+
+// SendJobMessage 发送任务消息
+func (b *DriverBroker) SendJobMessage(ctx context.Context, job queue.Job, msg interface{}, opts ...SendOption) error {
+	return b.send(ctx, job, msg, 0, opts...)
+}
+
+// SendJobDelayMessage 发送延时任务消息
+func (b *DriverBroker) SendJobDelayMessage(ctx context.Context, job queue.Job, msg interface{}, delay time.Duration, opts ...SendOption) error {
+	return b.send(ctx, job, msg, delay, opts...)
+}
+
+// JobMessage 编码任务消息，任务提供的分区键写入消息头
+func (b *DriverBroker) JobMessage(job queue.Job, msg interface{}) (*Message, error) {
+	queueJob, ok := b.registry.Job(job.Name())
+	if !ok {
+		return nil, fmt.Errorf("mq: job %s is not registered on %s", job.Name(), b.name)
+	}
+	payload, err := EncodeJob(queueJob.Job, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := payload.Headers()
+	headers[HeaderJob] = job.Name()
+	if key := JobShardingKey(queueJob.Job, msg); key != "" {
+		headers[HeaderShardingKey] = key
+	}
+	return &Message{Topic: queueJob.Queue.Topic(), Body: payload.Body, Headers: headers}, nil
+}
+
+func (b *DriverBroker) send(ctx context.Context, job queue.Job, msg interface{}, delay time.Duration, opts ...SendOption) error {
+	message, err := b.JobMessage(job, msg)
+	if err != nil {
+		return err
+	}
+	NewSendOptions(opts...).Apply(message)
+	message.Delay = delay
+	err = b.Publish(ctx, message)
+	if err != nil {
+		b.notify(ctx, "【队列生产者】%s 发送异常：\n 错误信息:\n %+v \n请求数据：\n %+v \n", b.name, err, msg)
+		return err
+	}
+	b.logger.Infof("队列发送成功：%s %s %+v", b.name, message.Topic, msg)
+	return nil
+}
+
+// consumer 订阅中的队列，通过流控暂停、恢复与调整并发
+type consumer struct {
+	*FlowControl
+	broker string
+	queue  queue.Queue
+	group  string
+	opts   ConsumeOptions
+}
+
+// State 运行状态
+func (c *consumer) State() ConsumerState {
+	return c.FlowControl.State(ConsumerState{Broker: c.broker, Topic: c.queue.Topic(), Group: c.group})
+}
+
+// Subscribe 订阅队列，连接异常时自动重新订阅。
+// 驱动按 MaxConcurrency 启动消费协程，流控限制同时执行的任务数，暂停或并发已满时驱动已拉取的消息等待执行。
+// 顺序消费只支持按分区独占消费的驱动，其他驱动无法保证同一分区键的消息按发送顺序投递，订阅时返回错误
+func (b *DriverBroker) Subscribe(q queue.Queue, opts ...ConsumeOption) error {
+	if b.registry.Queue(q.Topic()) == nil {
+		return fmt.Errorf("mq: queue %s is not registered on %s", q.Topic(), b.name)
+	}
+	if _, ok := b.Consumer(q.Topic()); ok {
+		return fmt.Errorf("mq: queue %s is already subscribed on %s", q.Topic(), b.name)
+	}
+	o := NewConsumeOptions(opts...)
+	if o.Ordered && !partitioned(b.driver) {
+		return fmt.Errorf("mq: ordered consumption is not supported on %s", b.name)
+	}
+	if o.MaxConcurrency < o.Concurrency {
+		o.MaxConcurrency = o.Concurrency
+	}
+	c := &consumer{
+		FlowControl: NewFlowControl(o.Concurrency, o.MaxConcurrency, o.Paused),
+		broker:      b.name,
+		queue:       q,
+		group:       b.groupName(q.GroupId()),
+		opts:        o,
+	}
+	b.mu.Lock()
+	b.consumers = append(b.consumers, c)
+	b.mu.Unlock()
+
+	subscribeOpts := SubscribeOptions{
+		Group:       c.group,
+		Concurrency: o.MaxConcurrency,
+	}
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		for {
+			err := b.driver.Subscribe(b.ctx, q.Topic(), subscribeOpts, func(ctx context.Context, d *Delivery) error {
+				// 暂停或并发已满时等待，停止消费时返回错误，消息不确认由驱动重新投递
+				if err := c.Acquire(b.ctx); err != nil {
+					return err
+				}
+				defer c.Release()
+				return b.process(ctx, c, d)
+			})
+			if b.ctx.Err() != nil {
+				return
+			}
+			b.notify(b.ctx, "【队列消费】%s topic %s 订阅异常: %+v", b.name, q.Topic(), err)
+
+			select {
+			case <-b.ctx.Done():
+				return
+			case <-time.After(resubscribeInterval):
+			}
+		}
+	}()
+	return nil
+}
+
+// Consumer 主题的消费者
+func (b *DriverBroker) Consumer(topic string) (ConsumerControl, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, c := range b.consumers {
+		if c.queue.Topic() == topic {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// Consumers 所有订阅中的消费者
+func (b *DriverBroker) Consumers() []ConsumerControl {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	consumers := make([]ConsumerControl, 0, len(b.consumers))
+	for _, c := range b.consumers {
+		consumers = append(consumers, c)
+	}
+	return consumers
+}
+
+// Shutdown 停止消费，等待处理中的消息完成
+func (b *DriverBroker) Shutdown(ctx context.Context) error {
+	b.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("mq %s: wait for in-flight messages: %w", b.name, ctx.Err())
+	}
+}
+
+// Ping 检查中间件是否可达
+func (b *DriverBroker) Ping(ctx context.Context) error {
+	return b.driver.Ping(ctx)
+}
+
+// Close 释放连接
+func (b *DriverBroker) Close() error {
+	return b.driver.Close()
+}
+
+// process 执行消息对应的任务。失败时按重试策略重新发送延时消息，未设置策略时返回错误由驱动重新投递，
+// 超过重试次数后写入死信队列；顺序消费时原地重试
+func (b *DriverBroker) process(ctx context.Context, c *consumer, d *Delivery) error {
+	topic, group, o := c.queue.Topic(), c.group, c.opts
+	md := &Metadata{ID: d.ID, Topic: topic, Group: group, Job: d.Headers[HeaderJob], Key: d.Key, Attempts: d.Attempts, Headers: d.Headers}
+	ctx, span := StartConsume(ctx, b.name, md, b.logger)
+	var err error
+	// span 记录任务执行结果，重试或写入死信后返回的错误不影响
+	defer func() { EndSpan(span, err) }()
+
+	// 没有 ID 的消息无法去重，写入死信队列，避免不同消息被当作同一条消息跳过
+	if d.ID == "" {
+		err = ErrMissingID
+		return b.deadLetter(ctx, group, md.Job, d, err)
+	}
+
+	// 任务未注册或注册在其他主题的消息写入死信队列，注册任务后可重放
+	queueJob, ok := b.registry.Job(md.Job)
+	if !ok || queueJob.Queue.Topic() != topic {
+		err = fmt.Errorf("%w: %s on topic %s", ErrUnknownJob, md.Job, topic)
+		return b.deadLetter(ctx, group, md.Job, d, err)
+	}
+	if d.Attempts > 1 {
+		metrics.MQRetryTotal.WithLabelValues(topic).Inc()
+	}
+
+	payload := NewPayload(d.Headers, d.Body)
+	record, ok := b.begin(ctx, group, queueJob.Job, payload, d)
+	if !ok {
+		if record.State == StateSucceeded {
+			return nil
+		}
+		// 正由其他消费者处理的消息按剩余租约延后投递，不计入投递次数；顺序消费时返回错误由驱动原地重试
+		if o.Ordered {
+			return ErrProcessing
+		}
+		return b.postpone(ctx, queueJob.Job.Name(), d, record)
+	}
+
+	// 顺序消费失败时原地重试，超过重试策略后写入死信队列，停止消费时返回错误由驱动重新投递
+	if o.Ordered {
+		retry := OrderedRetry{Policy: JobRetryPolicy(queueJob.Job, o.RetryPolicy), RetryTimes: o.RetryTimes, OnFailure: o.OrderedFailure}
+		var attempts int
+		attempts, err = retry.Do(b.ctx, d.Attempts, func(attempts int) error {
+			// 原地重试时任务从消息元数据中读取的投递次数随之递增
+			md.Attempts = attempts
+			return b.run(ctx, c, queueJob.Job, payload, attempts)
+		})
+		b.end(ctx, record, err)
+		if err == nil || b.ctx.Err() != nil {
+			return err
+		}
+		d.Attempts = attempts
+		d.Headers = AppendHistory(d.Headers, attempts, err)
+		return b.deadLetter(ctx, group, queueJob.Job.Name(), d, err)
+	}
+
+	err = b.run(ctx, c, queueJob.Job, payload, d.Attempts)
+	b.end(ctx, record, err)
+	if err == nil {
+		return nil
+	}
+	d.Headers = AppendHistory(d.Headers, d.Attempts, err)
+
+	policy := JobRetryPolicy(queueJob.Job, o.RetryPolicy)
+	if policy == nil {
+		if int64(d.Attempts) > o.RetryTimes {
+			return b.deadLetter(ctx, group, queueJob.Job.Name(), d, err)
+		}
+		return err
+	}
+
+	delay, retry := policy.Next(d.Attempts)
+	if !retry {
+		return b.deadLetter(ctx, group, queueJob.Job.Name(), d, err)
+	}
+	msg := Retry(d)
+	msg.Delay = delay
+	if e := b.Publish(ctx, msg); e != nil {
+		// 重试消息发送失败时由驱动重新投递原消息
+		b.notify(ctx, "【队列消费】%s 重试消息发送失败: %+v", queueJob.Job.Name(), e)
+		return err
+	}
+	return nil
+}
+
+// run 执行任务并记录指标与处理结果
+func (b *DriverBroker) run(ctx context.Context, c *consumer, job queue.Job, payload *Payload, attempts int) error {
+	topic := c.queue.Topic()
+	start := time.Now()
+	err := b.execute(ctx, job, payload, c.opts.BatchLimit)
+	metrics.MQConsumeDuration.WithLabelValues(topic).Observe(time.Since(start).Seconds())
+	c.Record(err)
+	if err == nil {
+		metrics.MQConsumeTotal.WithLabelValues(topic, metrics.ResultSuccess).Inc()
+		return nil
+	}
+
+	metrics.MQConsumeTotal.WithLabelValues(topic, metrics.ResultFailed).Inc()
+	b.notify(ctx, "【队列消费】%s 消息消费失败,参数：%s, 第 %d 次执行失败: %+v", job.Name(), payload.Body, attempts, err)
+	return err
+}
+
+// postpone 重新发送延时消息，租约到期后若仍未处理成功由该消息接管，发送失败时返回错误由驱动重新投递
+func (b *DriverBroker) postpone(ctx context.Context, job string, d *Delivery, record *ProcessedRecord) error {
+	// 延时按秒向上取整，避免按毫秒生成过多延时队列
+	delay := (time.Until(record.LeaseUntil) + time.Second - 1).Truncate(time.Second)
+	if delay < time.Second {
+		delay = time.Second
+	}
+	msg := Redeliver(d)
+	msg.Delay = delay
+	if err := b.Publish(ctx, msg); err != nil {
+		b.notify(ctx, "【队列消费】%s 消息 %s 延后投递失败: %+v", job, d.ID, err)
+		return ErrProcessing
+	}
+	return nil
+}
+
+// partitioned 驱动是否按分区独占消费
+func partitioned(driver Driver) bool {
+	p, ok := driver.(PartitionedDriver)
+	return ok && p.Partitioned()
+}
+
+// begin 获取消息处理记录，返回 false 时消息已处理成功或正由其他消费者处理
+func (b *DriverBroker) begin(ctx context.Context, group string, job queue.Job, payload *Payload, d *Delivery) (*ProcessedRecord, bool) {
+	if b.idempotency == nil {
+		return nil, true
+	}
+	record, ok, err := b.idempotency.Begin(ctx, group, job, payload, d)
+	if err != nil {
+		b.notify(ctx, "【队列消费】%s 消息 %s 获取处理记录失败，继续执行: %+v", job.Name(), d.ID, err)
+		return nil, true
+	}
+	if !ok {
+		b.logger.Infof("队列消息跳过：%s 消息 %s 处理记录 %s 状态 %s", job.Name(), d.ID, record.Key, record.State)
+	}
+	return record, ok
+}
+
+// end 记录处理结果
+func (b *DriverBroker) end(ctx context.Context, record *ProcessedRecord, err error) {
+	if record == nil {
+		return
+	}
+	if e := b.idempotency.End(ctx, record, err); e != nil {
+		b.notify(ctx, "【队列消费】处理记录 %s 更新失败: %+v", record.Key, e)
+	}
+}
+
+// deadLetter 写入死信队列，写入失败时返回错误由驱动重新投递
+func (b *DriverBroker) deadLetter(ctx context.Context, group, job string, d *Delivery, err error) error {
+	metrics.MQDeadLetterTotal.WithLabelValues(d.Topic).Inc()
+	if b.deadLetters == nil {
+		b.notify(ctx, "【队列消费】%s 消息 %s 无法处理，已丢弃: %+v", job, d.ID, err)
+		return nil
+	}
+
+	dl := NewDeadLetter(d, group, job, err)
+	if e := b.deadLetters.Push(ctx, dl); e != nil {
+		b.notify(ctx, "【队列消费】%s 消息 %s 写入死信队列失败: %+v", job, d.ID, e)
+		return err
+	}
+	b.notify(ctx, "【队列消费】%s 消息 %s 无法处理，已写入死信队列 %s：%s，原因: %+v", job, d.ID, DeadLetterTopic(d.Topic), dl.ID, err)
+	return nil
+}
+
+// execute 执行任务，limit 为批量任务每批的消息数上限，0 表示按任务的批量大小
+func (b *DriverBroker) execute(ctx context.Context, job queue.Job, payload *Payload, limit int) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			buf := make([]byte, 2048)
+			n := runtime.Stack(buf, false)
+			b.notify(ctx, "【任务执行异常】\n 错误内容：%+v\n%s", p, buf[:n])
+			err = &PanicError{Value: p, Stack: buf[:n]}
+		}
+	}()
+	return b.batchers.Execute(ctx, job, payload, limit)
+}
+
+func (b *DriverBroker) notify(ctx context.Context, format string, a ...any) {
+	message := fmt.Sprintf(format, a...)
+	b.logger.Errorf(message)
+	if b.notifier == nil {
+		return
+	}
+	err := b.notifier.SendAlarm(ctx, fmt.Sprintf("告警信息：\n%s\n", message))
+	if err != nil {
+		b.logger.Errorf("【队列】钉钉机器人发送失败: %+v", err)
+	}
+}
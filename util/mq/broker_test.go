@@ -0,0 +1,424 @@
+package mq_test
+
+import (
+	"context"
+	"errors"
+	"go-framework/util/mq"
+	"go-framework/util/mq/memory"
+	"go-framework/util/mq/queue"
+	"go-framework/util/xlog"
+	"go.uber.org/zap"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testTopic   = "test_topic"
+	testGroup   = "test_group"
+	testTimeout = 5 * time.Second
+)
+
+type testQueue struct {
+	jobs []queue.Job
+}
+
+func (q *testQueue) Topic() string        { return testTopic }
+func (q *testQueue) GroupId() string      { return testGroup }
+func (q *testQueue) Enqueue() []queue.Job { return q.jobs }
+
+// partitioned 将内存驱动视为按分区独占消费的驱动，单并发时与 kafka 的单个分区一致，用于测试顺序消费
+type partitioned struct {
+	*memory.Driver
+}
+
+func (partitioned) Partitioned() bool { return true }
+
+// deadLetters 内存死信队列，每写入一条死信通知一次
+type deadLetters struct {
+	mu     sync.Mutex
+	list   []*mq.DeadLetter
+	pushed chan *mq.DeadLetter
+}
+
+func newDeadLetters() *deadLetters {
+	return &deadLetters{pushed: make(chan *mq.DeadLetter, 16)}
+}
+
+func (q *deadLetters) Push(ctx context.Context, dl *mq.DeadLetter) error {
+	q.mu.Lock()
+	q.list = append(q.list, dl)
+	q.mu.Unlock()
+	q.pushed <- dl
+	return nil
+}
+
+func (q *deadLetters) List(ctx context.Context, topic string, offset, limit int64) ([]*mq.DeadLetter, int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.list, int64(len(q.list)), nil
+}
+
+func (q *deadLetters) Get(ctx context.Context, topic, id string) (*mq.DeadLetter, error) {
+	return nil, mq.ErrDeadLetterNotFound
+}
+
+func (q *deadLetters) Delete(ctx context.Context, topic string, ids ...string) error {
+	return nil
+}
+
+func (q *deadLetters) Topics(ctx context.Context) ([]string, error) {
+	return []string{testTopic}, nil
+}
+
+func (q *deadLetters) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.list)
+}
+
+// processedStore 内存消息处理记录
+type processedStore struct {
+	mu      sync.Mutex
+	records map[string]mq.ProcessedRecord
+}
+
+func newProcessedStore() *processedStore {
+	return &processedStore{records: make(map[string]mq.ProcessedRecord)}
+}
+
+func (s *processedStore) Acquire(ctx context.Context, r *mq.ProcessedRecord, lease time.Duration) (*mq.ProcessedRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.records[r.Key]
+	if ok && (existing.State == mq.StateSucceeded || existing.State == mq.StateProcessing && existing.LeaseUntil.After(time.Now())) {
+		return &existing, false, nil
+	}
+	rec := *r
+	rec.State = mq.StateProcessing
+	rec.Attempts = existing.Attempts + 1
+	rec.LeaseUntil = time.Now().Add(lease)
+	s.records[r.Key] = rec
+	return nil, true, nil
+}
+
+func (s *processedStore) Finish(ctx context.Context, key, owner, state, errMsg string, retention time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.records[key]
+	if !ok || rec.Owner != owner {
+		return mq.ErrLeaseLost
+	}
+	rec.State, rec.Error, rec.LeaseUntil = state, errMsg, time.Time{}
+	s.records[key] = rec
+	return nil
+}
+
+func (s *processedStore) Get(ctx context.Context, key string) (*mq.ProcessedRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.records[key]
+	if !ok {
+		return nil, mq.ErrProcessedNotFound
+	}
+	return &rec, nil
+}
+
+func (s *processedStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+// newMemoryDriver 创建重试间隔为 10 毫秒的内存驱动
+func newMemoryDriver(t *testing.T) *memory.Driver {
+	t.Helper()
+	driver, err := memory.New(map[string]interface{}{"retry_delay": 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return driver
+}
+
+// newBroker 创建注册了 jobs 的中间件，测试结束时停止消费
+func newBroker(t *testing.T, driver mq.Driver, jobs ...queue.Job) (*mq.DriverBroker, queue.Queue) {
+	t.Helper()
+	q := &testQueue{jobs: jobs}
+	b, err := mq.NewBroker("memory", driver, []queue.Queue{q}, xlog.NewLog(zap.NewNop(), xlog.Filter{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+		defer cancel()
+		if err := b.Shutdown(ctx); err != nil {
+			t.Error(err)
+		}
+		_ = b.Close()
+	})
+	return b, q
+}
+
+// receive 等待 ch 中的下一个值
+func receive[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(testTimeout):
+		t.Fatal("timed out")
+		panic("unreachable")
+	}
+}
+
+type order struct {
+	ID   string `json:"id"`
+	Fail int    `json:"fail"` // 前 Fail 次执行失败，-1 表示始终失败
+}
+
+// execution 任务的一次执行
+type execution struct {
+	ID       string
+	Attempts int
+	Err      error
+}
+
+// newOrderJob 创建记录每次执行的任务，按消息的 Fail 返回错误
+func newOrderJob(name string) (*mq.Job[order], <-chan execution) {
+	executions := make(chan execution, 64)
+	var mu sync.Mutex
+	count := make(map[string]int)
+	job := mq.NewJob(name, func(ctx context.Context, o order) error {
+		md, _ := mq.MetadataFromContext(ctx)
+		mu.Lock()
+		count[o.ID]++
+		n := count[o.ID]
+		mu.Unlock()
+
+		var err error
+		if o.Fail < 0 || n <= o.Fail {
+			err = errors.New("order failed")
+		}
+		executions <- execution{ID: o.ID, Attempts: md.Attempts, Err: err}
+		return err
+	})
+	return job, executions
+}
+
+func TestRetryPolicyDeadLetter(t *testing.T) {
+	job, executions := newOrderJob("retry_order")
+	b, q := newBroker(t, newMemoryDriver(t), job)
+	dls := newDeadLetters()
+	b.SetDeadLetterQueue(dls)
+	policy := mq.FixedRetry{Delay: 10 * time.Millisecond, MaxAttempts: 3}
+	if err := b.Subscribe(q, mq.WithRetryPolicy(policy)); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if err := mq.Dispatch(ctx, b, job, order{ID: "a", Fail: -1}); err != nil {
+		t.Fatal(err)
+	}
+	for attempts := 1; attempts <= 3; attempts++ {
+		if e := receive(t, executions); e.Attempts != attempts || e.Err == nil {
+			t.Fatalf("execution %d = %+v", attempts, e)
+		}
+	}
+
+	dl := receive(t, dls.pushed)
+	if dl.Job != job.Name() || dl.Topic != testTopic || dl.Group != testGroup {
+		t.Errorf("dead letter = %+v", dl)
+	}
+	if dl.Attempts != 3 || len(dl.History) != 3 {
+		t.Errorf("dead letter attempts = %d, history = %d, want 3, 3", dl.Attempts, len(dl.History))
+	}
+	if _, ok := dl.Headers[mq.HeaderAttempts]; ok {
+		t.Error("dead letter headers contain attempts")
+	}
+	select {
+	case e := <-executions:
+		t.Errorf("executed after dead-lettering: %+v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestRetryPolicySucceeds(t *testing.T) {
+	job, executions := newOrderJob("retry_success_order")
+	b, q := newBroker(t, newMemoryDriver(t), job)
+	dls := newDeadLetters()
+	b.SetDeadLetterQueue(dls)
+	policy := mq.FixedRetry{Delay: 10 * time.Millisecond, MaxAttempts: 3}
+	if err := b.Subscribe(q, mq.WithRetryPolicy(policy)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mq.Dispatch(context.Background(), b, job, order{ID: "a", Fail: 2}); err != nil {
+		t.Fatal(err)
+	}
+	for attempts := 1; attempts <= 3; attempts++ {
+		e := receive(t, executions)
+		if e.Attempts != attempts || (e.Err == nil) != (attempts == 3) {
+			t.Fatalf("execution %d = %+v", attempts, e)
+		}
+	}
+	if n := dls.len(); n != 0 {
+		t.Errorf("dead letters = %d, want 0", n)
+	}
+}
+
+func TestUnregisteredJobDeadLetter(t *testing.T) {
+	job, _ := newOrderJob("registered_order")
+	b, q := newBroker(t, newMemoryDriver(t), job)
+	dls := newDeadLetters()
+	b.SetDeadLetterQueue(dls)
+	if err := b.Subscribe(q); err != nil {
+		t.Fatal(err)
+	}
+
+	msg := &mq.Message{Topic: testTopic, Body: []byte(`{}`), Headers: map[string]string{mq.HeaderJob: "unknown_order"}}
+	if err := b.Publish(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	dl := receive(t, dls.pushed)
+	if dl.Job != "unknown_order" || dl.Error == "" {
+		t.Errorf("dead letter = %+v", dl)
+	}
+}
+
+func TestIdempotentSkipsDuplicate(t *testing.T) {
+	job, executions := newOrderJob("idempotent_order")
+	job.WithKey(func(o order) string { return o.ID })
+	b, q := newBroker(t, newMemoryDriver(t), job)
+	b.SetIdempotency(mq.NewIdempotency(newProcessedStore(), time.Minute, time.Hour))
+	if err := b.Subscribe(q); err != nil {
+		t.Fatal(err)
+	}
+
+	// 单并发按发送顺序处理，处理到 b 时重复的 a 已被跳过
+	ctx := context.Background()
+	for _, id := range []string{"a", "a", "b"} {
+		if err := mq.Dispatch(ctx, b, job, order{ID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, id := range []string{"a", "b"} {
+		if e := receive(t, executions); e.ID != id || e.Err != nil {
+			t.Fatalf("execution = %+v, want %s", e, id)
+		}
+	}
+	select {
+	case e := <-executions:
+		t.Errorf("duplicate executed: %+v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestIdempotentLeaseContention(t *testing.T) {
+	job, executions := newOrderJob("lease_order")
+	job.WithKey(func(o order) string { return o.ID })
+	b, q := newBroker(t, newMemoryDriver(t), job)
+	store := newProcessedStore()
+	b.SetIdempotency(mq.NewIdempotency(store, time.Minute, time.Hour))
+	if err := b.Subscribe(q); err != nil {
+		t.Fatal(err)
+	}
+
+	// 其他消费者持有租约，到期前消息延后投递，到期后由延后投递的消息接管
+	ctx := context.Background()
+	key := testGroup + ":" + job.Name() + ":a"
+	lease := 200 * time.Millisecond
+	if _, ok, _ := store.Acquire(ctx, &mq.ProcessedRecord{Key: key, Owner: "other"}, lease); !ok {
+		t.Fatal("acquire failed")
+	}
+	leaseUntil := time.Now().Add(lease)
+	if err := mq.Dispatch(ctx, b, job, order{ID: "a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	e := receive(t, executions)
+	if time.Now().Before(leaseUntil) {
+		t.Error("executed before the lease expired")
+	}
+	if e.Attempts != 1 || e.Err != nil {
+		t.Errorf("execution = %+v, want first attempt", e)
+	}
+	rec, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.State != mq.StateSucceeded || rec.Attempts != 2 {
+		t.Errorf("record state = %s, attempts = %d", rec.State, rec.Attempts)
+	}
+}
+
+func TestOrderedSkip(t *testing.T) {
+	job, executions := newOrderJob("ordered_skip_order")
+	b, q := newBroker(t, partitioned{newMemoryDriver(t)}, job)
+	dls := newDeadLetters()
+	b.SetDeadLetterQueue(dls)
+	policy := mq.FixedRetry{Delay: 10 * time.Millisecond, MaxAttempts: 2}
+	if err := b.Subscribe(q, mq.WithOrdered(mq.OrderedSkip), mq.WithRetryPolicy(policy)); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for _, o := range []order{{ID: "a", Fail: -1}, {ID: "b"}} {
+		if err := mq.Dispatch(ctx, b, job, o, mq.WithShardingKey("user-1")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a 原地重试后写入死信队列，之后才处理 b
+	want := []execution{{ID: "a", Attempts: 1}, {ID: "a", Attempts: 2}, {ID: "b", Attempts: 1}}
+	for i, w := range want {
+		if e := receive(t, executions); e.ID != w.ID || e.Attempts != w.Attempts {
+			t.Fatalf("execution %d = %+v, want %+v", i, e, w)
+		}
+		if i == 1 {
+			if dl := receive(t, dls.pushed); dl.Attempts != 2 {
+				t.Errorf("dead letter attempts = %d, want 2", dl.Attempts)
+			}
+		}
+	}
+}
+
+func TestOrderedBlock(t *testing.T) {
+	job, executions := newOrderJob("ordered_block_order")
+	b, q := newBroker(t, partitioned{newMemoryDriver(t)}, job)
+	dls := newDeadLetters()
+	b.SetDeadLetterQueue(dls)
+	policy := mq.FixedRetry{Delay: 10 * time.Millisecond, MaxAttempts: 2}
+	if err := b.Subscribe(q, mq.WithOrdered(mq.OrderedBlock), mq.WithRetryPolicy(policy)); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for _, o := range []order{{ID: "a", Fail: 3}, {ID: "b"}} {
+		if err := mq.Dispatch(ctx, b, job, o, mq.WithShardingKey("user-1")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 超过重试策略后 a 继续原地重试，成功前阻塞 b
+	for attempts := 1; attempts <= 4; attempts++ {
+		e := receive(t, executions)
+		if e.ID != "a" || e.Attempts != attempts || (e.Err == nil) != (attempts == 4) {
+			t.Fatalf("execution %d = %+v", attempts, e)
+		}
+	}
+	if e := receive(t, executions); e.ID != "b" || e.Err != nil {
+		t.Fatalf("execution = %+v, want b", e)
+	}
+	if n := dls.len(); n != 0 {
+		t.Errorf("dead letters = %d, want 0", n)
+	}
+}
+
+func TestOrderedUnsupported(t *testing.T) {
+	job, _ := newOrderJob("ordered_unsupported_order")
+	b, q := newBroker(t, newMemoryDriver(t), job)
+	if err := b.Subscribe(q, mq.WithOrdered(mq.OrderedBlock)); err == nil {
+		t.Error("ordered subscription on an unpartitioned driver succeeded")
+	}
+}
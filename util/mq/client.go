@@ -0,0 +1,300 @@
+package mq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-framework/util/mq/queue"
+	"go-framework/util/xlog"
+	"sync"
+	"time"
+)
+
+// 消息中间件名称
+const (
+	BrokerRocketMQ = "rocketmq"
+	BrokerKafka    = "kafka"
+	BrokerRabbitMQ = "rabbitmq"
+	BrokerRedis    = "redis"
+	BrokerMemory   = "memory"
+
+	// DefaultBroker 未指定时使用的消息中间件
+	DefaultBroker = BrokerRocketMQ
+)
+
+var _ Broker = (*Client)(nil)
+
+// Client 消息队列客户端，按队列主题路由到配置的消息中间件
+type Client struct {
+	Logger        *xlog.Log
+	registry      *Registry
+	defaultBroker string
+	routes        map[string]string
+	brokers       map[string]Broker
+	brokerOrder   []string
+	consumers     map[string]ConsumerConfig
+	mu            sync.RWMutex
+}
+
+// NewClient 创建客户端，routes 为主题到中间件名称的映射，未配置的主题使用 defaultBroker
+func NewClient(logger *xlog.Log, defaultBroker string, routes map[string]string) *Client {
+	if defaultBroker == "" {
+		defaultBroker = DefaultBroker
+	}
+	return &Client{
+		Logger:        logger,
+		registry:      NewRegistry(),
+		defaultBroker: defaultBroker,
+		routes:        routes,
+		brokers:       make(map[string]Broker),
+	}
+}
+
+// AddQueue 添加队列，主题或任务名称重复时 panic
+func (c *Client) AddQueue(queues ...queue.Queue) {
+	if err := c.registry.Add(queues...); err != nil {
+		c.Logger.Panicf("%v", err)
+	}
+}
+
+// BrokerName 主题使用的中间件名称
+func (c *Client) BrokerName(topic string) string {
+	if name, ok := c.routes[topic]; ok && name != "" {
+		return name
+	}
+	return c.defaultBroker
+}
+
+// BrokerNames 已注册队列使用到的中间件名称，按队列注册顺序去重
+func (c *Client) BrokerNames() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, q := range c.registry.Queues() {
+		name := c.BrokerName(q.Topic())
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Queues 路由到指定中间件的队列
+func (c *Client) Queues(broker string) []queue.Queue {
+	var queues []queue.Queue
+	for _, q := range c.registry.Queues() {
+		if c.BrokerName(q.Topic()) == broker {
+			queues = append(queues, q)
+		}
+	}
+	return queues
+}
+
+// AddBroker 添加中间件
+func (c *Client) AddBroker(name string, broker Broker) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.brokers[name]; !ok {
+		c.brokerOrder = append(c.brokerOrder, name)
+	}
+	c.brokers[name] = broker
+}
+
+// Broker 按名称获取中间件
+func (c *Client) Broker(name string) (Broker, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	broker, ok := c.brokers[name]
+	return broker, ok
+}
+
+// SetNotifier 设置告警通知，转发给支持告警的中间件
+func (c *Client) SetNotifier(notifier Notifier) {
+	for _, broker := range c.all() {
+		if n, ok := broker.(interface{ SetNotifier(Notifier) }); ok {
+			n.SetNotifier(notifier)
+		}
+	}
+}
+
+// SetDeadLetterQueue 设置死信队列，转发给支持死信队列的中间件
+func (c *Client) SetDeadLetterQueue(deadLetters DeadLetterQueue) {
+	for _, broker := range c.all() {
+		if d, ok := broker.(interface{ SetDeadLetterQueue(DeadLetterQueue) }); ok {
+			d.SetDeadLetterQueue(deadLetters)
+		}
+	}
+}
+
+// SetIdempotency 设置消费幂等，转发给支持消费幂等的中间件
+func (c *Client) SetIdempotency(idempotency *Idempotency) {
+	for _, broker := range c.all() {
+		if i, ok := broker.(interface{ SetIdempotency(*Idempotency) }); ok {
+			i.SetIdempotency(idempotency)
+		}
+	}
+}
+
+// SetConsumerConfig 设置按主题配置的消费选项，订阅时在代码中的选项之后应用
+func (c *Client) SetConsumerConfig(consumers map[string]ConsumerConfig) {
+	c.consumers = consumers
+}
+
+// Consumer 主题的消费者，用于运行时暂停、恢复与调整并发
+func (c *Client) Consumer(topic string) (ConsumerControl, error) {
+	broker, err := c.topicBroker(topic)
+	if err != nil {
+		return nil, err
+	}
+	if p, ok := broker.(ConsumerProvider); ok {
+		if consumer, ok := p.Consumer(topic); ok {
+			return consumer, nil
+		}
+	}
+	return nil, ErrConsumerNotFound
+}
+
+// ConsumerStates 所有订阅中消费者的运行状态，按中间件添加顺序
+func (c *Client) ConsumerStates() []ConsumerState {
+	states := make([]ConsumerState, 0)
+	for _, broker := range c.all() {
+		if p, ok := broker.(ConsumerProvider); ok {
+			for _, consumer := range p.Consumers() {
+				states = append(states, consumer.State())
+			}
+		}
+	}
+	return states
+}
+
+// ConsumerRun 启动消费者
+func (c *Client) ConsumerRun(handler func(client *Client)) {
+	handler(c)
+}
+
+// Publish 发送原始消息到主题所属的中间件
+func (c *Client) Publish(ctx context.Context, msg *Message) error {
+	broker, err := c.topicBroker(msg.Topic)
+	if err != nil {
+		return err
+	}
+	return broker.Publish(ctx, msg)
+}
+
+// Replay 重新发送死信的原始消息，成功后从死信队列删除
+func (c *Client) Replay(ctx context.Context, deadLetters DeadLetterQueue, dl *DeadLetter) error {
+	if err := c.Publish(ctx, dl.Message()); err != nil {
+		return err
+	}
+	return deadLetters.Delete(ctx, dl.Topic, dl.ID)
+}
+
+// JobMessage 按任务所属队列的中间件编码任务消息
+func (c *Client) JobMessage(job queue.Job, msg interface{}) (*Message, error) {
+	broker, err := c.jobBroker(job)
+	if err != nil {
+		return nil, err
+	}
+	return broker.JobMessage(job, msg)
+}
+
+// SendJobMessage 发送任务消息到任务所属队列的中间件
+func (c *Client) SendJobMessage(ctx context.Context, job queue.Job, msg interface{}, opts ...SendOption) error {
+	broker, err := c.jobBroker(job)
+	if err != nil {
+		return err
+	}
+	return broker.SendJobMessage(ctx, job, msg, opts...)
+}
+
+// SendJobDelayMessage 发送延时任务消息到任务所属队列的中间件
+func (c *Client) SendJobDelayMessage(ctx context.Context, job queue.Job, msg interface{}, delay time.Duration, opts ...SendOption) error {
+	broker, err := c.jobBroker(job)
+	if err != nil {
+		return err
+	}
+	return broker.SendJobDelayMessage(ctx, job, msg, delay, opts...)
+}
+
+// Subscribe 通过队列所属的中间件订阅队列
+func (c *Client) Subscribe(q queue.Queue, opts ...ConsumeOption) error {
+	broker, err := c.topicBroker(q.Topic())
+	if err != nil {
+		return err
+	}
+	if conf, ok := c.consumers[q.Topic()]; ok {
+		opts = append(opts, conf.Options()...)
+	}
+	return broker.Subscribe(q, opts...)
+}
+
+// Shutdown 并发停止所有中间件的消费者
+func (c *Client) Shutdown(ctx context.Context) error {
+	brokers := c.all()
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(brokers))
+	for i, broker := range brokers {
+		wg.Add(1)
+		go func(i int, broker Broker) {
+			defer wg.Done()
+			errs[i] = broker.Shutdown(ctx)
+		}(i, broker)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// Ping 检查所有中间件是否可达
+func (c *Client) Ping(ctx context.Context) error {
+	var errs []error
+	for _, broker := range c.all() {
+		errs = append(errs, broker.Ping(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+// Close 释放所有中间件的连接
+func (c *Client) Close() error {
+	var errs []error
+	for _, broker := range c.all() {
+		errs = append(errs, broker.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Client) jobBroker(job queue.Job) (Broker, error) {
+	queueJob, ok := c.registry.Job(job.Name())
+	if !ok {
+		return nil, fmt.Errorf("mq: job %s is not registered", job.Name())
+	}
+	return c.topicBroker(queueJob.Queue.Topic())
+}
+
+func (c *Client) topicBroker(topic string) (Broker, error) {
+	name := c.BrokerName(topic)
+	broker, ok := c.Broker(name)
+	if !ok {
+		return nil, fmt.Errorf("mq: broker %s of topic %s not found", name, topic)
+	}
+	return broker, nil
+}
+
+func (c *Client) all() []Broker {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	brokers := make([]Broker, 0, len(c.brokerOrder))
+	for _, name := range c.brokerOrder {
+		brokers = append(brokers, c.brokers[name])
+	}
+	return brokers
+}
+
+// GroupName 非正式环境下消费组名称添加环境后缀，与 rocketmq 保持一致
+func GroupName(groupId, appEnv, mqEnv string) string {
+	if mqEnv == "" || appEnv == "prod" {
+		return groupId
+	}
+	return fmt.Sprintf("%s_%s", groupId, mqEnv)
+}
@@ -0,0 +1,93 @@
+package codec
+
+import (
+	"fmt"
+	"github.com/vmihailenco/msgpack/v5"
+	"go-framework/util/helper"
+	"google.golang.org/protobuf/proto"
+	"sync"
+)
+
+// 内容类型
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+	ContentTypeMsgpack  = "application/msgpack"
+)
+
+// Codec 消息体编解码器
+type Codec interface {
+	// ContentType 写入消息头的内容类型
+	ContentType() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	mu     sync.RWMutex
+	codecs = map[string]Codec{
+		ContentTypeJSON:     JSON{},
+		ContentTypeProtobuf: Protobuf{},
+		ContentTypeMsgpack:  Msgpack{},
+	}
+)
+
+// Register 注册编解码器，同一内容类型重复注册时覆盖
+func Register(c Codec) {
+	mu.Lock()
+	defer mu.Unlock()
+	codecs[c.ContentType()] = c
+}
+
+// Get 按内容类型获取编解码器，内容类型为空时使用 JSON
+func Get(contentType string) (Codec, error) {
+	if contentType == "" {
+		return JSON{}, nil
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	c, ok := codecs[contentType]
+	if !ok {
+		return nil, fmt.Errorf("mq codec: unsupported content type %s", contentType)
+	}
+	return c, nil
+}
+
+// JSON json 编解码器
+type JSON struct{}
+
+func (JSON) ContentType() string { return ContentTypeJSON }
+
+func (JSON) Marshal(v interface{}) ([]byte, error) { return helper.Marshal(v) }
+
+func (JSON) Unmarshal(data []byte, v interface{}) error { return helper.UmMarshal(data, v) }
+
+// Protobuf protobuf 编解码器，消息类型需实现 proto.Message
+type Protobuf struct{}
+
+func (Protobuf) ContentType() string { return ContentTypeProtobuf }
+
+func (Protobuf) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("mq codec: %T is not a proto.Message", v)
+	}
+	return proto.Marshal(m)
+}
+
+func (Protobuf) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("mq codec: %T is not a proto.Message", v)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+// Msgpack msgpack 编解码器
+type Msgpack struct{}
+
+func (Msgpack) ContentType() string { return ContentTypeMsgpack }
+
+func (Msgpack) Marshal(v interface{}) ([]byte, error) { return msgpack.Marshal(v) }
+
+func (Msgpack) Unmarshal(data []byte, v interface{}) error { return msgpack.Unmarshal(data, v) }
@@ -0,0 +1,161 @@
+package mq
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/segmentio/ksuid"
+	"go-framework/util/helper"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// HeaderHistory 消息头：历次执行失败记录（JSON）
+	HeaderHistory = "x-attempt-history"
+
+	// DeadLetterSuffix 死信主题后缀
+	DeadLetterSuffix = "_DLQ"
+
+	maxHistory     = 10
+	encodingBase64 = "base64"
+)
+
+// ErrDeadLetterNotFound 死信不存在
+var ErrDeadLetterNotFound = errors.New("mq: dead letter not found")
+
+// AttemptRecord 单次执行失败记录
+type AttemptRecord struct {
+	Attempt int       `json:"attempt"`
+	Error   string    `json:"error"`
+	Time    time.Time `json:"time"`
+}
+
+// DeadLetter 超过重试策略后仍执行失败的消息
+type DeadLetter struct {
+	ID        string            `json:"id"`
+	Topic     string            `json:"topic"` // 原主题
+	Group     string            `json:"group"`
+	Job       string            `json:"job"`
+	MessageID string            `json:"message_id"`
+	Key       string            `json:"key"`
+	Headers   map[string]string `json:"headers"`            // 原消息头，不含投递次数与执行记录
+	Body      string            `json:"body"`               // 原消息体
+	Encoding  string            `json:"encoding,omitempty"` // 消息体不是有效的 UTF-8 时为 base64
+	Error     string            `json:"error"`
+	Stack     string            `json:"stack"`
+	Attempts  int               `json:"attempts"`
+	History   []AttemptRecord   `json:"history"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// DeadLetterQueue 死信队列，按原主题对应的死信主题分别保存
+type DeadLetterQueue interface {
+	// Push 写入死信
+	Push(ctx context.Context, dl *DeadLetter) error
+	// List 按写入时间倒序分页查询主题的死信，返回总数，limit 为 0 时只返回总数
+	List(ctx context.Context, topic string, offset, limit int64) ([]*DeadLetter, int64, error)
+	// Get 查询死信，不存在时返回 ErrDeadLetterNotFound
+	Get(ctx context.Context, topic, id string) (*DeadLetter, error)
+	// Delete 删除死信
+	Delete(ctx context.Context, topic string, ids ...string) error
+	// Topics 存在死信的原主题
+	Topics(ctx context.Context) ([]string, error)
+}
+
+// DeadLetterTopic 原主题对应的死信主题
+func DeadLetterTopic(topic string) string {
+	return topic + DeadLetterSuffix
+}
+
+// PanicError 任务执行 panic
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("mq: job panic: %v", e.Value)
+}
+
+// NewDeadLetter 根据最后一次投递的消息与错误创建死信
+func NewDeadLetter(d *Delivery, group, job string, err error) *DeadLetter {
+	headers := make(map[string]string, len(d.Headers))
+	for k, v := range d.Headers {
+		if k != HeaderAttempts && k != HeaderHistory {
+			headers[k] = v
+		}
+	}
+	body, encoding := string(d.Body), ""
+	if !utf8.Valid(d.Body) {
+		body, encoding = base64.StdEncoding.EncodeToString(d.Body), encodingBase64
+	}
+	return &DeadLetter{
+		ID:        ksuid.New().String(),
+		Topic:     d.Topic,
+		Group:     group,
+		Job:       job,
+		MessageID: d.ID,
+		Key:       d.Key,
+		Headers:   headers,
+		Body:      body,
+		Encoding:  encoding,
+		Error:     err.Error(),
+		Stack:     errorStack(err),
+		Attempts:  d.Attempts,
+		History:   History(d.Headers),
+		CreatedAt: time.Now(),
+	}
+}
+
+// Message 用于重放的原始消息
+func (dl *DeadLetter) Message() *Message {
+	headers := make(map[string]string, len(dl.Headers))
+	for k, v := range dl.Headers {
+		headers[k] = v
+	}
+	body := []byte(dl.Body)
+	if dl.Encoding == encodingBase64 {
+		body, _ = base64.StdEncoding.DecodeString(dl.Body)
+	}
+	return &Message{Topic: dl.Topic, Key: dl.Key, Body: body, Headers: headers}
+}
+
+// History 读取消息头中的执行失败记录
+func History(headers map[string]string) []AttemptRecord {
+	var history []AttemptRecord
+	if data := headers[HeaderHistory]; data != "" {
+		_ = helper.UmMarshal([]byte(data), &history)
+	}
+	return history
+}
+
+// AppendHistory 复制消息头并追加一次执行失败记录，只保留最近的记录
+func AppendHistory(headers map[string]string, attempt int, err error) map[string]string {
+	history := append(History(headers), AttemptRecord{Attempt: attempt, Error: err.Error(), Time: time.Now()})
+	if len(history) > maxHistory {
+		history = history[len(history)-maxHistory:]
+	}
+
+	copied := make(map[string]string, len(headers)+1)
+	for k, v := range headers {
+		copied[k] = v
+	}
+	if data, e := helper.Marshal(history); e == nil {
+		copied[HeaderHistory] = string(data)
+	}
+	return copied
+}
+
+// errorStack panic 时返回调用栈，否则返回带堆栈的错误详情
+func errorStack(err error) string {
+	var p *PanicError
+	if errors.As(err, &p) {
+		return string(p.Stack)
+	}
+	if detail := fmt.Sprintf("%+v", err); detail != err.Error() {
+		return detail
+	}
+	return ""
+}
@@ -0,0 +1,139 @@
+package deadletter
+
+import (
+	"context"
+	"errors"
+	"github.com/go-redis/redis/v8"
+	"go-framework/util/helper"
+	"go-framework/util/mq"
+	"sort"
+)
+
+const defaultPrefix = "mq:dlq:"
+
+var _ mq.DeadLetterQueue = (*Redis)(nil)
+
+// Redis 基于 redis 的死信队列
+//
+// 每个死信主题对应一个按写入时间排序的有序集合 "前缀+死信主题" 与保存内容的哈希 "前缀+死信主题:data"，
+// 存在死信的原主题记录在集合 "前缀+topics" 中。
+type Redis struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedis 创建死信队列，prefix 为空时使用 mq:dlq:
+func NewRedis(client *redis.Client, prefix string) *Redis {
+	if prefix == "" {
+		prefix = defaultPrefix
+	}
+	return &Redis{client: client, prefix: prefix}
+}
+
+// Push 写入死信
+func (r *Redis) Push(ctx context.Context, dl *mq.DeadLetter) error {
+	data, err := helper.Marshal(dl)
+	if err != nil {
+		return err
+	}
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, r.dataKey(dl.Topic), dl.ID, data)
+		pipe.ZAdd(ctx, r.indexKey(dl.Topic), &redis.Z{Score: float64(dl.CreatedAt.UnixMilli()), Member: dl.ID})
+		pipe.SAdd(ctx, r.topicsKey(), dl.Topic)
+		return nil
+	})
+	return err
+}
+
+// List 按写入时间倒序分页查询主题的死信，返回总数，limit 为 0 时只返回总数
+func (r *Redis) List(ctx context.Context, topic string, offset, limit int64) ([]*mq.DeadLetter, int64, error) {
+	total, err := r.client.ZCard(ctx, r.indexKey(topic)).Result()
+	if err != nil || total == 0 || limit <= 0 {
+		return nil, total, err
+	}
+	ids, err := r.client.ZRevRange(ctx, r.indexKey(topic), offset, offset+limit-1).Result()
+	if err != nil || len(ids) == 0 {
+		return nil, total, err
+	}
+
+	values, err := r.client.HMGet(ctx, r.dataKey(topic), ids...).Result()
+	if err != nil {
+		return nil, total, err
+	}
+	deadLetters := make([]*mq.DeadLetter, 0, len(values))
+	for _, v := range values {
+		data, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var dl mq.DeadLetter
+		if err = helper.UmMarshal([]byte(data), &dl); err != nil {
+			return nil, total, err
+		}
+		deadLetters = append(deadLetters, &dl)
+	}
+	return deadLetters, total, nil
+}
+
+// Get 查询死信
+func (r *Redis) Get(ctx context.Context, topic, id string) (*mq.DeadLetter, error) {
+	data, err := r.client.HGet(ctx, r.dataKey(topic), id).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, mq.ErrDeadLetterNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var dl mq.DeadLetter
+	if err = helper.UmMarshal([]byte(data), &dl); err != nil {
+		return nil, err
+	}
+	return &dl, nil
+}
+
+// Delete 删除死信，主题的死信全部删除后移出主题集合
+func (r *Redis) Delete(ctx context.Context, topic string, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	members := make([]interface{}, len(ids))
+	for i, id := range ids {
+		members[i] = id
+	}
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HDel(ctx, r.dataKey(topic), ids...)
+		pipe.ZRem(ctx, r.indexKey(topic), members...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	total, err := r.client.ZCard(ctx, r.indexKey(topic)).Result()
+	if err != nil || total > 0 {
+		return err
+	}
+	return r.client.SRem(ctx, r.topicsKey(), topic).Err()
+}
+
+// Topics 存在死信的原主题
+func (r *Redis) Topics(ctx context.Context) ([]string, error) {
+	topics, err := r.client.SMembers(ctx, r.topicsKey()).Result()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(topics)
+	return topics, nil
+}
+
+func (r *Redis) indexKey(topic string) string {
+	return r.prefix + mq.DeadLetterTopic(topic)
+}
+
+func (r *Redis) dataKey(topic string) string {
+	return r.indexKey(topic) + ":data"
+}
+
+func (r *Redis) topicsKey() string {
+	return r.prefix + "topics"
+}
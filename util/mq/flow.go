@@ -0,0 +1,193 @@
+package mq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrConsumerNotFound 主题未订阅
+var ErrConsumerNotFound = errors.New("mq: consumer not found")
+
+// ConsumerState 消费者运行状态
+type ConsumerState struct {
+	Broker         string     `json:"broker"`
+	Topic          string     `json:"topic"`
+	Group          string     `json:"group"`
+	Paused         bool       `json:"paused"`
+	Concurrency    int        `json:"concurrency"`
+	MaxConcurrency int        `json:"max_concurrency,omitempty"` // 运行时可调整的并发上限，0 表示不限
+	InFlight       int        `json:"in_flight"`                 // 处理中的消息数
+	AckBuffer      int        `json:"ack_buffer"`                // 待确认的消息数
+	Processed      int64      `json:"processed"`
+	Failed         int64      `json:"failed"`
+	LastError      string     `json:"last_error,omitempty"`
+	LastErrorAt    *time.Time `json:"last_error_at,omitempty"`
+}
+
+// ConsumerControl 可在运行时暂停、恢复与调整并发的消费者
+type ConsumerControl interface {
+	// Pause 暂停拉取与执行新消息，处理中的消息继续完成
+	Pause()
+	// Resume 恢复消费
+	Resume()
+	// SetConcurrency 调整并发数
+	SetConcurrency(concurrency int) error
+	// State 运行状态
+	State() ConsumerState
+}
+
+// ConsumerProvider 可按主题查询订阅中消费者的中间件
+type ConsumerProvider interface {
+	// Consumer 主题的消费者，未订阅时返回 false
+	Consumer(topic string) (ConsumerControl, bool)
+	// Consumers 所有订阅中的消费者，按订阅顺序
+	Consumers() []ConsumerControl
+}
+
+// FlowControl 消费流控：可动态调整的并发上限与暂停，并记录处理结果
+type FlowControl struct {
+	mu        sync.Mutex
+	limit     int
+	max       int
+	inFlight  int
+	paused    bool
+	signal    chan struct{}
+	processed int64
+	failed    int64
+	lastErr   string
+	lastErrAt time.Time
+}
+
+// NewFlowControl 创建流控，max 为运行时可调整的并发上限，0 表示不限
+func NewFlowControl(concurrency, max int, paused bool) *FlowControl {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	if max > 0 && concurrency > max {
+		concurrency = max
+	}
+	return &FlowControl{limit: concurrency, max: max, paused: paused, signal: make(chan struct{})}
+}
+
+// Acquire 等待未暂停且有空闲并发时占用一个并发，ctx 结束时返回错误
+func (f *FlowControl) Acquire(ctx context.Context) error {
+	for {
+		f.mu.Lock()
+		if !f.paused && f.inFlight < f.limit {
+			f.inFlight++
+			f.mu.Unlock()
+			return nil
+		}
+		signal := f.signal
+		f.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-signal:
+		}
+	}
+}
+
+// Release 释放 Acquire 占用的并发
+func (f *FlowControl) Release() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.inFlight--
+	f.notify()
+}
+
+// Record 记录任务执行结果
+func (f *FlowControl) Record(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err != nil {
+		f.failed++
+		f.lastErr, f.lastErrAt = err.Error(), time.Now()
+		return
+	}
+	f.processed++
+}
+
+// Wait 等待未暂停且有空闲并发，返回空闲并发数，用于按空闲并发拉取消息
+func (f *FlowControl) Wait(ctx context.Context) (int, error) {
+	for {
+		f.mu.Lock()
+		if free := f.limit - f.inFlight; !f.paused && free > 0 {
+			f.mu.Unlock()
+			return free, nil
+		}
+		signal := f.signal
+		f.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-signal:
+		}
+	}
+}
+
+// Pause 暂停
+func (f *FlowControl) Pause() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.paused = true
+	f.notify()
+}
+
+// Resume 恢复
+func (f *FlowControl) Resume() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.paused = false
+	f.notify()
+}
+
+// SetConcurrency 调整并发数，降低时处理中的消息继续完成
+func (f *FlowControl) SetConcurrency(concurrency int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if concurrency <= 0 {
+		return fmt.Errorf("mq: invalid concurrency %d", concurrency)
+	}
+	if f.max > 0 && concurrency > f.max {
+		return fmt.Errorf("mq: concurrency %d exceeds max concurrency %d", concurrency, f.max)
+	}
+	f.limit = concurrency
+	f.notify()
+	return nil
+}
+
+// RecordError 记录执行结果以外的错误，如拉取失败
+func (f *FlowControl) RecordError(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lastErr, f.lastErrAt = err.Error(), time.Now()
+}
+
+// State 填充运行状态中的流控字段
+func (f *FlowControl) State(s ConsumerState) ConsumerState {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s.Paused = f.paused
+	s.Concurrency = f.limit
+	s.MaxConcurrency = f.max
+	s.InFlight = f.inFlight
+	s.Processed = f.processed
+	s.Failed = f.failed
+	if f.lastErr != "" {
+		lastErrAt := f.lastErrAt
+		s.LastError, s.LastErrorAt = f.lastErr, &lastErrAt
+	}
+	return s
+}
+
+// notify 唤醒等待中的 Acquire 与 Wait，需持有锁
+func (f *FlowControl) notify() {
+	close(f.signal)
+	f.signal = make(chan struct{})
+}
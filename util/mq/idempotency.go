@@ -0,0 +1,137 @@
+package mq
+
+import (
+	"context"
+	"errors"
+	"github.com/segmentio/ksuid"
+	"go-framework/util/metrics"
+	"go-framework/util/mq/queue"
+	"time"
+)
+
+// 消息处理状态
+const (
+	StateProcessing = "processing"
+	StateSucceeded  = "succeeded"
+	StateFailed     = "failed"
+)
+
+const (
+	defaultIdempotencyLease     = 600 * time.Second
+	defaultIdempotencyRetention = 72 * time.Hour
+)
+
+var (
+	// ErrProcessedNotFound 处理记录不存在
+	ErrProcessedNotFound = errors.New("mq: processed record not found")
+	// ErrLeaseLost 处理记录的租约已过期并被其他消费者接管
+	ErrLeaseLost = errors.New("mq: processed record lease lost")
+	// ErrProcessing 消息正由其他消费者处理，顺序消费时由驱动原地重试
+	ErrProcessing = errors.New("mq: message is being processed by another consumer")
+)
+
+// ProcessedRecord 消息处理记录，同一消费组内按幂等键唯一
+type ProcessedRecord struct {
+	Key        string    `json:"key"`
+	Topic      string    `json:"topic"`
+	Group      string    `json:"group"`
+	Job        string    `json:"job"`
+	MessageID  string    `json:"message_id"` // 最近一次处理的消息 ID
+	State      string    `json:"state"`
+	Attempts   int       `json:"attempts"` // 处理次数
+	Error      string    `json:"error"`
+	Owner      string    `json:"owner"`       // 当前处理者，租约过期后由其他消费者接管
+	LeaseUntil time.Time `json:"lease_until"` // 处理中状态的租约到期时间
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// IdempotencyStore 消息处理记录存储
+type IdempotencyStore interface {
+	// Acquire 开始处理。记录不存在、处理失败或处理中但租约已过期时以 r.Owner 获取记录并返回 true，
+	// 否则返回 false 与已有记录
+	Acquire(ctx context.Context, r *ProcessedRecord, lease time.Duration) (*ProcessedRecord, bool, error)
+	// Finish 由 owner 更新处理结果，记录保留 retention 后删除，owner 不一致时返回 ErrLeaseLost
+	Finish(ctx context.Context, key, owner, state, errMsg string, retention time.Duration) error
+	// Get 查询处理记录，不存在时返回 ErrProcessedNotFound
+	Get(ctx context.Context, key string) (*ProcessedRecord, error)
+	// Delete 删除处理记录，允许消息重新处理
+	Delete(ctx context.Context, key string) error
+}
+
+// IdempotentJob 提供业务幂等键的任务，相同键的消息只成功处理一次；键为空时按消息 ID 去重
+type IdempotentJob interface {
+	queue.Job
+	IdempotencyKey(p *Payload) (string, error)
+}
+
+// Idempotency 消费幂等配置
+type Idempotency struct {
+	Store     IdempotencyStore
+	Lease     time.Duration // 处理中状态的租约，超过后视为处理卡住并允许重新处理，默认 600 秒
+	Retention time.Duration // 处理成功记录的保留时间，默认 72 小时
+}
+
+// NewIdempotency 创建消费幂等配置，lease、retention 为 0 时使用默认值
+func NewIdempotency(store IdempotencyStore, lease, retention time.Duration) *Idempotency {
+	if lease <= 0 {
+		lease = defaultIdempotencyLease
+	}
+	if retention <= 0 {
+		retention = defaultIdempotencyRetention
+	}
+	return &Idempotency{Store: store, Lease: lease, Retention: retention}
+}
+
+// IdempotencyKey 消息的幂等键：消费组 + 任务提供的业务键，未提供时为消费组 + 主题 + 原消息 ID
+func IdempotencyKey(group string, job queue.Job, p *Payload, d *Delivery) (string, error) {
+	if j, ok := job.(IdempotentJob); ok {
+		key, err := j.IdempotencyKey(p)
+		if err != nil {
+			return "", err
+		}
+		if key != "" {
+			return group + ":" + job.Name() + ":" + key, nil
+		}
+	}
+	return group + ":" + d.Topic + ":" + d.OriginID(), nil
+}
+
+// Begin 开始处理消息，返回 false 与已有记录时消息已处理成功或正由其他消费者处理：
+// 已处理成功的消息确认并跳过，处理中的消息按剩余租约延后投递，租约到期后由延后投递的消息接管。
+// 无法计算幂等键或存储异常时返回 true 与错误，按至少一次处理
+func (i *Idempotency) Begin(ctx context.Context, group string, job queue.Job, p *Payload, d *Delivery) (*ProcessedRecord, bool, error) {
+	key, err := IdempotencyKey(group, job, p, d)
+	if err != nil {
+		return nil, true, err
+	}
+	r := &ProcessedRecord{
+		Key:       key,
+		Topic:     d.Topic,
+		Group:     group,
+		Job:       job.Name(),
+		MessageID: d.OriginID(),
+		State:     StateProcessing,
+		Owner:     ksuid.New().String(),
+	}
+	existing, ok, err := i.Store.Acquire(ctx, r, i.Lease)
+	if err != nil {
+		return nil, true, err
+	}
+	if !ok {
+		metrics.MQConsumeTotal.WithLabelValues(d.Topic, metrics.ResultDuplicate).Inc()
+		return existing, false, nil
+	}
+	return r, true, nil
+}
+
+// End 记录处理结果，r 为 Begin 获取的记录，为 nil 时忽略
+func (i *Idempotency) End(ctx context.Context, r *ProcessedRecord, err error) error {
+	if r == nil {
+		return nil
+	}
+	if err != nil {
+		return i.Store.Finish(ctx, r.Key, r.Owner, StateFailed, err.Error(), i.Retention)
+	}
+	return i.Store.Finish(ctx, r.Key, r.Owner, StateSucceeded, "", i.Retention)
+}
@@ -0,0 +1,141 @@
+package idempotent
+
+import (
+	"context"
+	"errors"
+	"github.com/go-redis/redis/v8"
+	"go-framework/util/mq"
+	"strconv"
+	"time"
+)
+
+const defaultPrefix = "mq:idem:"
+
+var _ mq.IdempotencyStore = (*Redis)(nil)
+
+// acquireScript 记录不存在、处理失败或租约过期时获取记录，时间取自 redis，不受实例间时钟偏差影响
+//
+//	KEYS[1] 记录键
+//	ARGV    租约时长、过期时间（毫秒）、owner、幂等键、主题、消费组、任务、消息 ID
+var acquireScript = redis.NewScript(`
+redis.replicate_commands()
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local state = redis.call('HGET', KEYS[1], 'state')
+if state == 'succeeded' then
+	return 0
+end
+if state == 'processing' and tonumber(redis.call('HGET', KEYS[1], 'lease_until')) > now then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'state', 'processing', 'lease_until', now + tonumber(ARGV[1]), 'owner', ARGV[3], 'key', ARGV[4],
+	'topic', ARGV[5], 'group', ARGV[6], 'job', ARGV[7], 'message_id', ARGV[8], 'error', '', 'updated_at', now)
+redis.call('HSETNX', KEYS[1], 'created_at', now)
+redis.call('HINCRBY', KEYS[1], 'attempts', 1)
+redis.call('PEXPIRE', KEYS[1], ARGV[2])
+return 1
+`)
+
+// finishScript owner 一致时更新处理结果
+//
+//	KEYS[1] 记录键
+//	ARGV    owner、状态、错误、保留时间（毫秒）
+var finishScript = redis.NewScript(`
+redis.replicate_commands()
+if redis.call('HGET', KEYS[1], 'owner') ~= ARGV[1] then
+	return 0
+end
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+redis.call('HSET', KEYS[1], 'state', ARGV[2], 'error', ARGV[3], 'lease_until', 0, 'updated_at', now)
+redis.call('PEXPIRE', KEYS[1], ARGV[4])
+return 1
+`)
+
+// Redis 基于 redis 的消息处理记录，每条记录为一个带过期时间的哈希 "前缀+幂等键"
+type Redis struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedis 创建消息处理记录存储，prefix 为空时使用 mq:idem:
+func NewRedis(client *redis.Client, prefix string) *Redis {
+	if prefix == "" {
+		prefix = defaultPrefix
+	}
+	return &Redis{client: client, prefix: prefix}
+}
+
+// Acquire 开始处理，处理中的记录在两倍租约后过期
+func (r *Redis) Acquire(ctx context.Context, rec *mq.ProcessedRecord, lease time.Duration) (*mq.ProcessedRecord, bool, error) {
+	ok, err := acquireScript.Run(ctx, r.client, []string{r.prefix + rec.Key},
+		lease.Milliseconds(), (2 * lease).Milliseconds(), rec.Owner, rec.Key,
+		rec.Topic, rec.Group, rec.Job, rec.MessageID).Int()
+	if err != nil {
+		return nil, false, err
+	}
+	if ok == 1 {
+		return nil, true, nil
+	}
+
+	existing, err := r.Get(ctx, rec.Key)
+	if errors.Is(err, mq.ErrProcessedNotFound) {
+		// 记录恰好过期，由下次投递重新获取
+		return &mq.ProcessedRecord{Key: rec.Key, State: mq.StateProcessing}, false, nil
+	}
+	return existing, false, err
+}
+
+// Finish 更新处理结果
+func (r *Redis) Finish(ctx context.Context, key, owner, state, errMsg string, retention time.Duration) error {
+	ok, err := finishScript.Run(ctx, r.client, []string{r.prefix + key},
+		owner, state, errMsg, retention.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if ok == 0 {
+		return mq.ErrLeaseLost
+	}
+	return nil
+}
+
+// Get 查询处理记录
+func (r *Redis) Get(ctx context.Context, key string) (*mq.ProcessedRecord, error) {
+	values, err := r.client.HGetAll(ctx, r.prefix+key).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, mq.ErrProcessedNotFound
+	}
+
+	attempts, _ := strconv.Atoi(values["attempts"])
+	return &mq.ProcessedRecord{
+		Key:        values["key"],
+		Topic:      values["topic"],
+		Group:      values["group"],
+		Job:        values["job"],
+		MessageID:  values["message_id"],
+		State:      values["state"],
+		Attempts:   attempts,
+		Error:      values["error"],
+		Owner:      values["owner"],
+		LeaseUntil: unixMilli(values["lease_until"]),
+		CreatedAt:  unixMilli(values["created_at"]),
+		UpdatedAt:  unixMilli(values["updated_at"]),
+	}, nil
+}
+
+// Delete 删除处理记录
+func (r *Redis) Delete(ctx context.Context, key string) error {
+	return r.client.Del(ctx, r.prefix+key).Err()
+}
+
+// unixMilli 解析毫秒时间戳，为空或为 0 时返回零值
+func unixMilli(v string) time.Time {
+	ms, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
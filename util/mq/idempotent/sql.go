@@ -0,0 +1,175 @@
+package idempotent
+
+import (
+	"context"
+	"errors"
+	"go-framework/util/mq"
+	"go-framework/util/xsql/resolver"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+const defaultTable = "mq_processed"
+
+var _ mq.IdempotencyStore = (*SQL)(nil)
+
+// processed 消息处理记录表
+type processed struct {
+	Key        string    `gorm:"column:idempotency_key;primaryKey;size:255"`
+	Topic      string    `gorm:"size:255"`
+	Group      string    `gorm:"column:group_name;size:255"`
+	Job        string    `gorm:"size:255"`
+	MessageID  string    `gorm:"size:255"`
+	State      string    `gorm:"size:16;not null"`
+	Attempts   int       `gorm:"not null;default:0"`
+	Error      string    `gorm:"type:text"`
+	Owner      string    `gorm:"size:64"`
+	LeaseUntil time.Time `gorm:"not null"`
+	ExpiresAt  time.Time `gorm:"not null;index:idx_processed_expires_at"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (p *processed) record() *mq.ProcessedRecord {
+	r := &mq.ProcessedRecord{
+		Key:       p.Key,
+		Topic:     p.Topic,
+		Group:     p.Group,
+		Job:       p.Job,
+		MessageID: p.MessageID,
+		State:     p.State,
+		Attempts:  p.Attempts,
+		Error:     p.Error,
+		Owner:     p.Owner,
+		CreatedAt: p.CreatedAt,
+		UpdatedAt: p.UpdatedAt,
+	}
+	if p.State == mq.StateProcessing {
+		r.LeaseUntil = p.LeaseUntil
+	}
+	return r
+}
+
+// SQL 基于数据库表的消息处理记录，过期的记录由 Purge 删除
+type SQL struct {
+	db    *gorm.DB
+	table string
+}
+
+// NewSQL 创建消息处理记录存储，table 为空时使用 mq_processed
+func NewSQL(db *gorm.DB, table string) *SQL {
+	if table == "" {
+		table = defaultTable
+	}
+	return &SQL{db: db, table: table}
+}
+
+// Migrate 创建或更新处理记录表
+func (s *SQL) Migrate() error {
+	return s.db.Table(s.table).AutoMigrate(&processed{})
+}
+
+// Acquire 开始处理，处理中的记录在两倍租约后过期
+func (s *SQL) Acquire(ctx context.Context, rec *mq.ProcessedRecord, lease time.Duration) (*mq.ProcessedRecord, bool, error) {
+	now := time.Now()
+	row := &processed{
+		Key:        rec.Key,
+		Topic:      rec.Topic,
+		Group:      rec.Group,
+		Job:        rec.Job,
+		MessageID:  rec.MessageID,
+		State:      mq.StateProcessing,
+		Attempts:   1,
+		Owner:      rec.Owner,
+		LeaseUntil: now.Add(lease),
+		ExpiresAt:  now.Add(2 * lease),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	res := s.db.WithContext(ctx).Table(s.table).Clauses(clause.OnConflict{DoNothing: true}).Create(row)
+	if res.Error != nil {
+		return nil, false, res.Error
+	}
+	if res.RowsAffected == 1 {
+		return nil, true, nil
+	}
+
+	// 已有记录时，仅在处理失败、租约过期或记录已过期时接管
+	res = s.db.WithContext(ctx).Table(s.table).
+		Where("idempotency_key = ?", rec.Key).
+		Where("state = ? OR (state = ? AND lease_until < ?) OR expires_at < ?", mq.StateFailed, mq.StateProcessing, now, now).
+		Updates(map[string]interface{}{
+			"topic":       rec.Topic,
+			"group_name":  rec.Group,
+			"job":         rec.Job,
+			"message_id":  rec.MessageID,
+			"state":       mq.StateProcessing,
+			"attempts":    gorm.Expr("attempts + 1"),
+			"error":       "",
+			"owner":       rec.Owner,
+			"lease_until": now.Add(lease),
+			"expires_at":  now.Add(2 * lease),
+			"updated_at":  now,
+		})
+	if res.Error != nil {
+		return nil, false, res.Error
+	}
+	if res.RowsAffected == 1 {
+		return nil, true, nil
+	}
+
+	existing, err := s.Get(ctx, rec.Key)
+	if errors.Is(err, mq.ErrProcessedNotFound) {
+		// 记录恰好被删除，由下次投递重新获取
+		return &mq.ProcessedRecord{Key: rec.Key, State: mq.StateProcessing}, false, nil
+	}
+	return existing, false, err
+}
+
+// Finish 更新处理结果
+func (s *SQL) Finish(ctx context.Context, key, owner, state, errMsg string, retention time.Duration) error {
+	now := time.Now()
+	res := s.db.WithContext(ctx).Table(s.table).
+		Where("idempotency_key = ? AND owner = ?", key, owner).
+		Updates(map[string]interface{}{
+			"state":       state,
+			"error":       errMsg,
+			"lease_until": now,
+			"expires_at":  now.Add(retention),
+			"updated_at":  now,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return mq.ErrLeaseLost
+	}
+	return nil
+}
+
+// Get 查询处理记录，已过期的记录视为不存在；记录刚被写入或更新，从主库查询
+func (s *SQL) Get(ctx context.Context, key string) (*mq.ProcessedRecord, error) {
+	var row processed
+	err := s.db.WithContext(resolver.ForceMaster(ctx)).Table(s.table).
+		Where("idempotency_key = ? AND expires_at >= ?", key, time.Now()).
+		Take(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, mq.ErrProcessedNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return row.record(), nil
+}
+
+// Delete 删除处理记录
+func (s *SQL) Delete(ctx context.Context, key string) error {
+	return s.db.WithContext(ctx).Table(s.table).Where("idempotency_key = ?", key).Delete(&processed{}).Error
+}
+
+// Purge 删除已过期的记录，返回删除的条数，可由定时任务调用
+func (s *SQL) Purge(ctx context.Context) (int64, error) {
+	res := s.db.WithContext(ctx).Table(s.table).Where("expires_at < ?", time.Now()).Delete(&processed{})
+	return res.RowsAffected, res.Error
+}
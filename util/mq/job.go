@@ -0,0 +1,279 @@
+package mq
+
+import (
+	"context"
+	"fmt"
+	"go-framework/util/mq/codec"
+	"go-framework/util/mq/queue"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+const (
+	// HeaderContentType 消息头：消息体的内容类型
+	HeaderContentType = "content-type"
+	// HeaderVersion 消息头：消息体的结构版本
+	HeaderVersion = "x-version"
+
+	defaultJobVersion = 1
+)
+
+// Payload 任务消息体及其编码信息
+type Payload struct {
+	ContentType string
+	Version     int // 结构版本，为 0 时表示未指定
+	Body        []byte
+}
+
+// Headers 写入消息头的编码信息
+func (p *Payload) Headers() map[string]string {
+	headers := map[string]string{HeaderContentType: p.ContentType}
+	if p.Version > 0 {
+		headers[HeaderVersion] = strconv.Itoa(p.Version)
+	}
+	return headers
+}
+
+// NewPayload 根据消息头与消息体创建 Payload
+func NewPayload(headers map[string]string, body []byte) *Payload {
+	version, _ := strconv.Atoi(headers[HeaderVersion])
+	return &Payload{ContentType: headers[HeaderContentType], Version: version, Body: body}
+}
+
+// PayloadJob 自行编解码消息体的任务，未实现该接口的任务使用 JSON 编码并以 Execute 执行
+type PayloadJob interface {
+	queue.Job
+	// Encode 编码发送的消息
+	Encode(msg interface{}) (*Payload, error)
+	// ExecutePayload 解码消息体并执行
+	ExecutePayload(ctx context.Context, p *Payload) error
+}
+
+// EncodeJob 编码任务消息
+func EncodeJob(job queue.Job, msg interface{}) (*Payload, error) {
+	if j, ok := job.(PayloadJob); ok {
+		return j.Encode(msg)
+	}
+	body, err := codec.JSON{}.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	return &Payload{ContentType: codec.ContentTypeJSON, Body: body}, nil
+}
+
+// ExecuteJob 执行任务
+func ExecuteJob(ctx context.Context, job queue.Job, p *Payload) error {
+	if j, ok := job.(PayloadJob); ok {
+		return j.ExecutePayload(ctx, p)
+	}
+	return job.Execute(ctx, p.Body)
+}
+
+// Upgrade 将消息体从当前版本升级到下一版本，body 使用消息的内容类型编码
+type Upgrade func(c codec.Codec, body []byte) ([]byte, error)
+
+// JobOptions 类型化任务选项
+type JobOptions struct {
+	Version  int             // 结构版本，默认 1
+	Codec    codec.Codec     // 发送时使用的编解码器，默认 JSON
+	Upgrades map[int]Upgrade // 版本升级函数，键为升级前的版本
+}
+
+// JobOption 类型化任务选项函数
+type JobOption func(o *JobOptions)
+
+// WithVersion 设置结构版本，修改消息结构时递增并通过 WithUpgrade 兼容旧版本消息
+func WithVersion(version int) JobOption {
+	return func(o *JobOptions) {
+		o.Version = version
+	}
+}
+
+// WithCodec 设置发送时使用的编解码器，消费时按消息头的内容类型解码
+func WithCodec(c codec.Codec) JobOption {
+	return func(o *JobOptions) {
+		o.Codec = c
+	}
+}
+
+// WithUpgrade 设置从 from 版本升级到 from+1 版本的函数，多个版本依次升级
+func WithUpgrade(from int, fn Upgrade) JobOption {
+	return func(o *JobOptions) {
+		o.Upgrades[from] = fn
+	}
+}
+
+var (
+	_ PayloadJob    = (*Job[struct{}])(nil)
+	_ IdempotentJob = (*Job[struct{}])(nil)
+	_ ShardedJob    = (*Job[struct{}])(nil)
+)
+
+// Job 类型化任务，名称在注册后不应修改，消息按名称路由到任务
+type Job[T any] struct {
+	name    string
+	opts    JobOptions
+	handler func(ctx context.Context, payload T) error
+	key     func(payload T) string
+	shard   func(payload T) string
+}
+
+// NewJob 创建类型化任务，name 为稳定的任务名称，与 Go 类型名无关
+func NewJob[T any](name string, handler func(ctx context.Context, payload T) error, opts ...JobOption) *Job[T] {
+	o := JobOptions{
+		Version:  defaultJobVersion,
+		Codec:    codec.JSON{},
+		Upgrades: make(map[int]Upgrade),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Job[T]{name: name, opts: o, handler: handler}
+}
+
+// Name 任务名称
+func (j *Job[T]) Name() string {
+	return j.name
+}
+
+// Version 结构版本
+func (j *Job[T]) Version() int {
+	return j.opts.Version
+}
+
+// WithKey 设置业务幂等键，相同键的消息只成功处理一次
+func (j *Job[T]) WithKey(fn func(payload T) string) *Job[T] {
+	j.key = fn
+	return j
+}
+
+// IdempotencyKey 解码消息并返回业务幂等键，未设置时返回空
+func (j *Job[T]) IdempotencyKey(p *Payload) (string, error) {
+	if j.key == nil {
+		return "", nil
+	}
+	payload, err := j.payload(p)
+	if err != nil {
+		return "", err
+	}
+	return j.key(payload), nil
+}
+
+// WithShardingKey 设置分区键，如订单 ID，相同键的消息在顺序消费时按发送顺序处理
+func (j *Job[T]) WithShardingKey(fn func(payload T) string) *Job[T] {
+	j.shard = fn
+	return j
+}
+
+// ShardingKey 发送消息的分区键，未设置或 msg 类型不匹配时返回空
+func (j *Job[T]) ShardingKey(msg interface{}) string {
+	if j.shard == nil {
+		return ""
+	}
+	payload, err := j.value(msg)
+	if err != nil {
+		return ""
+	}
+	return j.shard(payload)
+}
+
+// Execute 以默认编解码器与当前版本解码并执行
+func (j *Job[T]) Execute(ctx context.Context, data []byte) error {
+	return j.ExecutePayload(ctx, &Payload{ContentType: j.opts.Codec.ContentType(), Version: j.opts.Version, Body: data})
+}
+
+// Encode 编码消息，msg 的类型需为 T 或 *T
+func (j *Job[T]) Encode(msg interface{}) (*Payload, error) {
+	payload, err := j.value(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := j.opts.Codec.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &Payload{ContentType: j.opts.Codec.ContentType(), Version: j.opts.Version, Body: body}, nil
+}
+
+// value 将发送的消息转换为 T
+func (j *Job[T]) value(msg interface{}) (T, error) {
+	var payload T
+	switch v := msg.(type) {
+	case T:
+		return v, nil
+	case *T:
+		return *v, nil
+	default:
+		return payload, fmt.Errorf("mq: job %s expects %T, got %T", j.name, payload, msg)
+	}
+}
+
+// ExecutePayload 将旧版本消息逐级升级到当前版本后解码并执行
+func (j *Job[T]) ExecutePayload(ctx context.Context, p *Payload) error {
+	payload, err := j.payload(p)
+	if err != nil {
+		return err
+	}
+	return j.handler(ctx, payload)
+}
+
+// payload 将消息逐级升级到当前版本后解码
+func (j *Job[T]) payload(p *Payload) (T, error) {
+	var zero T
+	c, err := codec.Get(p.ContentType)
+	if err != nil {
+		return zero, err
+	}
+
+	version := p.Version
+	if version == 0 {
+		version = defaultJobVersion
+	}
+	if version > j.opts.Version {
+		return zero, fmt.Errorf("mq: job %s message version %d is newer than %d", j.name, version, j.opts.Version)
+	}
+	body := p.Body
+	for ; version < j.opts.Version; version++ {
+		upgrade, ok := j.opts.Upgrades[version]
+		if !ok {
+			continue
+		}
+		if body, err = upgrade(c, body); err != nil {
+			return zero, fmt.Errorf("mq: job %s upgrade from version %d: %w", j.name, version, err)
+		}
+	}
+
+	payload, err := j.decode(c, body)
+	if err != nil {
+		return zero, fmt.Errorf("mq: job %s decode: %w", j.name, err)
+	}
+	return payload, nil
+}
+
+// decode 解码消息体，T 为指针类型（如 protobuf 消息）时解码到新分配的值
+func (j *Job[T]) decode(c codec.Codec, body []byte) (T, error) {
+	var payload T
+	if t := reflect.TypeOf(payload); t != nil && t.Kind() == reflect.Ptr {
+		payload = reflect.New(t.Elem()).Interface().(T)
+		return payload, c.Unmarshal(body, payload)
+	}
+	return payload, c.Unmarshal(body, &payload)
+}
+
+// TypedJob 消息类型为 T 的类型化任务，如 Job[T]、Batch[T]
+type TypedJob[T any] interface {
+	queue.Job
+	payload(p *Payload) (T, error)
+}
+
+// Dispatch 发送类型化任务消息
+func Dispatch[T any](ctx context.Context, p Producer, job TypedJob[T], payload T, opts ...SendOption) error {
+	return p.SendJobMessage(ctx, job, payload, opts...)
+}
+
+// DispatchDelay 发送类型化延时任务消息
+func DispatchDelay[T any](ctx context.Context, p Producer, job TypedJob[T], payload T, delay time.Duration, opts ...SendOption) error {
+	return p.SendJobDelayMessage(ctx, job, payload, delay, opts...)
+}
@@ -0,0 +1,75 @@
+package mq_test
+
+import (
+	"context"
+	"go-framework/util/mq"
+	"go-framework/util/mq/codec"
+	"testing"
+)
+
+type userV1 struct {
+	Name string `json:"name" msgpack:"name"`
+}
+
+type userV2 struct {
+	FullName string `json:"full_name" msgpack:"full_name"`
+}
+
+func TestJobCodec(t *testing.T) {
+	users := make(chan userV2, 1)
+	job := mq.NewJob("msgpack_user", func(ctx context.Context, u userV2) error {
+		md, _ := mq.MetadataFromContext(ctx)
+		if ct := md.Headers[mq.HeaderContentType]; ct != codec.ContentTypeMsgpack {
+			t.Errorf("content type = %s, want %s", ct, codec.ContentTypeMsgpack)
+		}
+		users <- u
+		return nil
+	}, mq.WithCodec(codec.Msgpack{}))
+	b, q := newBroker(t, newMemoryDriver(t), job)
+	if err := b.Subscribe(q); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mq.Dispatch(context.Background(), b, job, userV2{FullName: "Ada Lovelace"}); err != nil {
+		t.Fatal(err)
+	}
+	if u := receive(t, users); u.FullName != "Ada Lovelace" {
+		t.Errorf("user = %+v", u)
+	}
+}
+
+func TestJobVersionUpgrade(t *testing.T) {
+	users := make(chan userV2, 1)
+	job := mq.NewJob("versioned_user", func(ctx context.Context, u userV2) error {
+		users <- u
+		return nil
+	}, mq.WithVersion(2), mq.WithUpgrade(1, func(c codec.Codec, body []byte) ([]byte, error) {
+		var v1 userV1
+		if err := c.Unmarshal(body, &v1); err != nil {
+			return nil, err
+		}
+		return c.Marshal(userV2{FullName: v1.Name})
+	}))
+	b, q := newBroker(t, newMemoryDriver(t), job)
+	if err := b.Subscribe(q); err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟升级前的生产者以版本 1 发送的消息
+	old := mq.NewJob("versioned_user", func(ctx context.Context, u userV1) error { return nil })
+	payload, err := old.Encode(userV1{Name: "Ada Lovelace"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload.Version != 1 {
+		t.Fatalf("payload version = %d, want 1", payload.Version)
+	}
+	headers := payload.Headers()
+	headers[mq.HeaderJob] = job.Name()
+	if err = b.Publish(context.Background(), &mq.Message{Topic: testTopic, Body: payload.Body, Headers: headers}); err != nil {
+		t.Fatal(err)
+	}
+	if u := receive(t, users); u.FullName != "Ada Lovelace" {
+		t.Errorf("user = %+v", u)
+	}
+}
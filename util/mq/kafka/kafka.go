@@ -0,0 +1,230 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"go-framework/util/helper"
+	"go-framework/util/mq"
+	"strconv"
+	"time"
+)
+
+const (
+	// headerDeliverAt 延时消息的投递时间（毫秒时间戳），kafka 不支持延时消息，由消费者等待到期后处理
+	headerDeliverAt = "x-deliver-at"
+	// retrySuffix 延时消息与重试消息写入 "主题.retry"，等待到期时不阻塞原主题的分区
+	retrySuffix = ".retry"
+
+	maxRetryBackoff = 30 * time.Second
+)
+
+var _ mq.PartitionedDriver = (*Driver)(nil)
+
+// Config kafka 配置
+type Config struct {
+	Brokers  []string `json:"brokers"`  // 地址
+	Username string   `json:"username"` // SASL/PLAIN 用户名，为空时不认证
+	Password string   `json:"password"` // SASL/PLAIN 密码
+}
+
+// Driver kafka 驱动，同一消费组内的每个并发对应一个 Reader，由 kafka 分配分区
+type Driver struct {
+	conf   Config
+	dialer *kafka.Dialer
+	writer *kafka.Writer
+}
+
+// New 创建 kafka 驱动
+func New(c interface{}) (*Driver, error) {
+	var conf Config
+	if err := helper.UnMarshalWithInterface(c, &conf); err != nil {
+		return nil, fmt.Errorf("kafka config error: %w", err)
+	}
+	if len(conf.Brokers) == 0 {
+		return nil, errors.New("kafka config error: brokers is empty")
+	}
+
+	dialer := &kafka.Dialer{Timeout: 10 * time.Second, DualStack: true}
+	transport := &kafka.Transport{}
+	if conf.Username != "" {
+		mechanism := plain.Mechanism{Username: conf.Username, Password: conf.Password}
+		dialer.SASLMechanism = mechanism
+		transport.SASL = mechanism
+	}
+
+	return &Driver{
+		conf:   conf,
+		dialer: dialer,
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(conf.Brokers...),
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireAll,
+			AllowAutoTopicCreation: true,
+			Transport:              transport,
+		},
+	}, nil
+}
+
+// Publish 发送消息，延时消息写入投递时间头后发送到 "主题.retry"
+func (d *Driver) Publish(ctx context.Context, msg *mq.Message) error {
+	topic := msg.Topic
+	headers := make([]kafka.Header, 0, len(msg.Headers)+1)
+	for k, v := range msg.Headers {
+		// 重新发送的消息使用新的投递时间
+		if k == headerDeliverAt {
+			continue
+		}
+		headers = append(headers, kafka.Header{Key: k, Value: []byte(v)})
+	}
+	if msg.Delay > 0 {
+		deliverAt := time.Now().Add(msg.Delay).UnixMilli()
+		headers = append(headers, kafka.Header{Key: headerDeliverAt, Value: []byte(strconv.FormatInt(deliverAt, 10))})
+		topic += retrySuffix
+	}
+
+	// 设置分区键时按分区键分区，保证同一分区键的消息有序；没有键时 Key 为 nil，由 Hash 轮询分区
+	message := kafka.Message{Topic: topic, Value: msg.Body, Headers: headers}
+	key := msg.Key
+	if shardingKey := msg.ShardingKey(); shardingKey != "" {
+		key = shardingKey
+	}
+	if key != "" {
+		message.Key = []byte(key)
+	}
+	return d.writer.WriteMessages(ctx, message)
+}
+
+// Subscribe 订阅主题及其 "主题.retry"，消息处理失败时在本地按退避时间重试，成功或放弃后提交位点
+func (d *Driver) Subscribe(ctx context.Context, topic string, opts mq.SubscribeOptions, handler mq.Handler) error {
+	return mq.Consume(ctx, 2*opts.Concurrency, func(i int, handleCtx context.Context) error {
+		readTopic := topic
+		if i >= opts.Concurrency {
+			readTopic = topic + retrySuffix
+		}
+		return d.consume(ctx, handleCtx, topic, readTopic, opts.Group, handler)
+	})
+}
+
+// consume 消费 readTopic，投递的消息主题为 topic
+func (d *Driver) consume(ctx, handleCtx context.Context, topic, readTopic, group string, handler mq.Handler) error {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers: d.conf.Brokers,
+		GroupID: group,
+		Topic:   readTopic,
+		Dialer:  d.dialer,
+		// "主题.retry" 在首次发送延时消息时才自动创建，创建后需重新分配分区
+		WatchPartitionChanges: true,
+	})
+	defer reader.Close()
+
+	for {
+		m, err := reader.FetchMessage(ctx)
+		if err != nil {
+			return err
+		}
+
+		delivery := newDelivery(topic, m)
+		if !waitDeliverAt(ctx, m) {
+			return ctx.Err()
+		}
+		for {
+			if err = handler(handleCtx, delivery); err == nil {
+				break
+			}
+			delivery.Attempts++
+			if !sleep(ctx, backoff(delivery.Attempts)) {
+				// 未提交位点，重新分配分区后再次投递
+				return ctx.Err()
+			}
+		}
+
+		if err = reader.CommitMessages(handleCtx, m); err != nil {
+			return err
+		}
+	}
+}
+
+// Partitioned 每个并发对应一个 Reader，同一分区只由一个 Reader 按顺序消费
+func (d *Driver) Partitioned() bool {
+	return true
+}
+
+// Ping 连接任意一个 broker
+func (d *Driver) Ping(ctx context.Context) error {
+	var errs []error
+	for _, addr := range d.conf.Brokers {
+		conn, err := d.dialer.DialContext(ctx, "tcp", addr)
+		if err == nil {
+			return conn.Close()
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// Close 关闭生产者
+func (d *Driver) Close() error {
+	return d.writer.Close()
+}
+
+// newDelivery 转换消息，"主题.retry" 的位点与原主题相互独立，消息 ID 加上 retry 前缀
+func newDelivery(topic string, m kafka.Message) *mq.Delivery {
+	headers := make(map[string]string, len(m.Headers))
+	for _, h := range m.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	id := fmt.Sprintf("%d-%d", m.Partition, m.Offset)
+	if m.Topic != topic {
+		id = "retry-" + id
+	}
+	return &mq.Delivery{
+		Message: mq.Message{
+			Topic:   topic,
+			Key:     string(m.Key),
+			Body:    m.Value,
+			Headers: headers,
+		},
+		ID:       id,
+		Attempts: mq.Attempts(headers),
+	}
+}
+
+// waitDeliverAt 等待延时消息到期，等待期间阻塞 "主题.retry" 的所在分区
+func waitDeliverAt(ctx context.Context, m kafka.Message) bool {
+	for _, h := range m.Headers {
+		if h.Key != headerDeliverAt {
+			continue
+		}
+		deliverAt, err := strconv.ParseInt(string(h.Value), 10, 64)
+		if err != nil {
+			return true
+		}
+		return sleep(ctx, time.Until(time.UnixMilli(deliverAt)))
+	}
+	return true
+}
+
+func backoff(attempts int) time.Duration {
+	d := time.Duration(attempts) * time.Second
+	if d > maxRetryBackoff {
+		return maxRetryBackoff
+	}
+	return d
+}
+
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
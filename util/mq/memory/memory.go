@@ -0,0 +1,369 @@
+package memory
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/segmentio/ksuid"
+	"go-framework/util/helper"
+	"go-framework/util/mq"
+	"sync"
+	"time"
+)
+
+const defaultRetryDelay = time.Second
+
+// ErrClosed 驱动已关闭
+var ErrClosed = errors.New("memory mq: driver closed")
+
+var _ mq.Driver = (*Driver)(nil)
+
+// Config 进程内消息队列配置
+type Config struct {
+	Path       string `json:"path"`        // BoltDB 文件路径，为空时消息只保存在内存中
+	RetryDelay int    `json:"retry_delay"` // 消费失败后重新投递的间隔（毫秒），默认 1000
+}
+
+// Option 驱动选项
+type Option func(d *Driver)
+
+// WithStore 使用自定义的消息存储，如 SQLite
+func WithStore(store Store) Option {
+	return func(d *Driver) {
+		d.store = store
+	}
+}
+
+// Driver 进程内消息队列驱动，用于本地开发与测试
+//
+// 每个消费组独立接收主题的全部消息，组内多个消费者竞争消费。延时消息与失败重试按投递时间
+// 排队，消息在确认前保留在存储中，配置了持久化时进程重启后重新投递未确认的消息。
+// 主题尚无消费组时发送的消息暂存，由第一个订阅的消费组接收。
+type Driver struct {
+	store      Store
+	retryDelay time.Duration
+
+	mu     sync.Mutex
+	topics map[string]*topic
+	seq    uint64
+	closed bool
+}
+
+type topic struct {
+	backlog []*Record
+	groups  map[string]*group
+}
+
+type group struct {
+	queue  recordHeap
+	signal chan struct{}
+}
+
+// New 创建进程内消息队列驱动
+func New(c interface{}, opts ...Option) (*Driver, error) {
+	var conf Config
+	if err := helper.UnMarshalWithInterface(c, &conf); err != nil {
+		return nil, fmt.Errorf("memory mq config error: %w", err)
+	}
+
+	d := &Driver{
+		retryDelay: defaultRetryDelay,
+		topics:     make(map[string]*topic),
+	}
+	if conf.RetryDelay > 0 {
+		d.retryDelay = time.Duration(conf.RetryDelay) * time.Millisecond
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	if d.store == nil && conf.Path != "" {
+		store, err := NewBoltStore(conf.Path)
+		if err != nil {
+			return nil, fmt.Errorf("memory mq store error: %w", err)
+		}
+		d.store = store
+	}
+	if err := d.load(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// load 恢复存储中未确认的消息
+func (d *Driver) load() error {
+	if d.store == nil {
+		return nil
+	}
+	records, err := d.store.Load()
+	if err != nil {
+		return fmt.Errorf("memory mq load error: %w", err)
+	}
+	for _, r := range records {
+		if r.Seq > d.seq {
+			d.seq = r.Seq
+		}
+		t := d.topic(r.Topic)
+		if r.Group == "" {
+			t.backlog = append(t.backlog, r)
+			continue
+		}
+		heap.Push(&t.group(r.Group).queue, r)
+	}
+	return nil
+}
+
+// Publish 发送消息，复制给主题的每个消费组
+func (d *Driver) Publish(ctx context.Context, msg *mq.Message) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return ErrClosed
+	}
+
+	d.seq++
+	base := Record{
+		ID:        ksuid.New().String(),
+		Topic:     msg.Topic,
+		Key:       msg.Key,
+		Headers:   msg.Headers,
+		Body:      msg.Body,
+		DeliverAt: time.Now().Add(msg.Delay).UnixMilli(),
+		Seq:       d.seq,
+	}
+
+	t := d.topic(msg.Topic)
+	if len(t.groups) == 0 {
+		r := base
+		if err := d.put(&r); err != nil {
+			return err
+		}
+		t.backlog = append(t.backlog, &r)
+		return nil
+	}
+
+	records := make([]*Record, 0, len(t.groups))
+	for name := range t.groups {
+		r := base
+		r.Group = name
+		records = append(records, &r)
+	}
+	if err := d.put(records...); err != nil {
+		return err
+	}
+	for _, r := range records {
+		g := t.groups[r.Group]
+		heap.Push(&g.queue, r)
+		g.notify()
+	}
+	return nil
+}
+
+// Subscribe 订阅主题，处理失败的消息按重试间隔重新排队
+func (d *Driver) Subscribe(ctx context.Context, topic string, opts mq.SubscribeOptions, handler mq.Handler) error {
+	if err := d.join(topic, opts.Group); err != nil {
+		return err
+	}
+
+	return mq.Consume(ctx, opts.Concurrency, func(_ int, handleCtx context.Context) error {
+		d.consume(ctx, handleCtx, topic, opts.Group, handler)
+		return nil
+	})
+}
+
+// join 创建消费组，主题暂存的消息转给该消费组
+func (d *Driver) join(name, groupName string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return ErrClosed
+	}
+
+	t := d.topic(name)
+	g := t.group(groupName)
+	if len(t.backlog) == 0 {
+		return nil
+	}
+
+	records := make([]*Record, len(t.backlog))
+	for i, r := range t.backlog {
+		moved := *r
+		moved.Group = groupName
+		records[i] = &moved
+	}
+	if err := d.put(records...); err != nil {
+		return err
+	}
+	if err := d.delete(t.backlog...); err != nil {
+		return err
+	}
+	for _, r := range records {
+		heap.Push(&g.queue, r)
+	}
+	t.backlog = nil
+	g.notify()
+	return nil
+}
+
+func (d *Driver) consume(ctx, handleCtx context.Context, topic, group string, handler mq.Handler) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for ctx.Err() == nil {
+		r, wait, signal := d.next(topic, group)
+		if r != nil {
+			d.handle(handleCtx, r, handler)
+			continue
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+		select {
+		case <-ctx.Done():
+			return
+		case <-signal:
+		case <-timer.C:
+		}
+	}
+}
+
+// next 取出到期的消息，没有时返回距离最近一条消息到期的时间
+func (d *Driver) next(topic, group string) (*Record, time.Duration, <-chan struct{}) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	g := d.topic(topic).group(group)
+	wait := time.Minute
+	if len(g.queue) > 0 {
+		wait = time.Until(time.UnixMilli(g.queue[0].DeliverAt))
+		if wait <= 0 {
+			return heap.Pop(&g.queue).(*Record), 0, nil
+		}
+	}
+	return nil, wait, g.signal
+}
+
+func (d *Driver) handle(ctx context.Context, r *Record, handler mq.Handler) {
+	delivery := &mq.Delivery{
+		Message:  mq.Message{Topic: r.Topic, Key: r.Key, Body: r.Body, Headers: r.Headers},
+		ID:       r.ID,
+		Attempts: mq.Attempts(r.Headers),
+	}
+	if err := handler(ctx, delivery); err == nil {
+		d.ack(r)
+		return
+	}
+	d.requeue(r, mq.Retry(delivery).Headers)
+}
+
+func (d *Driver) ack(r *Record) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_ = d.delete(r)
+}
+
+// requeue 带递增的投递次数重新排队，写入存储失败时原消息仍保留在存储中
+func (d *Driver) requeue(r *Record, headers map[string]string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	retry := *r
+	retry.Headers = headers
+	retry.DeliverAt = time.Now().Add(d.retryDelay).UnixMilli()
+	_ = d.put(&retry)
+	g := d.topic(r.Topic).group(r.Group)
+	heap.Push(&g.queue, &retry)
+	g.notify()
+}
+
+// Ping 驱动未关闭时始终可用
+func (d *Driver) Ping(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return ErrClosed
+	}
+	return nil
+}
+
+// Close 关闭驱动与存储，未确认的消息保留在存储中
+func (d *Driver) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return nil
+	}
+	d.closed = true
+	if d.store == nil {
+		return nil
+	}
+	return d.store.Close()
+}
+
+func (d *Driver) topic(name string) *topic {
+	t, ok := d.topics[name]
+	if !ok {
+		t = &topic{groups: make(map[string]*group)}
+		d.topics[name] = t
+	}
+	return t
+}
+
+func (d *Driver) put(records ...*Record) error {
+	if d.store == nil || d.closed {
+		return nil
+	}
+	return d.store.Put(records...)
+}
+
+func (d *Driver) delete(records ...*Record) error {
+	if d.store == nil || d.closed {
+		return nil
+	}
+	return d.store.Delete(records...)
+}
+
+func (t *topic) group(name string) *group {
+	g, ok := t.groups[name]
+	if !ok {
+		g = &group{signal: make(chan struct{})}
+		t.groups[name] = g
+	}
+	return g
+}
+
+// notify 唤醒等待中的消费者
+func (g *group) notify() {
+	close(g.signal)
+	g.signal = make(chan struct{})
+}
+
+// recordHeap 按投递时间与写入顺序排列的消息堆
+type recordHeap []*Record
+
+func (h recordHeap) Len() int { return len(h) }
+
+func (h recordHeap) Less(i, j int) bool {
+	if h[i].DeliverAt != h[j].DeliverAt {
+		return h[i].DeliverAt < h[j].DeliverAt
+	}
+	return h[i].Seq < h[j].Seq
+}
+
+func (h recordHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *recordHeap) Push(x interface{}) { *h = append(*h, x.(*Record)) }
+
+func (h *recordHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	r := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return r
+}
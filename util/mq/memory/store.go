@@ -0,0 +1,117 @@
+package memory
+
+import (
+	"go-framework/util/helper"
+	bolt "go.etcd.io/bbolt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var messagesBucket = []byte("messages")
+
+// Record 持久化的消息，Group 为空表示主题尚无消费组订阅
+type Record struct {
+	ID        string            `json:"id"`
+	Topic     string            `json:"topic"`
+	Group     string            `json:"group"`
+	Key       string            `json:"key"`
+	Headers   map[string]string `json:"headers"`
+	Body      []byte            `json:"body"`
+	DeliverAt int64             `json:"deliver_at"` // 投递时间（毫秒时间戳）
+	Seq       uint64            `json:"seq"`        // 写入顺序，投递时间相同时按顺序投递
+}
+
+// Store 消息存储，消息在确认前一直保留，进程重启后重新投递
+type Store interface {
+	// Load 读取全部未确认的消息
+	Load() ([]*Record, error)
+	// Put 写入或覆盖消息
+	Put(records ...*Record) error
+	// Delete 删除已确认的消息
+	Delete(records ...*Record) error
+	// Close 关闭存储
+	Close() error
+}
+
+// BoltStore 基于 BoltDB 的消息存储
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore 打开 BoltDB 文件，文件不存在时创建
+func NewBoltStore(path string) (*BoltStore, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 3 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(messagesBucket)
+		return err
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &BoltStore{db: db}, nil
+}
+
+// Load 读取全部未确认的消息
+func (s *BoltStore) Load() ([]*Record, error) {
+	var records []*Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(messagesBucket).ForEach(func(k, v []byte) error {
+			var r Record
+			if err := helper.UmMarshal(v, &r); err != nil {
+				return err
+			}
+			records = append(records, &r)
+			return nil
+		})
+	})
+	return records, err
+}
+
+// Put 在同一事务中写入消息
+func (s *BoltStore) Put(records ...*Record) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(messagesBucket)
+		for _, r := range records {
+			data, err := helper.Marshal(r)
+			if err != nil {
+				return err
+			}
+			if err = bucket.Put(recordKey(r), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Delete 在同一事务中删除消息
+func (s *BoltStore) Delete(records ...*Record) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(messagesBucket)
+		for _, r := range records {
+			if err := bucket.Delete(recordKey(r)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Close 关闭数据库
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+func recordKey(r *Record) []byte {
+	return []byte(r.Topic + "\x00" + r.Group + "\x00" + r.ID)
+}
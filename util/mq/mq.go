@@ -0,0 +1,302 @@
+package mq
+
+import (
+	"context"
+	"errors"
+	"go-framework/util/mq/queue"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// HeaderJob 消息头：任务名称
+	HeaderJob = "x-job"
+	// HeaderAttempts 消息头：驱动重新发送失败消息时记录的投递次数
+	HeaderAttempts = "x-attempts"
+	// HeaderShardingKey 消息头：分区键，相同键的消息发送到同一分区
+	HeaderShardingKey = "x-sharding-key"
+	// HeaderMessageID 消息头：重新发送的消息记录原消息 ID，幂等键按原消息计算
+	HeaderMessageID = "x-message-id"
+)
+
+// Producer 消息生产者，任务消息由订阅同一队列的 Consumer 解析并执行
+type Producer interface {
+	// Publish 发送原始消息，用于重放死信
+	Publish(ctx context.Context, msg *Message) error
+	// SendJobMessage 发送任务消息
+	SendJobMessage(ctx context.Context, job queue.Job, msg interface{}, opts ...SendOption) error
+	// SendJobDelayMessage 发送延时任务消息
+	SendJobDelayMessage(ctx context.Context, job queue.Job, msg interface{}, delay time.Duration, opts ...SendOption) error
+}
+
+// SendOptions 发送选项
+type SendOptions struct {
+	ShardingKey string // 分区键，优先于任务提供的分区键
+}
+
+// SendOption 发送选项函数
+type SendOption func(o *SendOptions)
+
+// WithShardingKey 设置分区键，相同键的消息发送到同一分区（rocketmq sharding key、kafka 分区键），
+// 顺序消费时按发送顺序处理
+func WithShardingKey(key string) SendOption {
+	return func(o *SendOptions) {
+		o.ShardingKey = key
+	}
+}
+
+// NewSendOptions 创建发送选项
+func NewSendOptions(opts ...SendOption) SendOptions {
+	var o SendOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Apply 将发送选项写入消息
+func (o SendOptions) Apply(msg *Message) {
+	if o.ShardingKey != "" {
+		if msg.Headers == nil {
+			msg.Headers = make(map[string]string)
+		}
+		msg.Headers[HeaderShardingKey] = o.ShardingKey
+	}
+}
+
+// Consumer 消息消费者
+type Consumer interface {
+	// Subscribe 订阅队列，在后台执行队列中注册的任务
+	Subscribe(q queue.Queue, opts ...ConsumeOption) error
+	// Shutdown 停止消费，等待处理中的消息完成
+	Shutdown(ctx context.Context) error
+}
+
+// Broker 消息中间件
+type Broker interface {
+	Producer
+	Consumer
+	// JobMessage 编码任务消息，用于先保存再发送的场景（如 outbox）
+	JobMessage(job queue.Job, msg interface{}) (*Message, error)
+	// Ping 检查中间件是否可达
+	Ping(ctx context.Context) error
+	// Close 释放连接，在 Shutdown 之后调用
+	Close() error
+}
+
+// ConsumeOptions 消费选项
+type ConsumeOptions struct {
+	Concurrency    int            // 并发数
+	MaxConcurrency int            // 运行时可调整的并发上限，默认等于 Concurrency，rocketmq 不限
+	Paused         bool           // 订阅后暂停，通过 ConsumerControl 恢复
+	RetryTimes     int64          // 未设置重试策略时由驱动重新投递的次数，超过后进入死信队列
+	RetryPolicy    RetryPolicy    // 重试策略，任务实现 RetryableJob 时以任务为准
+	Ordered        bool           // 顺序消费，同一分区键的消息按发送顺序处理，仅支持 kafka 与 rocketmq
+	OrderedFailure OrderedFailure // 顺序消费超过重试策略后的处理方式
+	BatchLimit     int            // 批量任务每批的消息数上限，0 表示按任务的批量大小，rocketmq 不使用
+}
+
+// ConsumeOption 消费选项函数
+type ConsumeOption func(o *ConsumeOptions)
+
+// WithConcurrency 设置并发数
+func WithConcurrency(concurrency int) ConsumeOption {
+	return func(o *ConsumeOptions) {
+		o.Concurrency = concurrency
+	}
+}
+
+// WithMaxConcurrency 设置运行时可调整的并发上限
+func WithMaxConcurrency(maxConcurrency int) ConsumeOption {
+	return func(o *ConsumeOptions) {
+		o.MaxConcurrency = maxConcurrency
+	}
+}
+
+// WithPaused 订阅后暂停消费
+func WithPaused(paused bool) ConsumeOption {
+	return func(o *ConsumeOptions) {
+		o.Paused = paused
+	}
+}
+
+// WithOrdered 顺序消费：同一分区键的消息串行处理，不同分区键并行处理，onFailure 为超过重试策略后的处理方式。
+// 支持 kafka 与 rocketmq：kafka 失败的消息原地重试，rocketmq 失败的消息不确认，由 mq 按顺序重新投递；
+// 其他驱动订阅时返回错误
+func WithOrdered(onFailure OrderedFailure) ConsumeOption {
+	return func(o *ConsumeOptions) {
+		o.Ordered = true
+		o.OrderedFailure = onFailure
+	}
+}
+
+// WithRetryTimes 设置失败重试次数
+func WithRetryTimes(retryTimes int64) ConsumeOption {
+	return func(o *ConsumeOptions) {
+		o.RetryTimes = retryTimes
+	}
+}
+
+// WithRetryPolicy 设置重试策略，失败的消息按策略重新发送延时消息
+func WithRetryPolicy(policy RetryPolicy) ConsumeOption {
+	return func(o *ConsumeOptions) {
+		o.RetryPolicy = policy
+	}
+}
+
+// WithBatchLimit 设置批量任务每批的消息数上限。
+// rocketmq 以外的驱动同步处理消息，批次中的消息数不超过 MaxConcurrency，上限不大于 MaxConcurrency 时批次满后立即执行
+func WithBatchLimit(limit int) ConsumeOption {
+	return func(o *ConsumeOptions) {
+		o.BatchLimit = limit
+	}
+}
+
+// NewConsumeOptions 应用消费选项，并发数默认为 1
+func NewConsumeOptions(opts ...ConsumeOption) ConsumeOptions {
+	o := ConsumeOptions{Concurrency: 1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 1
+	}
+	return o
+}
+
+// ConsumerConfig 按主题配置的消费选项，在代码中的选项之后应用
+type ConsumerConfig struct {
+	Concurrency    int    `json:"concurrency"`
+	MaxConcurrency int    `json:"max_concurrency"`
+	Paused         bool   `json:"paused"`
+	Ordered        bool   `json:"ordered"`
+	OrderedFailure string `json:"ordered_failure"` // block、skip，默认 block
+	BatchLimit     int    `json:"batch_limit"`
+}
+
+// Options 转换为消费选项，未配置的字段不覆盖代码中的选项
+func (c ConsumerConfig) Options() []ConsumeOption {
+	var opts []ConsumeOption
+	if c.Concurrency > 0 {
+		opts = append(opts, WithConcurrency(c.Concurrency))
+	}
+	if c.MaxConcurrency > 0 {
+		opts = append(opts, WithMaxConcurrency(c.MaxConcurrency))
+	}
+	if c.Paused {
+		opts = append(opts, WithPaused(true))
+	}
+	if c.Ordered {
+		onFailure := OrderedBlock
+		if c.OrderedFailure == "skip" {
+			onFailure = OrderedSkip
+		}
+		opts = append(opts, WithOrdered(onFailure))
+	}
+	if c.BatchLimit > 0 {
+		opts = append(opts, WithBatchLimit(c.BatchLimit))
+	}
+	return opts
+}
+
+// Message 发送的原始消息
+type Message struct {
+	Topic   string
+	Key     string
+	Body    []byte
+	Headers map[string]string
+	Delay   time.Duration
+}
+
+// ShardingKey 消息的分区键
+func (m *Message) ShardingKey() string {
+	return m.Headers[HeaderShardingKey]
+}
+
+// Delivery 消费到的原始消息
+type Delivery struct {
+	Message
+	ID       string
+	Attempts int // 第几次投递，从 1 开始
+}
+
+// OriginID 原消息 ID，延后投递的消息 ID 由驱动重新生成，原消息 ID 记录在消息头中
+func (d *Delivery) OriginID() string {
+	if id := d.Headers[HeaderMessageID]; id != "" {
+		return id
+	}
+	return d.ID
+}
+
+// Handler 原始消息处理函数，返回 nil 时确认消息，否则由驱动重新投递且 Attempts 加 1
+type Handler func(ctx context.Context, d *Delivery) error
+
+// SubscribeOptions 订阅选项
+type SubscribeOptions struct {
+	Group       string
+	Concurrency int
+}
+
+// Driver 消息中间件驱动，只负责原始消息的收发
+type Driver interface {
+	// Publish 发送消息
+	Publish(ctx context.Context, msg *Message) error
+	// Subscribe 订阅主题并以 Concurrency 个并发调用 handler，阻塞直到 ctx 取消且处理中的消息完成
+	Subscribe(ctx context.Context, topic string, opts SubscribeOptions, handler Handler) error
+	// Ping 检查中间件是否可达
+	Ping(ctx context.Context) error
+	// Close 释放连接
+	Close() error
+}
+
+// Consume 启动 n 个消费协程并等待全部退出，供驱动的 Subscribe 使用。
+// ctx 取消时消费协程停止接收新消息，handleCtx 不随 ctx 取消，处理中的消息不随订阅取消而中断。
+// ctx 已取消时返回 ctx.Err()，否则返回各消费协程的错误
+func Consume(ctx context.Context, n int, consume func(i int, handleCtx context.Context) error) error {
+	handleCtx := context.WithoutCancel(ctx)
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = consume(i, handleCtx)
+		}(i)
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return errors.Join(errs...)
+}
+
+// Attempts 读取消息头中的投递次数，缺失时为 1
+func Attempts(headers map[string]string) int {
+	attempts, err := strconv.Atoi(headers[HeaderAttempts])
+	if err != nil || attempts < 1 {
+		return 1
+	}
+	return attempts
+}
+
+// Retry 复制待重新投递的消息并将投递次数加 1
+func Retry(d *Delivery) *Message {
+	headers := make(map[string]string, len(d.Headers)+1)
+	for k, v := range d.Headers {
+		headers[k] = v
+	}
+	headers[HeaderAttempts] = strconv.Itoa(d.Attempts + 1)
+	return &Message{Topic: d.Topic, Key: d.Key, Body: d.Body, Headers: headers}
+}
+
+// Redeliver 复制待延后投递的消息，投递次数不变，消息头记录原消息 ID
+func Redeliver(d *Delivery) *Message {
+	headers := make(map[string]string, len(d.Headers)+1)
+	for k, v := range d.Headers {
+		headers[k] = v
+	}
+	headers[HeaderMessageID] = d.OriginID()
+	return &Message{Topic: d.Topic, Key: d.Key, Body: d.Body, Headers: headers}
+}
@@ -0,0 +1,91 @@
+package mq
+
+import (
+	"context"
+	"go-framework/util/mq/queue"
+	"time"
+)
+
+// defaultOrderedRetryDelay 顺序消费未设置重试策略时原地重试的间隔
+const defaultOrderedRetryDelay = time.Second
+
+// OrderedFailure 顺序消费时消息执行失败的处理方式
+type OrderedFailure int
+
+const (
+	// OrderedBlock 原地重试直到成功，阻塞同一分区键的后续消息，超过重试策略后按最后一次间隔继续重试
+	OrderedBlock OrderedFailure = iota
+	// OrderedSkip 按重试策略原地重试，超过后写入死信队列并继续处理同一分区键的后续消息
+	OrderedSkip
+)
+
+// ShardedJob 提供分区键的任务，相同键的消息发送到同一分区，顺序消费时按发送顺序处理
+type ShardedJob interface {
+	queue.Job
+	ShardingKey(msg interface{}) string
+}
+
+// JobShardingKey 任务消息的分区键，任务未实现 ShardedJob 时为空
+func JobShardingKey(job queue.Job, msg interface{}) string {
+	if j, ok := job.(ShardedJob); ok {
+		return j.ShardingKey(msg)
+	}
+	return ""
+}
+
+// PartitionedDriver 按分区键分区且每个并发独占分区的驱动（如 kafka），只有这类驱动支持顺序消费
+type PartitionedDriver interface {
+	Driver
+	Partitioned() bool
+}
+
+// OrderedRetry 顺序消费的原地重试，失败的消息不重新发送，避免打乱同一分区键的顺序
+type OrderedRetry struct {
+	Policy     RetryPolicy    // 重试策略，为 nil 时按 RetryTimes 以固定间隔重试
+	RetryTimes int64          // 未设置重试策略时的重试次数
+	OnFailure  OrderedFailure // 超过重试策略后的处理方式
+}
+
+// Next 第 attempts 次执行失败后的重试间隔，last 为上次间隔，返回 false 时写入死信队列
+func (r OrderedRetry) Next(attempts int, last time.Duration) (time.Duration, bool) {
+	delay, ok := defaultOrderedRetryDelay, int64(attempts) <= r.RetryTimes
+	if r.Policy != nil {
+		delay, ok = r.Policy.Next(attempts)
+	}
+	if ok {
+		return delay, true
+	}
+	if r.OnFailure == OrderedBlock {
+		if last <= 0 {
+			last = defaultOrderedRetryDelay
+		}
+		return last, true
+	}
+	return 0, false
+}
+
+// Do 从第 attempts 次开始执行 fn，失败时原地重试，返回最后一次执行的次数与错误。
+// ctx 结束时停止重试并返回最后一次执行的错误，由调用方判断是否需要重新投递
+func (r OrderedRetry) Do(ctx context.Context, attempts int, fn func(attempts int) error) (int, error) {
+	var last time.Duration
+	for {
+		err := fn(attempts)
+		if err == nil {
+			return attempts, nil
+		}
+		delay, ok := r.Next(attempts, last)
+		if !ok {
+			return attempts, err
+		}
+		last = delay
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return attempts, err
+		case <-timer.C:
+		}
+		attempts++
+	}
+}
@@ -0,0 +1,75 @@
+package outbox
+
+import (
+	"go-framework/util/helper"
+	"go-framework/util/mq"
+	"gorm.io/gorm"
+	"time"
+)
+
+const defaultTable = "mq_outbox"
+
+// 消息状态
+const (
+	StatusPending int8 = 0 // 待发送
+	StatusSent    int8 = 1 // 已发送
+	StatusFailed  int8 = 2 // 超过最大发送次数，需人工处理
+)
+
+// Record outbox 表中的消息
+type Record struct {
+	ID        uint64     `gorm:"primaryKey" json:"id"`
+	Topic     string     `gorm:"size:255;not null;index:idx_outbox_topic" json:"topic"`
+	Key       string     `gorm:"column:message_key;size:255" json:"key"`
+	Headers   string     `gorm:"type:text" json:"headers"` // 消息头（JSON）
+	Body      []byte     `json:"body"`
+	Delay     int64      `json:"delay"` // 延时（毫秒），从写入时开始计算
+	Status    int8       `gorm:"not null;default:0;index:idx_outbox_status,priority:1" json:"status"`
+	Attempts  int        `gorm:"not null;default:0" json:"attempts"`
+	NextAt    time.Time  `gorm:"index:idx_outbox_status,priority:2" json:"next_at"` // 下次发送时间
+	Error     string     `gorm:"type:text" json:"error"`                            // 最后一次发送失败的原因
+	CreatedAt time.Time  `json:"created_at"`
+	SentAt    *time.Time `gorm:"index:idx_outbox_sent_at" json:"sent_at"`
+}
+
+// newRecord 根据待发送的消息创建记录
+func newRecord(msg *mq.Message) (*Record, error) {
+	headers, err := helper.Marshal(msg.Headers)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &Record{
+		Topic:     msg.Topic,
+		Key:       msg.Key,
+		Headers:   string(headers),
+		Body:      msg.Body,
+		Delay:     msg.Delay.Milliseconds(),
+		Status:    StatusPending,
+		NextAt:    now,
+		CreatedAt: now,
+	}, nil
+}
+
+// Message 还原待发送的消息，延时扣除已等待的时间
+func (r *Record) Message() (*mq.Message, error) {
+	headers := make(map[string]string)
+	if r.Headers != "" {
+		if err := helper.UmMarshal([]byte(r.Headers), &headers); err != nil {
+			return nil, err
+		}
+	}
+	msg := &mq.Message{Topic: r.Topic, Key: r.Key, Body: r.Body, Headers: headers}
+	if delay := time.Duration(r.Delay)*time.Millisecond - time.Since(r.CreatedAt); delay > 0 {
+		msg.Delay = delay
+	}
+	return msg, nil
+}
+
+// Migrate 创建或更新 outbox 表，table 为空时使用 mq_outbox
+func Migrate(db *gorm.DB, table string) error {
+	if table == "" {
+		table = defaultTable
+	}
+	return db.Table(table).AutoMigrate(&Record{})
+}
@@ -0,0 +1,81 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"go-framework/util/mq"
+	"go-framework/util/mq/queue"
+	"go-framework/util/types"
+	"go-framework/util/xsql/transaction"
+	"gorm.io/gorm"
+	"time"
+)
+
+// system outbox 写入时生产者 span 的 messaging.system
+const system = "outbox"
+
+// JobEncoder 任务消息编码，mq.Client 按任务所属的中间件编码
+type JobEncoder interface {
+	JobMessage(job queue.Job, msg interface{}) (*mq.Message, error)
+}
+
+// Producer 在业务事务中写入 outbox 消息，事务提交后由 Relay 发送，事务回滚时消息一并丢弃
+//
+//	tx, _ := svc.DBEngine.NewTransaction(config.DBDefault)
+//	_ = tx.Tx[string(config.DBDefault)].Create(&order).Error
+//	_ = svc.Outbox.SendJobMessage(ctx, tx, job.ShopJob, job.ShopPayload{ShopId: 1})
+//	_ = tx.Commit()
+//
+// tx 为 nil 时使用 ctx 中由 WithTransaction 开启的事务
+type Producer struct {
+	encoder JobEncoder
+	db      types.DB
+	table   string
+}
+
+// NewProducer 创建 outbox 生产者，db 为 outbox 表所在的数据库，table 为空时使用 mq_outbox
+func NewProducer(encoder JobEncoder, db types.DB, table string) *Producer {
+	if table == "" {
+		table = defaultTable
+	}
+	return &Producer{encoder: encoder, db: db, table: table}
+}
+
+// SendJobMessage 在事务中写入任务消息
+func (p *Producer) SendJobMessage(ctx context.Context, tx *transaction.Transaction, job queue.Job, msg interface{}, opts ...mq.SendOption) error {
+	return p.SendJobDelayMessage(ctx, tx, job, msg, 0, opts...)
+}
+
+// SendJobDelayMessage 在事务中写入延时任务消息，延时从写入时开始计算
+func (p *Producer) SendJobDelayMessage(ctx context.Context, tx *transaction.Transaction, job queue.Job, msg interface{}, delay time.Duration, opts ...mq.SendOption) error {
+	message, err := p.encoder.JobMessage(job, msg)
+	if err != nil {
+		return err
+	}
+	mq.NewSendOptions(opts...).Apply(message)
+	message.Delay = delay
+	return p.Publish(ctx, tx, message)
+}
+
+// Publish 在事务中写入原始消息，链路上下文与请求 ID 写入消息头，由 Relay 发送时恢复
+func (p *Producer) Publish(ctx context.Context, tx *transaction.Transaction, msg *mq.Message) (err error) {
+	var db *gorm.DB
+	var ok bool
+	if tx != nil {
+		db, ok = tx.Tx[string(p.db)]
+	} else {
+		db, ok = transaction.DB(ctx, string(p.db))
+	}
+	if !ok || db == nil {
+		return fmt.Errorf("outbox: transaction of db %s is not started", p.db)
+	}
+
+	ctx, span := mq.StartPublish(ctx, system, msg)
+	defer func() { mq.EndSpan(span, err) }()
+
+	record, err := newRecord(msg)
+	if err != nil {
+		return err
+	}
+	return db.WithContext(ctx).Table(p.table).Create(record).Error
+}
@@ -0,0 +1,322 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"github.com/go-redsync/redsync/v4"
+	"go-framework/util/helper"
+	"go-framework/util/locker"
+	"go-framework/util/mq"
+	"go-framework/util/xlog"
+	"go-framework/util/xsql/resolver"
+	"gorm.io/gorm"
+	"sync"
+	"time"
+)
+
+const (
+	defaultInterval    = time.Second
+	defaultBatchSize   = 100
+	defaultMaxAttempts = 10
+	defaultRetryDelay  = time.Second
+	defaultRetention   = 72 * time.Hour
+
+	// lockExpiry relay 锁的过期时间，持有锁的实例每 lockExpiry/3 续期
+	lockExpiry      = 30 * time.Second
+	cleanupInterval = time.Hour
+	cleanupBatch    = 1000
+)
+
+// Config outbox 配置
+type Config struct {
+	Enable      bool   `json:"enable"`       // 是否启动 relay
+	DB          string `json:"db"`           // outbox 表所在的数据库，默认 default
+	Redis       string `json:"redis"`        // relay 分布式锁使用的 redis 别名，默认 default
+	Table       string `json:"table"`        // 表名，默认 mq_outbox
+	AutoMigrate bool   `json:"auto_migrate"` // 启动时自动建表
+	Interval    int    `json:"interval"`     // 轮询间隔（毫秒），默认 1000
+	BatchSize   int    `json:"batch_size"`   // 每批发送的最大条数，默认 100
+	MaxAttempts int    `json:"max_attempts"` // 最大发送次数，超过后标记为失败，默认 10
+	RetryDelay  int    `json:"retry_delay"`  // 发送失败后的重试间隔（毫秒），按发送次数递增，默认 1000
+	Retention   int    `json:"retention"`    // 已发送消息的保留时间（小时），默认 72
+}
+
+// Publisher 消息发送，mq.Client 按主题路由到所属的中间件
+type Publisher interface {
+	Publish(ctx context.Context, msg *mq.Message) error
+}
+
+// Relay 将 outbox 表中待发送的消息发送到消息中间件
+//
+// 多个实例通过分布式锁保证只有一个实例发送。同一主题的消息按写入顺序发送，某条消息发送失败时
+// 该主题后续的消息等待其重试成功或超过最大发送次数后再发送。消息发送成功但状态更新失败时会重复发送，
+// 消费者需保证幂等。
+type Relay struct {
+	db        *gorm.DB
+	publisher Publisher
+	redis     *redis.Client
+	logger    *xlog.Log
+
+	table       string
+	interval    time.Duration
+	batchSize   int
+	maxAttempts int
+	retryDelay  time.Duration
+	retention   time.Duration
+
+	lastCleanup time.Time
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+// NewRelay 创建 relay，db 为 outbox 表所在的数据库连接
+func NewRelay(c interface{}, db *gorm.DB, publisher Publisher, redis *redis.Client, logger *xlog.Log) (*Relay, error) {
+	var conf Config
+	if err := helper.UnMarshalWithInterface(c, &conf); err != nil {
+		return nil, fmt.Errorf("outbox config error: %w", err)
+	}
+
+	r := &Relay{
+		db:          db,
+		publisher:   publisher,
+		redis:       redis,
+		logger:      logger,
+		table:       defaultTable,
+		interval:    defaultInterval,
+		batchSize:   defaultBatchSize,
+		maxAttempts: defaultMaxAttempts,
+		retryDelay:  defaultRetryDelay,
+		retention:   defaultRetention,
+	}
+	if conf.Table != "" {
+		r.table = conf.Table
+	}
+	if conf.Interval > 0 {
+		r.interval = time.Duration(conf.Interval) * time.Millisecond
+	}
+	if conf.BatchSize > 0 {
+		r.batchSize = conf.BatchSize
+	}
+	if conf.MaxAttempts > 0 {
+		r.maxAttempts = conf.MaxAttempts
+	}
+	if conf.RetryDelay > 0 {
+		r.retryDelay = time.Duration(conf.RetryDelay) * time.Millisecond
+	}
+	if conf.Retention > 0 {
+		r.retention = time.Duration(conf.Retention) * time.Hour
+	}
+	return r, nil
+}
+
+// Start 在后台轮询发送消息
+func (r *Relay) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.run(ctx)
+	}()
+}
+
+// Stop 停止轮询，等待发送中的消息完成
+func (r *Relay) Stop(ctx context.Context) error {
+	if r.cancel == nil {
+		return nil
+	}
+	r.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("outbox relay: wait for in-flight messages: %w", ctx.Err())
+	}
+}
+
+func (r *Relay) run(ctx context.Context) {
+	defer helper.RecoverPanic(r.logger)
+
+	mutex := locker.NewMutex(r.redis)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := mutex.Lock(r.lockKey(), redsync.WithExpiry(lockExpiry), redsync.WithTries(1)); err != nil {
+			continue
+		}
+		if err := r.lead(ctx, mutex, ticker); ctx.Err() == nil {
+			r.logger.Errorf("outbox relay: lost lock: %v", err)
+		}
+	}
+}
+
+// lead 持有锁期间轮询发送，后台每 lockExpiry/3 续期，续期失败时取消发送中的批次并返回原因
+func (r *Relay) lead(ctx context.Context, mutex *locker.Mutex, ticker *time.Ticker) error {
+	leaderCtx, cancel := context.WithCancelCause(ctx)
+	stop := make(chan struct{})
+	defer func() {
+		close(stop)
+		cancel(nil)
+		_, _ = mutex.Unlock()
+	}()
+	go func() {
+		extend := time.NewTicker(lockExpiry / 3)
+		defer extend.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-extend.C:
+				ok, err := mutex.Extend()
+				if err == nil && !ok {
+					err = redsync.ErrExtendFailed
+				}
+				if err != nil {
+					// 锁已失效时其他实例可能开始发送，中止当前批次
+					cancel(fmt.Errorf("outbox relay: extend lock %s: %w", r.lockKey(), err))
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		r.drain(leaderCtx)
+		r.cleanup(leaderCtx)
+
+		select {
+		case <-leaderCtx.Done():
+			return context.Cause(leaderCtx)
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain 积压时连续发送，直到一批未满或出错
+func (r *Relay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		sent, err := r.RelayOnce(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				r.logger.Errorf("outbox relay: %+v", err)
+			}
+			return
+		}
+		if sent < r.batchSize {
+			return
+		}
+	}
+}
+
+// RelayOnce 发送一批到期的待发送消息，返回发送成功的条数
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	// 有消息等待重试的主题不发送后续消息，保证同一主题按写入顺序发送；以数据库时间判断是否到期，不受实例时钟偏差影响
+	waiting := r.db.Table(r.table).Select("topic").Where("status = ? AND next_at > CURRENT_TIMESTAMP", StatusPending)
+
+	// 刚写入的消息可能尚未同步到从库，从主库查询
+	var records []*Record
+	err := r.db.WithContext(resolver.ForceMaster(ctx)).Table(r.table).
+		Where("status = ? AND topic NOT IN (?)", StatusPending, waiting).
+		Order("id").Limit(r.batchSize).Find(&records).Error
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	failed := make(map[string]struct{})
+	for _, record := range records {
+		if _, ok := failed[record.Topic]; ok {
+			continue
+		}
+		if err = r.publish(ctx, record); err != nil {
+			failed[record.Topic] = struct{}{}
+			r.fail(ctx, record, err)
+			continue
+		}
+		sent++
+		err = r.db.WithContext(ctx).Table(r.table).Where("id = ?", record.ID).Updates(map[string]interface{}{
+			"status":   StatusSent,
+			"attempts": gorm.Expr("attempts + 1"),
+			"error":    "",
+			"sent_at":  time.Now(),
+		}).Error
+		if err != nil {
+			return sent, fmt.Errorf("mark message %d sent: %w", record.ID, err)
+		}
+	}
+	return sent, nil
+}
+
+// publish 恢复写入时的链路上下文并发送
+func (r *Relay) publish(ctx context.Context, record *Record) error {
+	msg, err := record.Message()
+	if err != nil {
+		return err
+	}
+	return r.publisher.Publish(mq.ExtractContext(ctx, msg.Headers), msg)
+}
+
+// fail 记录发送失败，按发送次数递增重试间隔，超过最大发送次数后标记为失败
+func (r *Relay) fail(ctx context.Context, record *Record, err error) {
+	attempts := record.Attempts + 1
+	updates := map[string]interface{}{
+		"attempts": attempts,
+		"error":    err.Error(),
+		"next_at":  time.Now().Add(r.retryDelay * time.Duration(attempts)),
+	}
+	if attempts >= r.maxAttempts {
+		updates["status"] = StatusFailed
+		r.logger.Errorf("outbox relay: message %d of topic %s failed after %d attempts: %+v", record.ID, record.Topic, attempts, err)
+	} else {
+		r.logger.Errorf("outbox relay: message %d of topic %s attempt %d failed: %+v", record.ID, record.Topic, attempts, err)
+	}
+
+	if e := r.db.WithContext(ctx).Table(r.table).Where("id = ?", record.ID).Updates(updates).Error; e != nil {
+		r.logger.Errorf("outbox relay: mark message %d failed: %+v", record.ID, e)
+	}
+}
+
+// cleanup 分批删除超过保留时间的已发送消息
+func (r *Relay) cleanup(ctx context.Context) {
+	if time.Since(r.lastCleanup) < cleanupInterval {
+		return
+	}
+	r.lastCleanup = time.Now()
+
+	before := time.Now().Add(-r.retention)
+	for ctx.Err() == nil {
+		var ids []uint64
+		err := r.db.WithContext(ctx).Table(r.table).
+			Where("status = ? AND sent_at < ?", StatusSent, before).
+			Limit(cleanupBatch).Pluck("id", &ids).Error
+		if err == nil && len(ids) > 0 {
+			err = r.db.WithContext(ctx).Table(r.table).Where("id IN ?", ids).Delete(&Record{}).Error
+		}
+		if err != nil {
+			r.logger.Errorf("outbox relay: cleanup: %+v", err)
+			return
+		}
+		if len(ids) < cleanupBatch {
+			return
+		}
+	}
+}
+
+func (r *Relay) lockKey() string {
+	return "mq:outbox:relay:" + r.table
+}
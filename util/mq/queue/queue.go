@@ -1,12 +1,15 @@
 package queue
 
+import "context"
+
 type Queue interface {
 	Topic() string
 	GroupId() string
 	Enqueue() []Job
 }
 
+// Job 队列任务，ctx 携带消费者 span、消息元数据与日志，见 mq.MetadataFromContext、mq.LoggerFromContext
 type Job interface {
 	Name() string
-	Execute([]byte) error
+	Execute(ctx context.Context, data []byte) error
 }
@@ -0,0 +1,297 @@
+package redisstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"go-framework/util/helper"
+	"go-framework/util/mq"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	payloadField = "payload"
+
+	defaultPrefix     = "mq:"
+	defaultMaxLen     = 100000
+	defaultClaimIdle  = 60 * time.Second
+	defaultRetryDelay = 3 * time.Second
+
+	readBlock     = 2 * time.Second
+	moveInterval  = time.Second
+	moveBatchSize = 100
+)
+
+// moveScript 将到期的延时消息原子地移入流
+var moveScript = redis.NewScript(`
+local items = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, item in ipairs(items) do
+	redis.call('XADD', KEYS[2], 'MAXLEN', '~', ARGV[3], '*', 'payload', item)
+	redis.call('ZREM', KEYS[1], item)
+end
+return #items
+`)
+
+var _ mq.Driver = (*Driver)(nil)
+
+// Config redis streams 配置
+type Config struct {
+	Redis      string `json:"redis"`       // 使用的 redis 别名，默认 default
+	Prefix     string `json:"prefix"`      // 键前缀，默认 mq:
+	MaxLen     int64  `json:"max_len"`     // 流的近似最大长度，默认 100000
+	ClaimIdle  int    `json:"claim_idle"`  // 未确认的消息超过该时间（秒）后由其他消费者认领，默认 60
+	RetryDelay int    `json:"retry_delay"` // 消费失败后重新投递的间隔（毫秒），默认 3000
+}
+
+// Driver redis streams 驱动
+//
+// 主题对应名为 "前缀+主题" 的流，消费组对应流的 consumer group，新建的消费组从流的开头消费。
+// 延时消息写入 "前缀+主题:delayed" 有序集合，到期后移入流；消费失败的消息写入消费组自己的
+// "前缀+主题:retry:消费组:delayed" 有序集合，到期后移入只由该消费组消费的 "前缀+主题:retry:消费组" 流，
+// 不会重复投递给其他消费组；消费者宕机未确认的消息由其他消费者认领。
+type Driver struct {
+	client     *redis.Client
+	prefix     string
+	maxLen     int64
+	claimIdle  time.Duration
+	retryDelay time.Duration
+}
+
+type payload struct {
+	ID      string            `json:"id"`
+	Key     string            `json:"key,omitempty"`
+	Headers map[string]string `json:"headers"`
+	Body    []byte            `json:"body"`
+}
+
+// New 创建 redis streams 驱动
+func New(client *redis.Client, c interface{}) (*Driver, error) {
+	var conf Config
+	if err := helper.UnMarshalWithInterface(c, &conf); err != nil {
+		return nil, fmt.Errorf("redis stream config error: %w", err)
+	}
+	if client == nil {
+		return nil, errors.New("redis stream config error: redis client is nil")
+	}
+
+	d := &Driver{
+		client:     client,
+		prefix:     conf.Prefix,
+		maxLen:     conf.MaxLen,
+		claimIdle:  time.Duration(conf.ClaimIdle) * time.Second,
+		retryDelay: time.Duration(conf.RetryDelay) * time.Millisecond,
+	}
+	if d.prefix == "" {
+		d.prefix = defaultPrefix
+	}
+	if d.maxLen <= 0 {
+		d.maxLen = defaultMaxLen
+	}
+	if d.claimIdle <= 0 {
+		d.claimIdle = defaultClaimIdle
+	}
+	if d.retryDelay <= 0 {
+		d.retryDelay = defaultRetryDelay
+	}
+	return d, nil
+}
+
+// Publish 发送消息，延时消息写入有序集合
+func (d *Driver) Publish(ctx context.Context, msg *mq.Message) error {
+	data, err := encode(msg)
+	if err != nil {
+		return err
+	}
+	if msg.Delay > 0 {
+		return d.client.ZAdd(ctx, d.delayedKey(msg.Topic), &redis.Z{
+			Score:  float64(time.Now().Add(msg.Delay).UnixMilli()),
+			Member: data,
+		}).Err()
+	}
+	return d.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: d.streamKey(msg.Topic),
+		MaxLen: d.maxLen,
+		Approx: true,
+		Values: []interface{}{payloadField, data},
+	}).Err()
+}
+
+// Subscribe 订阅主题，处理失败的消息写入消费组的重试集合后确认
+func (d *Driver) Subscribe(ctx context.Context, topic string, opts mq.SubscribeOptions, handler mq.Handler) error {
+	// 从流的开头创建消费组，消费组首次订阅之前发送的消息也会被消费
+	for _, stream := range []string{d.streamKey(topic), d.retryStreamKey(topic, opts.Group)} {
+		err := d.client.XGroupCreateMkStream(ctx, stream, opts.Group, "0").Err()
+		if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			return err
+		}
+	}
+
+	moved := make(chan struct{})
+	go func() {
+		defer close(moved)
+		d.moveDelayed(ctx, topic, opts.Group)
+	}()
+
+	err := mq.Consume(ctx, opts.Concurrency, func(i int, handleCtx context.Context) error {
+		consumer := fmt.Sprintf("%s-%d-%d", hostname(), os.Getpid(), i)
+		return d.consume(ctx, handleCtx, topic, opts.Group, consumer, handler)
+	})
+	<-moved
+	return err
+}
+
+func (d *Driver) consume(ctx, handleCtx context.Context, topic, group, consumer string, handler mq.Handler) error {
+	streams := []string{d.streamKey(topic), d.retryStreamKey(topic, group)}
+	lastClaim := time.Now()
+
+	for ctx.Err() == nil {
+		if time.Since(lastClaim) >= d.claimIdle {
+			lastClaim = time.Now()
+			for _, stream := range streams {
+				messages, _, err := d.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+					Stream:   stream,
+					Group:    group,
+					MinIdle:  d.claimIdle,
+					Start:    "0-0",
+					Count:    10,
+					Consumer: consumer,
+				}).Result()
+				if err != nil && ctx.Err() == nil {
+					return err
+				}
+				for _, message := range messages {
+					d.handle(handleCtx, topic, stream, group, message, handler)
+				}
+			}
+		}
+
+		result, err := d.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    group,
+			Consumer: consumer,
+			Streams:  []string{streams[0], streams[1], ">", ">"},
+			Count:    1,
+			Block:    readBlock,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		for _, s := range result {
+			for _, message := range s.Messages {
+				d.handle(handleCtx, topic, s.Stream, group, message, handler)
+			}
+		}
+	}
+	return ctx.Err()
+}
+
+// handle 处理 stream 中的消息，stream 为主题的流或消费组的重试流
+func (d *Driver) handle(ctx context.Context, topic, stream, group string, message redis.XMessage, handler mq.Handler) {
+	data, _ := message.Values[payloadField].(string)
+	var p payload
+	if err := helper.UmMarshal([]byte(data), &p); err != nil {
+		// 无法解析的消息直接确认，避免反复投递
+		d.client.XAck(ctx, stream, group, message.ID)
+		return
+	}
+
+	delivery := &mq.Delivery{
+		Message:  mq.Message{Topic: topic, Key: p.Key, Body: p.Body, Headers: p.Headers},
+		ID:       message.ID,
+		Attempts: mq.Attempts(p.Headers),
+	}
+	if err := handler(ctx, delivery); err == nil {
+		d.client.XAck(ctx, stream, group, message.ID)
+		return
+	}
+
+	retry, err := encode(mq.Retry(delivery))
+	if err != nil {
+		return
+	}
+	// 重试消息只写入当前消费组的重试集合，与确认在同一事务中执行，失败时消息保持未确认，由认领流程再次投递
+	_, _ = d.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, d.retryDelayedKey(topic, group), &redis.Z{
+			Score:  float64(time.Now().Add(d.retryDelay).UnixMilli()),
+			Member: retry,
+		})
+		pipe.XAck(ctx, stream, group, message.ID)
+		return nil
+	})
+}
+
+// moveDelayed 定期将到期的延时消息移入主题的流，将消费组到期的重试消息移入消费组的重试流
+func (d *Driver) moveDelayed(ctx context.Context, topic, group string) {
+	ticker := time.NewTicker(moveInterval)
+	defer ticker.Stop()
+
+	keys := [][]string{
+		{d.delayedKey(topic), d.streamKey(topic)},
+		{d.retryDelayedKey(topic, group), d.retryStreamKey(topic, group)},
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+			for _, k := range keys {
+				_ = moveScript.Run(ctx, d.client, k, now, moveBatchSize, d.maxLen).Err()
+			}
+		}
+	}
+}
+
+// Ping 检查 redis 连接
+func (d *Driver) Ping(ctx context.Context) error {
+	return d.client.Ping(ctx).Err()
+}
+
+// Close redis 客户端由 xredis 管理，此处无需关闭
+func (d *Driver) Close() error {
+	return nil
+}
+
+func (d *Driver) streamKey(topic string) string {
+	return d.prefix + topic
+}
+
+func (d *Driver) delayedKey(topic string) string {
+	return d.prefix + topic + ":delayed"
+}
+
+func (d *Driver) retryStreamKey(topic, group string) string {
+	return d.prefix + topic + ":retry:" + group
+}
+
+func (d *Driver) retryDelayedKey(topic, group string) string {
+	return d.retryStreamKey(topic, group) + ":delayed"
+}
+
+func encode(msg *mq.Message) (string, error) {
+	data, err := helper.Marshal(payload{
+		ID:      strconv.FormatInt(time.Now().UnixNano(), 36) + strconv.FormatInt(rand.Int63(), 36),
+		Key:     msg.Key,
+		Headers: msg.Headers,
+		Body:    msg.Body,
+	})
+	return string(data), err
+}
+
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return "consumer"
+	}
+	return name
+}
@@ -0,0 +1,68 @@
+package mq
+
+import (
+	"fmt"
+	"go-framework/util/mq/queue"
+)
+
+// QueueJob 任务及其所属队列
+type QueueJob struct {
+	Queue queue.Queue
+	Job   queue.Job
+}
+
+// Registry 队列与任务注册表，任务名称全局唯一
+type Registry struct {
+	queues map[string]queue.Queue
+	jobs   map[string]*QueueJob
+	order  []string
+}
+
+// NewRegistry 创建注册表
+func NewRegistry() *Registry {
+	return &Registry{
+		queues: make(map[string]queue.Queue),
+		jobs:   make(map[string]*QueueJob),
+	}
+}
+
+// Add 注册队列及其任务，主题或任务名称重复时返回错误
+func (r *Registry) Add(queues ...queue.Queue) error {
+	for _, q := range queues {
+		if r.queues[q.Topic()] != nil {
+			return fmt.Errorf("mq: %s queue already registered", q.Topic())
+		}
+		for _, job := range q.Enqueue() {
+			if _, exists := r.jobs[job.Name()]; exists {
+				return fmt.Errorf("mq: duplicate job found for key %s", job.Name())
+			}
+		}
+
+		r.queues[q.Topic()] = q
+		r.order = append(r.order, q.Topic())
+		for _, job := range q.Enqueue() {
+			r.jobs[job.Name()] = &QueueJob{Queue: q, Job: job}
+		}
+	}
+	return nil
+}
+
+// Queue 按主题查找队列
+func (r *Registry) Queue(topic string) queue.Queue {
+	return r.queues[topic]
+}
+
+// Queues 按注册顺序返回所有队列
+func (r *Registry) Queues() []queue.Queue {
+	queues := make([]queue.Queue, 0, len(r.order))
+	for _, topic := range r.order {
+		queues = append(queues, r.queues[topic])
+	}
+	return queues
+}
+
+// Job 按任务名称查找任务
+func (r *Registry) Job(name string) (*QueueJob, bool) {
+	job, ok := r.jobs[name]
+	return job, ok
+}
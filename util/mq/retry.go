@@ -0,0 +1,73 @@
+package mq
+
+import (
+	"go-framework/util/mq/queue"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy 重试策略，任务执行失败后由中间件按策略重新发送延时消息
+type RetryPolicy interface {
+	// Next 第 attempts 次执行失败后的重试间隔，返回 false 时不再重试，消息进入死信队列
+	Next(attempts int) (time.Duration, bool)
+}
+
+// RetryableJob 自定义重试策略的任务，优先于订阅时设置的策略
+type RetryableJob interface {
+	RetryPolicy() RetryPolicy
+}
+
+// FixedRetry 固定间隔重试
+type FixedRetry struct {
+	Delay       time.Duration // 重试间隔
+	MaxAttempts int           // 最大执行次数（含首次），小于 1 时不重试
+}
+
+// Next 未达到最大执行次数时按固定间隔重试
+func (r FixedRetry) Next(attempts int) (time.Duration, bool) {
+	if attempts >= r.MaxAttempts {
+		return 0, false
+	}
+	return r.Delay, true
+}
+
+// ExponentialRetry 指数退避重试，间隔为 Base * Multiplier^(attempts-1)，不超过 Max
+type ExponentialRetry struct {
+	Base        time.Duration // 首次重试间隔
+	Max         time.Duration // 最大重试间隔，为 0 时不限制
+	Multiplier  float64       // 增长倍数，默认 2
+	Jitter      float64       // 随机抖动比例（0~1），间隔在 [d*(1-Jitter), d] 之间随机
+	MaxAttempts int           // 最大执行次数（含首次），小于 1 时不重试
+}
+
+// Next 未达到最大执行次数时按指数退避计算重试间隔
+func (r ExponentialRetry) Next(attempts int) (time.Duration, bool) {
+	if attempts >= r.MaxAttempts {
+		return 0, false
+	}
+	multiplier := r.Multiplier
+	if multiplier <= 1 {
+		multiplier = 2
+	}
+
+	delay := float64(r.Base) * math.Pow(multiplier, float64(attempts-1))
+	if r.Max > 0 && delay > float64(r.Max) {
+		delay = float64(r.Max)
+	}
+	if r.Jitter > 0 {
+		jitter := math.Min(r.Jitter, 1)
+		delay -= delay * jitter * rand.Float64()
+	}
+	return time.Duration(delay), true
+}
+
+// JobRetryPolicy 任务的重试策略，任务未实现 RetryableJob 时使用 fallback
+func JobRetryPolicy(job queue.Job, fallback RetryPolicy) RetryPolicy {
+	if j, ok := job.(RetryableJob); ok {
+		if policy := j.RetryPolicy(); policy != nil {
+			return policy
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,73 @@
+package rocketmq
+
+import (
+	"context"
+	"fmt"
+	"go-framework/util/mq"
+	"go-framework/util/mq/queue"
+	"time"
+)
+
+var (
+	_ mq.Broker           = (*Client)(nil)
+	_ mq.ConsumerProvider = (*Client)(nil)
+)
+
+// Publish 发送原始消息
+func (c *Client) Publish(ctx context.Context, msg *mq.Message) error {
+	return c.Producer.Publish(ctx, msg)
+}
+
+// SendJobMessage 发送任务消息
+func (c *Client) SendJobMessage(ctx context.Context, job queue.Job, msg interface{}, opts ...mq.SendOption) error {
+	return c.Producer.SendJobMessage(ctx, job, msg, opts...)
+}
+
+// SendJobDelayMessage 发送延时任务消息
+func (c *Client) SendJobDelayMessage(ctx context.Context, job queue.Job, msg interface{}, delay time.Duration, opts ...mq.SendOption) error {
+	return c.Producer.SendJobDelayMessage(ctx, job, msg, delay, opts...)
+}
+
+// JobMessage 编码任务消息
+func (c *Client) JobMessage(job queue.Job, msg interface{}) (*mq.Message, error) {
+	return c.Decoder.Marshal(job, msg)
+}
+
+// Subscribe 订阅队列
+func (c *Client) Subscribe(q queue.Queue, opts ...mq.ConsumeOption) error {
+	o := mq.NewConsumeOptions(opts...)
+	if _, ok := c.Consumer(q.Topic()); ok {
+		return fmt.Errorf("rocketmq: queue %s is already subscribed", q.Topic())
+	}
+	ConsumerMessage(c, q, WithConcurrency(o.Concurrency), WithMaxConcurrency(o.MaxConcurrency), WithPaused(o.Paused),
+		WithRetryTimes(o.RetryTimes), WithRetryPolicy(o.RetryPolicy), WithOrdered(o.Ordered, o.OrderedFailure))
+	return nil
+}
+
+// Consumer 主题的消费者
+func (c *Client) Consumer(topic string) (mq.ConsumerControl, bool) {
+	c.consumerLock.Lock()
+	defer c.consumerLock.Unlock()
+	for _, consumer := range c.consumers {
+		if consumer.queue.Topic() == topic {
+			return consumer, true
+		}
+	}
+	return nil, false
+}
+
+// Consumers 所有订阅中的消费者
+func (c *Client) Consumers() []mq.ConsumerControl {
+	c.consumerLock.Lock()
+	defer c.consumerLock.Unlock()
+	consumers := make([]mq.ConsumerControl, 0, len(c.consumers))
+	for _, consumer := range c.consumers {
+		consumers = append(consumers, consumer)
+	}
+	return consumers
+}
+
+// Close http 协议无长连接，无需释放
+func (c *Client) Close() error {
+	return nil
+}
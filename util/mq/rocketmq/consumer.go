@@ -4,12 +4,13 @@ import (
 	"context"
 	"fmt"
 	mq_http_sdk "github.com/aliyunmq/mq-http-go-sdk"
-	"github.com/go-redsync/redsync/v4"
 	"github.com/gogap/errors"
 	"github.com/panjf2000/ants/v2"
 	"go-framework/util/helper"
-	"go-framework/util/locker"
+	"go-framework/util/metrics"
+	"go-framework/util/mq"
 	"go-framework/util/mq/queue"
+	"go.opentelemetry.io/otel/trace"
 	"runtime"
 	"strings"
 	"sync"
@@ -18,9 +19,13 @@ import (
 
 const (
 	RetryTimeKeyFormat = "retryTime:%s:%s"
-	RockTimeKeyFormat  = "rock:%s:%s"
+
+	// maxPullBatch 单次拉取的最大消息数，受 http 接口限制
+	maxPullBatch = 16
 )
 
+var _ mq.ConsumerControl = (*Consumer)(nil)
+
 var NotAskBuffer []mq_http_sdk.ConsumeMessageEntry
 
 type Consumer struct {
@@ -32,33 +37,84 @@ type Consumer struct {
 	askBufferLock    sync.Mutex
 	batchAskInterval time.Duration
 	concurrency      int
+	maxConcurrency   int
+	paused           bool
+	flow             *mq.FlowControl
 	retryTimes       int64
+	retryPolicy      mq.RetryPolicy
+	ordered          bool
+	orderedFailure   mq.OrderedFailure
+	lanes            map[string][]mq_http_sdk.ConsumeMessageEntry
+	batches          sync.WaitGroup
+	laneLock         sync.Mutex
+	ctx              context.Context
+	cancel           context.CancelFunc
+	done             chan struct{}
+	drained          chan struct{}
+	stopOnce         sync.Once
 }
 
 type ConsumerOption func(consumer *Consumer)
 
+// WithOrdered 顺序消费：按分区顺序拉取消息，同一 sharding key 的消息串行处理，不同 sharding key 并行处理，
+// 未设置 sharding key 的消息全部串行处理。失败的消息不确认，由 mq 在下次可见时间按顺序重新投递，
+// 期间同一分区的后续消息不会被拉取；onFailure 为超过重试策略后的处理方式
+func WithOrdered(ordered bool, onFailure mq.OrderedFailure) ConsumerOption {
+	return func(consumer *Consumer) {
+		consumer.ordered = ordered
+		consumer.orderedFailure = onFailure
+	}
+}
+
 func WithConcurrency(concurrency int) ConsumerOption {
 	return func(consumer *Consumer) {
 		consumer.concurrency = concurrency
 	}
 }
 
+// WithMaxConcurrency 设置运行时可调整的并发上限，0 表示不限
+func WithMaxConcurrency(maxConcurrency int) ConsumerOption {
+	return func(consumer *Consumer) {
+		consumer.maxConcurrency = maxConcurrency
+	}
+}
+
+// WithPaused 启动后暂停拉取消息
+func WithPaused(paused bool) ConsumerOption {
+	return func(consumer *Consumer) {
+		consumer.paused = paused
+	}
+}
+
 func WithRetryTimes(retryTimes int64) ConsumerOption {
 	return func(consumer *Consumer) {
 		consumer.retryTimes = retryTimes
 	}
 }
 
+// WithRetryPolicy 设置重试策略，失败的消息按策略重新发送延时消息
+func WithRetryPolicy(policy mq.RetryPolicy) ConsumerOption {
+	return func(consumer *Consumer) {
+		consumer.retryPolicy = policy
+	}
+}
+
 func ConsumerMessage(client *Client, queue queue.Queue, opts ...ConsumerOption) {
 	consumer := &Consumer{
 		client:           client,
 		queue:            queue,
 		batchAskInterval: time.Millisecond * 200,
+		lanes:            make(map[string][]mq_http_sdk.ConsumeMessageEntry),
+		done:             make(chan struct{}),
+		drained:          make(chan struct{}),
 	}
 	for _, opt := range opts {
 		opt(consumer)
 	}
 
+	consumer.ctx, consumer.cancel = context.WithCancel(context.Background())
+	consumer.flow = mq.NewFlowControl(consumer.concurrency, consumer.maxConcurrency, consumer.paused)
+
 	var err error
 	consumer.pool, err = ants.NewPool(consumer.concurrency)
 	if err != nil {
@@ -66,6 +122,7 @@ func ConsumerMessage(client *Client, queue queue.Queue, opts ...ConsumerOption)
 	}
 	groupId := client.GetGroupNameByGroupId(queue.GroupId())
 	consumer.consumer = client.Client().GetConsumer(client.conf.MQ.Namespace, queue.Topic(), groupId, "")
+	client.addConsumer(consumer)
 
 	go consumer.ConsumerMessage()
 	return
@@ -89,24 +146,171 @@ func (c *Consumer) StartProcessing(respChan chan mq_http_sdk.ConsumeMessageRespo
 
 	for resp := range respChan {
 		for _, msg := range resp.Messages {
+			if c.ordered {
+				wg.Add(1)
+				c.dispatchOrdered(msg, &wg)
+				continue
+			}
+			// 拉取后被暂停或调低并发时等待，停止时未执行的消息不确认，由 mq 重新投递
+			if err := c.flow.Acquire(c.ctx); err != nil {
+				continue
+			}
 			wg.Add(1)
 			msgCopy := msg
-			_ = c.pool.Submit(func() {
+			err := c.pool.Submit(func() {
 				defer wg.Done()
+				defer c.flow.Release()
 				c.processMessage(msgCopy)
 			})
+			if err != nil {
+				wg.Done()
+				c.flow.Release()
+				c.notify("消息id: %s 提交执行失败: %+v", msg.MessageId, err)
+			}
 		}
 	}
 
 	wg.Wait()
-	c.sendBatchAsk() // Send remaining asks if any
+	c.batches.Wait()
+	close(c.drained) // 所有已拉取的消息处理完毕，剩余确认由 Stop 统一刷出
+}
+
+// dispatchOrdered 将消息加入所属 sharding key 的队列，队列未在处理时启动处理协程
+func (c *Consumer) dispatchOrdered(msg mq_http_sdk.ConsumeMessageEntry, wg *sync.WaitGroup) {
+	key := msg.ShardingKey
+	c.laneLock.Lock()
+	pending, running := c.lanes[key]
+	c.lanes[key] = append(pending, msg)
+	c.laneLock.Unlock()
+	if !running {
+		go c.runLane(key, wg)
+	}
+}
+
+// runLane 按顺序处理 sharding key 队列中的消息，消息开始执行时才占用并发，队列中等待的消息不占用。
+// 消息未确认时放弃队列中的后续消息，由 mq 按顺序重新投递，队列为空时退出
+func (c *Consumer) runLane(key string, wg *sync.WaitGroup) {
+	for {
+		c.laneLock.Lock()
+		pending := c.lanes[key]
+		if len(pending) == 0 {
+			delete(c.lanes, key)
+			c.laneLock.Unlock()
+			return
+		}
+		msg := pending[0]
+		c.laneLock.Unlock()
+
+		acked := false
+		if err := c.flow.Acquire(c.ctx); err == nil {
+			acked = c.processMessage(msg)
+			c.flow.Release()
+		}
+
+		c.laneLock.Lock()
+		if !acked {
+			for range c.lanes[key] {
+				wg.Done()
+			}
+			delete(c.lanes, key)
+			c.laneLock.Unlock()
+			return
+		}
+		c.lanes[key] = c.lanes[key][1:]
+		c.laneLock.Unlock()
+		wg.Done()
+	}
+}
+
+// Stop 停止拉取消息，等待处理中的消息执行完毕并刷出待确认的消息
+func (c *Consumer) Stop(ctx context.Context) error {
+	c.stopOnce.Do(func() {
+		close(c.done)
+		c.cancel()
+	})
+
+	select {
+	case <-c.drained:
+	case <-ctx.Done():
+		return fmt.Errorf("topic %s: wait for in-flight messages: %w", c.queue.Topic(), ctx.Err())
+	}
+
+	defer c.pool.Release()
+	return c.flushAsk(ctx)
+}
+
+// flushAsk 循环确认缓冲区中的消息，直到缓冲区为空或超时
+func (c *Consumer) flushAsk(ctx context.Context) error {
+	for c.askBufferLen() > 0 {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("topic %s: %d messages not acked: %w", c.queue.Topic(), c.askBufferLen(), ctx.Err())
+		default:
+		}
+		c.sendBatchAsk()
+	}
+	return nil
+}
+
+func (c *Consumer) askBufferLen() int {
+	c.askBufferLock.Lock()
+	defer c.askBufferLock.Unlock()
+	return len(c.askBuffer)
+}
+
+// Pause 暂停拉取与执行新消息，处理中的消息继续完成
+func (c *Consumer) Pause() {
+	c.flow.Pause()
+}
+
+// Resume 恢复消费
+func (c *Consumer) Resume() {
+	c.flow.Resume()
 }
 
-// pullMessage 获取消息
+// SetConcurrency 调整并发数
+func (c *Consumer) SetConcurrency(concurrency int) error {
+	if err := c.flow.SetConcurrency(concurrency); err != nil {
+		return err
+	}
+	c.pool.Tune(concurrency)
+	return nil
+}
+
+// State 运行状态
+func (c *Consumer) State() mq.ConsumerState {
+	return c.flow.State(mq.ConsumerState{
+		Broker:    mq.BrokerRocketMQ,
+		Topic:     c.queue.Topic(),
+		Group:     c.client.GetGroupName(c.queue.Topic()),
+		AckBuffer: c.askBufferLen(),
+	})
+}
+
+// pullMessage 获取消息，按空闲并发数拉取，暂停或并发已满时停止拉取
 func (c *Consumer) pullMessage(respChan chan mq_http_sdk.ConsumeMessageResponse, errChan chan error) {
+	defer close(errChan)
+	defer close(respChan)
+
 	for {
-		c.consumer.ConsumeMessage(respChan, errChan, 16, 30)
-		time.Sleep(time.Millisecond * 500)
+		free, err := c.flow.Wait(c.ctx)
+		if err != nil {
+			return
+		}
+		if free > maxPullBatch {
+			free = maxPullBatch
+		}
+		if c.ordered {
+			c.consumer.ConsumeMessageOrderly(respChan, errChan, int32(free), 30)
+		} else {
+			c.consumer.ConsumeMessage(respChan, errChan, int32(free), 30)
+		}
+
+		select {
+		case <-c.done:
+			return
+		case <-time.After(time.Millisecond * 500):
+		}
 	}
 
 	//job := &job.OrderJob{}
@@ -140,91 +344,237 @@ func (c *Consumer) pullMessage(respChan chan mq_http_sdk.ConsumeMessageResponse,
 //	return messages
 //}
 
-// processMessage 处理消息
-func (c *Consumer) processMessage(message mq_http_sdk.ConsumeMessageEntry) {
+// consumeMessage 已开始处理的消息
+type consumeMessage struct {
+	message  mq_http_sdk.ConsumeMessageEntry
+	ctx      context.Context
+	span     trace.Span
+	delivery *mq.Delivery
+	task     queue.Job
+	payload  *mq.Payload
+	record   *mq.ProcessedRecord
+}
+
+// processMessage 处理消息。设置了重试策略的任务失败后重新发送延时消息，
+// 否则不确认由 mq 重新投递，超过重试次数后写入死信队列。
+// 消息在执行成功、重试消息发送成功或写入死信队列后才确认，失败时由 mq 重新投递。
+// 非顺序消费时批量任务加入批次后返回，不占用消费并发，批次执行后确认。返回消息是否已确认
+func (c *Consumer) processMessage(message mq_http_sdk.ConsumeMessageEntry) (acked bool) {
 	defer helper.RecoverPanic(c.client.Logger)
 
+	m, acked := c.prepare(message)
+	if m == nil {
+		return acked
+	}
+
+	start := time.Now()
+	if !c.ordered {
+		c.batches.Add(1)
+		submitted := c.client.batchers.Submit(m.ctx, m.task, m.payload, func(err error) {
+			defer c.batches.Done()
+			defer helper.RecoverPanic(c.client.Logger)
+			c.complete(m, c.observe(m.task, m.payload, start, err))
+		})
+		if submitted {
+			return false
+		}
+		c.batches.Done()
+	}
+	return c.complete(m, c.observe(m.task, m.payload, start, c.taskExecute(m.ctx, m.task, m.payload)))
+}
+
+// prepare 解析消息并获取处理记录，消息无需执行时返回 nil 与是否已确认
+func (c *Consumer) prepare(message mq_http_sdk.ConsumeMessageEntry) (*consumeMessage, bool) {
 	groupName := c.client.GetGroupName(c.queue.Topic())
-	if message.Properties["groupId"] != groupName {
-		return
+	if message.Properties[groupIdProperty] != groupName {
+		return nil, false
 	}
 
-	rockKey := c.GetRockKey(c.queue.Topic(), message.MessageId)
 	retryTimesKey := c.GetRetryTimesKey(c.queue.Topic(), message.MessageId)
+	times := c.client.redisClient.Incr(context.Background(), retryTimesKey).Val()
+	c.client.redisClient.Expire(context.Background(), retryTimesKey, time.Second*600)
+
+	// 重试策略重新发送的消息通过属性记录投递次数，mq 重新投递的消息通过 redis 计数
+	delivery := c.delivery(message, mq.Attempts(decodeProperties(message.Properties))+int(times)-1)
+	if delivery.Attempts > 1 {
+		metrics.MQRetryTotal.WithLabelValues(c.queue.Topic()).Inc()
+	}
+
+	md := &mq.Metadata{
+		ID:       message.MessageId,
+		Topic:    c.queue.Topic(),
+		Group:    groupName,
+		Job:      delivery.Headers[mq.HeaderJob],
+		Key:      message.MessageKey,
+		Attempts: delivery.Attempts,
+		Headers:  delivery.Headers,
+	}
+	ctx, span := mq.StartConsume(context.Background(), mq.BrokerRocketMQ, md, c.client.Logger)
+	m := &consumeMessage{message: message, ctx: ctx, span: span, delivery: delivery}
 
-	// 为300秒，超时会导致重复消费，Http协议，该时间不支持配置修改，
-	// 放置最前面，放置其他调用类等情况的致命异常
-	mutex := locker.NewMutex(c.client.redisClient)
-	err := mutex.Lock(rockKey, redsync.WithExpiry(time.Second*600))
+	if !c.client.Decoder.Check(message.MessageBody, delivery.Headers) {
+		return nil, c.settle(m, true, nil)
+	}
+	task, payload, err := c.client.Decoder.UnMarshal(message.MessageBody, delivery.Headers)
 	if err != nil {
-		c.notify("key: %s 消费id：%s，消息重复消费: %+v", rockKey, message.MessageId, err)
+		c.notify("消息反序列化失败: %+v", err)
+		return nil, c.settle(m, c.deadLetter(ctx, delivery, "", err), err)
+	}
+	md.Job = task.Name()
+	m.task, m.payload = task, payload
 
-		return
+	// 已处理成功的消息直接确认，正由其他消费者处理的消息不确认，超过租约后由重新投递的消息接管
+	record, ok := c.begin(ctx, groupName, task, payload, delivery)
+	if !ok {
+		return nil, c.settle(m, record.State == mq.StateSucceeded, nil)
 	}
-	defer func() {
-		res, err2 := mutex.Unlock()
-		if !res || err2 != nil {
-			c.notify("key: %s 消费id：%s，解锁状态：%+v; 消息解锁失败: %+v", rockKey, message.MessageId, res, err2)
+	m.record = record
+	return m, false
+}
+
+// complete 记录执行结果，失败时按重试策略重新发送或写入死信队列，返回消息是否已确认
+func (c *Consumer) complete(m *consumeMessage, err error) bool {
+	c.end(m.ctx, m.record, err)
+	if err == nil {
+		return c.settle(m, true, nil)
+	}
+	if c.ordered {
+		return c.settle(m, c.failOrdered(m.ctx, m.delivery, m.task, err), err)
+	}
+
+	delivery := m.delivery
+	policy := mq.JobRetryPolicy(m.task, c.retryPolicy)
+	if policy == nil && int64(delivery.Attempts) <= c.retryTimes {
+		return c.settle(m, false, err)
+	}
+	delivery.Headers = mq.AppendHistory(delivery.Headers, delivery.Attempts, err)
+	if policy != nil {
+		if delay, ok := policy.Next(delivery.Attempts); ok {
+			return c.settle(m, c.retry(m.ctx, delivery, delay, m.task), err)
 		}
-	}()
+	}
+	return c.settle(m, c.deadLetter(m.ctx, delivery, m.task.Name(), err), err)
+}
 
-	times := c.client.redisClient.Incr(context.Background(), retryTimesKey).Val()
-	c.client.redisClient.Expire(context.Background(), retryTimesKey, time.Second*600)
+// settle 按 acked 确认消息并结束 span，err 为任务执行的错误
+func (c *Consumer) settle(m *consumeMessage, acked bool, err error) bool {
+	if acked {
+		c.ack(m.message)
+	}
+	mq.EndSpan(m.span, err)
+	return acked
+}
 
-	isAsk := true
-	if times > c.retryTimes {
-		c.addAskBuffer(message, &isAsk)
+// failOrdered 顺序消费失败的消息不原地重试也不重新发送，未超过重试策略时不确认，由 mq 按顺序重新投递；
+// OrderedSkip 超过重试策略后写入死信队列，返回是否确认
+func (c *Consumer) failOrdered(ctx context.Context, delivery *mq.Delivery, task queue.Job, err error) bool {
+	retry := mq.OrderedRetry{Policy: mq.JobRetryPolicy(task, c.retryPolicy), RetryTimes: c.retryTimes, OnFailure: c.orderedFailure}
+	if _, ok := retry.Next(delivery.Attempts, 0); ok {
+		return false
 	}
+	delivery.Headers = mq.AppendHistory(delivery.Headers, delivery.Attempts, err)
+	return c.deadLetter(ctx, delivery, task.Name(), err)
+}
 
-	msgBody := message.MessageBody
+// observe 记录任务执行的指标与处理结果，start 为开始处理的时间，返回 err
+func (c *Consumer) observe(task queue.Job, payload *mq.Payload, start time.Time, err error) error {
+	metrics.MQConsumeDuration.WithLabelValues(c.queue.Topic()).Observe(time.Since(start).Seconds())
+	c.flow.Record(err)
+	if err != nil {
+		c.notify("%s 消息消费失败,参数：%s, 执行失败: %+v", task.Name(), payload.Body, err)
+		metrics.MQConsumeTotal.WithLabelValues(c.queue.Topic(), metrics.ResultFailed).Inc()
+		return err
+	}
+	metrics.MQConsumeTotal.WithLabelValues(c.queue.Topic(), metrics.ResultSuccess).Inc()
+	return nil
+}
 
-	if c.client.Decoder.Check(msgBody) {
-		task, msgBodyByte, err := c.client.Decoder.UnMarshal(msgBody)
-		if err != nil {
-			c.notify("消息反序列化失败: %+v", err)
-		}
+// begin 获取消息处理记录，返回 false 时消息已处理成功或正由其他消费者处理
+func (c *Consumer) begin(ctx context.Context, group string, task queue.Job, payload *mq.Payload, delivery *mq.Delivery) (*mq.ProcessedRecord, bool) {
+	if c.client.idempotency == nil {
+		return nil, true
+	}
+	record, ok, err := c.client.idempotency.Begin(ctx, group, task, payload, delivery)
+	if err != nil {
+		c.notify("%s 消息id: %s 获取处理记录失败，继续执行: %+v", task.Name(), delivery.ID, err)
+		return nil, true
+	}
+	if !ok {
+		c.client.Logger.Infof("队列消息跳过：%s 消息id: %s 处理记录 %s 状态 %s", task.Name(), delivery.ID, record.Key, record.State)
+	}
+	return record, ok
+}
 
-		if c.retryTimes == 0 {
-			c.addAskBuffer(message, &isAsk)
-		}
+// end 记录处理结果
+func (c *Consumer) end(ctx context.Context, record *mq.ProcessedRecord, err error) {
+	if record == nil {
+		return
+	}
+	if e := c.client.idempotency.End(ctx, record, err); e != nil {
+		c.notify("处理记录 %s 更新失败: %+v", record.Key, e)
+	}
+}
 
-		// 捕获panic
-		var isError bool
-		c.taskExecute(task, msgBodyByte, &isError)
-		if isError {
-			isAsk = false
-		}
+func (c *Consumer) delivery(message mq_http_sdk.ConsumeMessageEntry, attempts int) *mq.Delivery {
+	return &mq.Delivery{
+		Message: mq.Message{
+			Topic:   c.queue.Topic(),
+			Key:     message.MessageKey,
+			Body:    []byte(message.MessageBody),
+			Headers: decodeProperties(message.Properties),
+		},
+		ID:       message.MessageId,
+		Attempts: attempts,
 	}
+}
 
-	c.addAskBuffer(message, &isAsk)
+// retry 按重试策略重新发送延时消息，返回是否发送成功，发送失败时原消息不确认，由 mq 重新投递
+func (c *Consumer) retry(ctx context.Context, delivery *mq.Delivery, delay time.Duration, task queue.Job) bool {
+	msg := mq.Retry(delivery)
+	msg.Delay = delay
+	if err := c.client.Producer.Publish(ctx, msg); err != nil {
+		c.notify("%s 重试消息发送失败: %+v", task.Name(), err)
+		return false
+	}
+	return true
+}
+
+// deadLetter 写入死信队列，未配置死信队列时丢弃，返回 false 时写入失败，消息不确认
+func (c *Consumer) deadLetter(ctx context.Context, delivery *mq.Delivery, job string, err error) bool {
+	metrics.MQDeadLetterTotal.WithLabelValues(c.queue.Topic()).Inc()
+	if c.client.deadLetters == nil {
+		c.notify("消息id: %s 超过重试次数，已丢弃", delivery.ID)
+		return true
+	}
+
+	groupName := c.client.GetGroupName(c.queue.Topic())
+	dl := mq.NewDeadLetter(delivery, groupName, job, err)
+	if e := c.client.deadLetters.Push(ctx, dl); e != nil {
+		c.notify("消息id: %s 写入死信队列失败: %+v, 消息内容: %s", delivery.ID, e, delivery.Body)
+		return false
+	}
+	c.notify("消息id: %s 超过重试次数，已写入死信队列 %s：%s", delivery.ID, mq.DeadLetterTopic(c.queue.Topic()), dl.ID)
+	return true
 }
 
-func (c *Consumer) taskExecute(task queue.Job, msgBodyByte []byte, isError *bool) {
+func (c *Consumer) taskExecute(ctx context.Context, task queue.Job, payload *mq.Payload) (err error) {
 	defer func() {
-		if err := recover(); err != nil {
-			*isError = true
-			c.notify("消息执行失败: %+v", err)
+		if p := recover(); p != nil {
 			buf := make([]byte, 2048)
 			n := runtime.Stack(buf, false)
-			c.notify("【任务执行异常】\n 错误内容：\n%s", buf[:n])
+			c.notify("【任务执行异常】\n 错误内容：%+v\n%s", p, buf[:n])
+			err = &mq.PanicError{Value: p, Stack: buf[:n]}
 		}
 	}()
-	err := task.Execute(msgBodyByte)
-	if err != nil {
-		*isError = true
-		c.notify("%s 消息消费失败,参数：%s, 执行失败: %+v", task.Name(), string(msgBodyByte), err)
-	}
+	// 顺序消费时批量任务逐条执行
+	return c.client.batchers.Execute(ctx, task, payload, 1)
 }
 
-func (c *Consumer) addAskBuffer(message mq_http_sdk.ConsumeMessageEntry, isAsk *bool) {
-	if !*isAsk {
-		NotAskBuffer = append(NotAskBuffer, message)
-		return
-	}
-	defer c.askBufferLock.Unlock()
+// ack 加入待确认缓冲区，由定时任务批量确认
+func (c *Consumer) ack(message mq_http_sdk.ConsumeMessageEntry) {
 	c.askBufferLock.Lock()
+	defer c.askBufferLock.Unlock()
 	c.askBuffer = append(c.askBuffer, message)
-	*isAsk = false
 }
 
 func (c *Consumer) batchAskTimer() {
@@ -235,6 +585,8 @@ func (c *Consumer) batchAskTimer() {
 		select {
 		case <-timer.C:
 			c.sendBatchAsk()
+		case <-c.drained:
+			return
 		}
 	}
 }
@@ -262,6 +614,7 @@ func (c *Consumer) sendBatchAsk() {
 	}
 	ackErr := c.consumer.AckMessage(receiptHandle)
 	if ackErr != nil {
+		metrics.MQAckTotal.WithLabelValues(c.queue.Topic(), metrics.ResultFailed).Add(float64(askBufferLen))
 		// 某些消息的句柄可能超时，会导致消息消费状态确认不成功。
 		if errAckItems, ok := ackErr.(errors.ErrCode).Context()["Detail"].([]mq_http_sdk.ErrAckItem); ok {
 			c.notify("消息id: %+v,确认消费失败: %+v", messageId, errAckItems)
@@ -272,6 +625,7 @@ func (c *Consumer) sendBatchAsk() {
 		return
 	}
 
+	metrics.MQAckTotal.WithLabelValues(c.queue.Topic(), metrics.ResultSuccess).Add(float64(askBufferLen))
 	c.client.Logger.Infof("消息成功: %+v", messageId)
 	c.askBuffer = c.askBuffer[askBufferLen:]
 }
@@ -280,6 +634,7 @@ func (c *Consumer) errorHandler(errChan chan error) {
 	for err := range errChan {
 		// 消费出现异常
 		if !strings.Contains(err.(errors.ErrCode).Error(), "MessageNotExist") {
+			c.flow.RecordError(err)
 			c.notify("消费消息失败: %+v", err)
 		}
 	}
@@ -289,10 +644,6 @@ func (c *Consumer) GetRetryTimesKey(topic string, message string) string {
 	return fmt.Sprintf(RetryTimeKeyFormat, topic, message)
 }
 
-func (c *Consumer) GetRockKey(topic string, message string) string {
-	return fmt.Sprintf(RockTimeKeyFormat, topic, message)
-}
-
 func (c *Consumer) notify(format string, a ...any) {
 	message := fmt.Sprintf("【队列消费】: %s", fmt.Sprintf(format, a...))
 	c.client.ErrorNotify(context.Background(), message)
@@ -1,9 +1,13 @@
 package rocketmq
 
-import "go-framework/util/mq/queue"
+import (
+	"go-framework/util/mq"
+	"go-framework/util/mq/queue"
+)
 
+// Decoder 任务消息编解码，编码信息写入消息属性
 type Decoder interface {
-	Marshal(job queue.Job, msg interface{}) (string, string, error)
-	UnMarshal(msg string) (queue.Job, []byte, error)
-	Check(msg string) bool
+	Marshal(job queue.Job, msg interface{}) (*mq.Message, error)
+	UnMarshal(body string, properties map[string]string) (queue.Job, *mq.Payload, error)
+	Check(body string, properties map[string]string) bool
 }
@@ -1,9 +1,12 @@
 package rocketmq
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"go-framework/util/helper"
+	"go-framework/util/mq"
+	"go-framework/util/mq/codec"
 	"go-framework/util/mq/queue"
 	"strings"
 )
@@ -16,38 +19,60 @@ func NewJobDecoder(client *Client) *JobDecoder {
 	return &JobDecoder{client: client}
 }
 
-func (jd *JobDecoder) Marshal(job queue.Job, msg interface{}) (string, string, error) {
-	var msgData MsgData
-	jobName := job.Name()
-	queueJob := jd.client.Jobs[jobName]
-	if queueJob.Queue == nil {
-		return "", "", errors.New("queue is nil")
+// Marshal 编码任务消息，任务名称、内容类型与版本写入消息属性，非 JSON 消息体以 base64 编码
+func (jd *JobDecoder) Marshal(job queue.Job, msg interface{}) (*mq.Message, error) {
+	queueJob := jd.client.Jobs[job.Name()]
+	if queueJob == nil || queueJob.Queue == nil {
+		return nil, errors.New("queue is nil")
 	}
-	queueInfo := jd.client.Jobs[jobName].Queue
-
-	topic := queueInfo.Topic()
-	groupId := queueInfo.GroupId()
-	msgData.Topic = topic
-	msgData.GroupId = groupId
-	msgData.JobName = jobName
-	msgData.Data = msg
 
-	marshal, err := helper.Marshal(msgData)
+	payload, err := mq.EncodeJob(queueJob.Job, msg)
 	if err != nil {
-		return "", "", err
+		return nil, err
+	}
+	body := string(payload.Body)
+	if payload.ContentType != codec.ContentTypeJSON {
+		body = base64.StdEncoding.EncodeToString(payload.Body)
 	}
 
-	return topic, fmt.Sprintf("%s%s%s", QueueMark, Separate, string(marshal)), nil
+	headers := payload.Headers()
+	headers[mq.HeaderJob] = job.Name()
+	if key := mq.JobShardingKey(queueJob.Job, msg); key != "" {
+		headers[mq.HeaderShardingKey] = key
+	}
+	return &mq.Message{Topic: queueJob.Queue.Topic(), Body: []byte(body), Headers: headers}, nil
 }
 
-func (jd *JobDecoder) Check(msg string) bool {
-	return strings.Contains(msg, QueueMark+Separate)
+// Check 是否为任务消息，兼容 "app_name@" 前缀的旧格式消息
+func (jd *JobDecoder) Check(body string, properties map[string]string) bool {
+	return properties[mq.HeaderJob] != "" || strings.Contains(body, QueueMark+Separate)
 }
 
-func (jd *JobDecoder) UnMarshal(msg string) (queue.Job, []byte, error) {
-	if msg == "" {
+func (jd *JobDecoder) UnMarshal(body string, properties map[string]string) (queue.Job, *mq.Payload, error) {
+	if body == "" {
 		return nil, nil, fmt.Errorf("msg is empty")
 	}
+	if properties[mq.HeaderJob] == "" {
+		return jd.unMarshalLegacy(body)
+	}
+
+	queueJob := jd.client.Jobs[properties[mq.HeaderJob]]
+	if queueJob == nil || queueJob.Queue == nil {
+		return nil, nil, errors.New("queue is nil")
+	}
+	payload := mq.NewPayload(properties, []byte(body))
+	if payload.ContentType != "" && payload.ContentType != codec.ContentTypeJSON {
+		data, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return nil, nil, err
+		}
+		payload.Body = data
+	}
+	return queueJob.Job, payload, nil
+}
+
+// unMarshalLegacy 解析 "app_name@{MsgData}" 格式的旧消息
+func (jd *JobDecoder) unMarshalLegacy(msg string) (queue.Job, *mq.Payload, error) {
 	subIndex := strings.Index(msg, Separate)
 	body := msg[subIndex+1:]
 	var msgData MsgData
@@ -64,11 +89,9 @@ func (jd *JobDecoder) UnMarshal(msg string) (queue.Job, []byte, error) {
 	}
 
 	queueJob := jd.client.Jobs[msgData.JobName]
-	if queueJob.Queue == nil {
+	if queueJob == nil || queueJob.Queue == nil {
 		return nil, nil, errors.New("queue is nil")
 	}
 
-	job := jd.client.Jobs[msgData.JobName].Job
-
-	return job, res, nil
+	return queueJob.Job, &mq.Payload{ContentType: codec.ContentTypeJSON, Body: res}, nil
 }
@@ -2,15 +2,22 @@ package rocketmq
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	mq_http_sdk "github.com/aliyunmq/mq-http-go-sdk"
 	"go-framework/util/helper"
+	"go-framework/util/mq"
 	"go-framework/util/mq/queue"
+	"strings"
 	"time"
 )
 
 const (
 	GroupId = ""
+
+	groupIdProperty = "groupId"
+	// encodedPrefix 含有属性不支持的特殊字符时，属性值以 base64 编码并添加该前缀
+	encodedPrefix = "b64."
 )
 
 type Producer struct {
@@ -24,25 +31,52 @@ func NewProducer(client *Client) *Producer {
 }
 
 // SendJobMessage 发送任务消息
-func (p *Producer) SendJobMessage(ctx context.Context, job queue.Job, msg interface{}) error {
-	topic, msgd, err := p.client.Decoder.Marshal(job, msg)
+func (p *Producer) SendJobMessage(ctx context.Context, job queue.Job, msg interface{}, opts ...mq.SendOption) error {
+	message, err := p.client.Decoder.Marshal(job, msg)
 	if err != nil {
 		p.client.Logger.Errorf("SendJobMessage marshal job error, %v", err)
 		return err
 	}
+	mq.NewSendOptions(opts...).Apply(message)
 
-	return p.sendMessage(ctx, topic, GroupId, msgd)
+	return p.Publish(ctx, message)
 }
 
 // SendJobDelayMessage 发送延时任务消息
-func (p *Producer) SendJobDelayMessage(ctx context.Context, job queue.Job, msg interface{}, duration time.Duration) error {
-	topic, msgd, err := p.client.Decoder.Marshal(job, msg)
+func (p *Producer) SendJobDelayMessage(ctx context.Context, job queue.Job, msg interface{}, duration time.Duration, opts ...mq.SendOption) error {
+	message, err := p.client.Decoder.Marshal(job, msg)
 	if err != nil {
 		p.client.Logger.Errorf("SendJobDelayMessage marshal Decoder job error, %v", err)
 		return err
 	}
+	mq.NewSendOptions(opts...).Apply(message)
+	message.Delay = duration
 
-	return p.sendDelayMessage(ctx, topic, GroupId, msgd, duration)
+	return p.Publish(ctx, message)
+}
+
+// Publish 发送原始消息，消息头写入消息属性，分区键同时写入 sharding key
+func (p *Producer) Publish(ctx context.Context, msg *mq.Message) error {
+	msgRequest, err := p.publishMessageRequest(msg.Topic, GroupId, string(msg.Body))
+	if err != nil {
+		return err
+	}
+	for k, v := range msg.Headers {
+		if k != groupIdProperty {
+			msgRequest.Properties[k] = encodeProperty(v)
+		}
+	}
+	if msg.Key != "" {
+		msgRequest.MessageKey = msg.Key
+	}
+	if key := msg.ShardingKey(); key != "" {
+		msgRequest.ShardingKey = key
+	}
+	if msg.Delay > 0 {
+		msgRequest.StartDeliverTime = time.Now().Add(msg.Delay).UnixMilli()
+	}
+
+	return p.publishMessage(ctx, msgRequest, msg.Topic)
 }
 
 // SendMessage 发送消息
@@ -88,10 +122,17 @@ func (p *Producer) sendDelayMessage(ctx context.Context, topic string, groupId s
 	return err
 }
 
-// publishMessage 发送消息
+// publishMessage 发送消息，消息属性写入链路上下文、请求 ID 与发送时间
 func (p *Producer) publishMessage(ctx context.Context, msgRequest mq_http_sdk.PublishMessageRequest, topic string) error {
+	msg := &mq.Message{Topic: topic, Headers: decodeProperties(msgRequest.Properties)}
+	ctx, span := mq.StartPublish(ctx, mq.BrokerRocketMQ, msg)
+	for k, v := range msg.Headers {
+		msgRequest.Properties[k] = encodeProperty(v)
+	}
+
 	producer := p.client.Client().GetProducer(p.client.conf.MQ.Namespace, topic)
 	res, err := producer.PublishMessage(msgRequest)
+	mq.EndSpan(span, err)
 
 	p.log(ctx, msgRequest, res, err)
 	return err
@@ -107,7 +148,7 @@ func (p *Producer) publishMessageRequest(topic, groupId string, msg string) (mq_
 	if groupId == "" {
 		groupId = topic
 	}
-	msgRequest.Properties["groupId"] = p.client.GetGroupName(groupId)
+	msgRequest.Properties[groupIdProperty] = p.client.GetGroupName(groupId)
 	return msgRequest, nil
 }
 
@@ -119,3 +160,25 @@ func (p *Producer) log(ctx context.Context, msgRequest mq_http_sdk.PublishMessag
 		p.client.Logger.Infof("队列发送成功：请求数据：\n%+v \n, 返回数据：\n%+v \n", msgRequest, msgResponse)
 	}
 }
+
+// encodeProperty 编码含有特殊字符的属性值
+func encodeProperty(v string) string {
+	if !mq_http_sdk.ContainsSpecialChar(v) {
+		return v
+	}
+	return encodedPrefix + base64.RawURLEncoding.EncodeToString([]byte(v))
+}
+
+// decodeProperties 复制消息属性并解码属性值
+func decodeProperties(properties map[string]string) map[string]string {
+	decoded := make(map[string]string, len(properties))
+	for k, v := range properties {
+		if strings.HasPrefix(v, encodedPrefix) {
+			if data, err := base64.RawURLEncoding.DecodeString(v[len(encodedPrefix):]); err == nil {
+				v = string(data)
+			}
+		}
+		decoded[k] = v
+	}
+	return decoded
+}
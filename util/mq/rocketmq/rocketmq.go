@@ -2,13 +2,17 @@ package rocketmq
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	mq_http_sdk "github.com/aliyunmq/mq-http-go-sdk"
 	"github.com/go-redis/redis/v8"
-	"go-framework/internal/common/tool/dingtalk_tool"
 	"go-framework/util/helper"
+	"go-framework/util/mq"
+	"go-framework/util/mq/idempotent"
 	"go-framework/util/mq/queue"
 	"go-framework/util/xlog"
+	"net/http"
+	"sync"
 )
 
 type clientHandler func(client *Client)
@@ -37,24 +41,43 @@ type Client struct {
 	Logger       *xlog.Log
 	Producer     *Producer
 	redisClient  *redis.Client
-	dingtalkTool *dingtalk_tool.Dingtalk
+	notifier     mq.Notifier
+	deadLetters  mq.DeadLetterQueue
+	idempotency  *mq.Idempotency
+	batchers     mq.Batchers
 	queues       map[string]queue.Queue
 	Jobs         map[string]*QueueJob
 	Decoder      Decoder
+	consumers    []*Consumer
+	consumerLock sync.Mutex
 }
 
 func NewClient(c interface{}, logger *xlog.Log, redisClient *redis.Client, fs ...clientHandler) (client *Client) {
+	client, err := New(c, logger, redisClient, fs...)
+	if err != nil {
+		logger.Panicf("%v", err)
+	}
+	return client
+}
+
+// New 创建客户端，配置或任务注册异常时返回错误
+func New(c interface{}, logger *xlog.Log, redisClient *redis.Client, fs ...clientHandler) (*Client, error) {
 	var conf *mqConf
 	err := helper.UnMarshalWithInterface(c, &conf)
 	if err != nil {
-		logger.Panicf("rocketmq config error: %v", err)
+		return nil, fmt.Errorf("rocketmq config error: %w", err)
+	}
+	if len(conf.MQ.Endpoint) == 0 {
+		return nil, errors.New("rocketmq config error: endpoint is empty")
 	}
-	client = &Client{
+	client := &Client{
 		conf:        conf,
 		Logger:      logger,
 		redisClient: redisClient,
 		queues:      make(map[string]queue.Queue),
 		Jobs:        make(map[string]*QueueJob),
+		// 默认以 redis 记录消息处理状态，避免重复消费
+		idempotency: mq.NewIdempotency(idempotent.NewRedis(redisClient, ""), 0, 0),
 	}
 
 	for _, f := range fs {
@@ -65,11 +88,11 @@ func NewClient(c interface{}, logger *xlog.Log, redisClient *redis.Client, fs ..
 
 	err = client.RegisterJob()
 	if err != nil {
-		client.Logger.Panicf("register job error: %v", err)
+		return nil, fmt.Errorf("register job error: %w", err)
 	}
 
 	client.Decoder = NewJobDecoder(client)
-	return
+	return client, nil
 }
 
 // ConsumerRun 启动消费者
@@ -77,21 +100,71 @@ func (c *Client) ConsumerRun(handler func(client *Client)) {
 	handler(c)
 }
 
-func (c *Client) SetNotifier(dingtalkTool *dingtalk_tool.Dingtalk) {
-	c.dingtalkTool = dingtalkTool
+func (c *Client) addConsumer(consumer *Consumer) {
+	c.consumerLock.Lock()
+	defer c.consumerLock.Unlock()
+	c.consumers = append(c.consumers, consumer)
+}
+
+// Shutdown 停止所有消费者，等待处理中的消息完成并确认
+func (c *Client) Shutdown(ctx context.Context) error {
+	c.consumerLock.Lock()
+	consumers := c.consumers
+	c.consumerLock.Unlock()
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(consumers))
+	for i, consumer := range consumers {
+		wg.Add(1)
+		go func(i int, consumer *Consumer) {
+			defer wg.Done()
+			errs[i] = consumer.Stop(ctx)
+		}(i, consumer)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// SetNotifier 设置告警通知
+func (c *Client) SetNotifier(notifier mq.Notifier) {
+	c.notifier = notifier
+}
+
+// SetDeadLetterQueue 设置死信队列，未设置时超过重试次数的消息被丢弃
+func (c *Client) SetDeadLetterQueue(deadLetters mq.DeadLetterQueue) {
+	c.deadLetters = deadLetters
+}
+
+// SetIdempotency 设置消费幂等，默认使用 redis 记录消息处理状态
+func (c *Client) SetIdempotency(idempotency *mq.Idempotency) {
+	c.idempotency = idempotency
 }
 
 func (c *Client) ErrorNotify(ctx context.Context, message string) {
 	c.Logger.Errorf(message)
-	if c.dingtalkTool == nil {
+	if c.notifier == nil {
 		return
 	}
-	err := c.dingtalkTool.SendAlarm(ctx, fmt.Sprintf("告警信息：\n%s\n", message))
+	err := c.notifier.SendAlarm(ctx, fmt.Sprintf("告警信息：\n%s\n", message))
 	if err != nil {
 		c.Logger.Errorf("【队列】钉钉机器人发送失败: %+v", err)
 	}
 }
 
+// Ping 检查 MQ 接入点是否可达，收到任意 HTTP 响应即视为可达
+func (c *Client) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.conf.MQ.Endpoint[0], nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
 // Client 创建阿里云客户端
 func (c *Client) Client() mq_http_sdk.MQClient {
 	client := mq_http_sdk.NewAliyunMQClient(c.conf.MQ.Endpoint[0], c.conf.MQ.AccessKey, c.conf.MQ.SecretKey, "")
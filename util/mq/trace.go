@@ -0,0 +1,169 @@
+package mq
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/segmentio/ksuid"
+	"go-framework/util/tracer"
+	"go-framework/util/xlog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"strconv"
+	"time"
+)
+
+const (
+	// HeaderRequestID 消息头：请求 ID，在消息链路中保持不变
+	HeaderRequestID = "x-request-id"
+	// HeaderPublishTime 消息头：发送时间（毫秒时间戳）
+	HeaderPublishTime = "x-publish-time"
+
+	instrumentationName = "go-framework/util/mq"
+)
+
+// propagator 消息头中传递 traceparent 与 baggage，不依赖是否初始化了全局 propagator
+var propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+type (
+	metadataKey  struct{}
+	loggerKey    struct{}
+	requestIDKey struct{}
+)
+
+// Metadata 消费中的消息元数据
+type Metadata struct {
+	ID          string
+	Topic       string
+	Group       string
+	Job         string
+	Key         string
+	Attempts    int // 第几次投递，从 1 开始
+	RequestID   string
+	PublishTime time.Time // 发送时间，消息头缺失时为零值
+	Headers     map[string]string
+}
+
+// MetadataFromContext 获取任务执行时 ctx 中的消息元数据
+func MetadataFromContext(ctx context.Context) (*Metadata, bool) {
+	md, ok := ctx.Value(metadataKey{}).(*Metadata)
+	return md, ok
+}
+
+// LoggerFromContext 获取任务执行时 ctx 中携带消息 ID 与 trace_id 的日志，不存在时返回 nil
+func LoggerFromContext(ctx context.Context) *xlog.Log {
+	logger, _ := ctx.Value(loggerKey{}).(*xlog.Log)
+	return logger
+}
+
+// WithRequestID 设置发送消息时写入消息头的请求 ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext 获取请求 ID，消费中的消息沿用消息头中的请求 ID
+func RequestIDFromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(requestIDKey{}).(string); ok && id != "" {
+		return id
+	}
+	if md, ok := MetadataFromContext(ctx); ok {
+		return md.RequestID
+	}
+	return ""
+}
+
+// StartPublish 创建生产者 span，并将链路上下文、请求 ID 与发送时间写入消息头
+func StartPublish(ctx context.Context, system string, msg *Message) (context.Context, trace.Span) {
+	// gin 的链路上下文保存在 Request 中
+	if c, ok := ctx.(*gin.Context); ok {
+		ctx = c.Request.Context()
+	}
+	ctx, span := otel.Tracer(instrumentationName).Start(ctx, msg.Topic+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", system),
+			attribute.String("messaging.operation", "publish"),
+			attribute.String("messaging.destination.name", msg.Topic),
+		))
+
+	if msg.Headers == nil {
+		msg.Headers = make(map[string]string)
+	}
+	propagator.Inject(ctx, propagation.MapCarrier(msg.Headers))
+	// 重放的消息沿用原请求 ID
+	requestID := RequestIDFromContext(ctx)
+	if requestID == "" {
+		requestID = msg.Headers[HeaderRequestID]
+	}
+	if requestID == "" {
+		requestID = ksuid.New().String()
+	}
+	msg.Headers[HeaderRequestID] = requestID
+	msg.Headers[HeaderPublishTime] = strconv.FormatInt(time.Now().UnixMilli(), 10)
+	return ctx, span
+}
+
+// ExtractContext 从保存的消息头恢复链路上下文与请求 ID，用于延后发送的消息（如 outbox）
+func ExtractContext(ctx context.Context, headers map[string]string) context.Context {
+	ctx = propagator.Extract(ctx, propagation.MapCarrier(headers))
+	if requestID := headers[HeaderRequestID]; requestID != "" {
+		ctx = WithRequestID(ctx, requestID)
+	}
+	return ctx
+}
+
+// StartConsume 从消息头提取链路上下文并创建关联生产者的消费者 span，
+// 返回的 ctx 携带 span、消息元数据与日志，业务代码可通过 tracer.Span 创建子 span
+func StartConsume(ctx context.Context, system string, md *Metadata, logger *xlog.Log) (context.Context, trace.Span) {
+	parent := propagator.Extract(ctx, propagation.MapCarrier(md.Headers))
+	var opts []trace.SpanStartOption
+	if sc := trace.SpanContextFromContext(parent); sc.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: sc}))
+	}
+	md.RequestID = md.Headers[HeaderRequestID]
+	if ms, err := strconv.ParseInt(md.Headers[HeaderPublishTime], 10, 64); err == nil {
+		md.PublishTime = time.UnixMilli(ms)
+	}
+
+	t := otel.Tracer(instrumentationName)
+	ctx, span := t.Start(parent, md.Topic+" process", append(opts,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", system),
+			attribute.String("messaging.operation", "process"),
+			attribute.String("messaging.destination.name", md.Topic),
+			attribute.String("messaging.consumer.group.name", md.Group),
+			attribute.String("messaging.message.id", md.ID),
+			attribute.String("messaging.job", md.Job),
+			attribute.Int("messaging.delivery.attempts", md.Attempts),
+		))...)
+	ctx = context.WithValue(ctx, tracer.TracerKey, t)
+	ctx = context.WithValue(ctx, metadataKey{}, md)
+
+	if logger != nil {
+		fields := []zap.Field{
+			zap.String("topic", md.Topic),
+			zap.String("message_id", md.ID),
+			zap.String("job", md.Job),
+			zap.Int("attempts", md.Attempts),
+			zap.String("request_id", md.RequestID),
+		}
+		if span.SpanContext().HasTraceID() {
+			fields = append(fields, zap.String("trace_id", span.SpanContext().TraceID().String()))
+		}
+		ctx = context.WithValue(ctx, loggerKey{}, logger.WithFields(fields...))
+	}
+	return ctx, span
+}
+
+// EndSpan 记录错误并结束 span
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,67 @@
+package mq_test
+
+import (
+	"context"
+	"errors"
+	"go-framework/util/mq"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"testing"
+	"time"
+)
+
+func TestTraceparentRoundTrip(t *testing.T) {
+	tp := sdktrace.NewTracerProvider()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prev)
+		_ = tp.Shutdown(context.Background())
+	})
+
+	type consumed struct {
+		span      trace.SpanContext
+		headers   map[string]string
+		requestID string
+	}
+	consumes := make(chan consumed, 2)
+	first := true
+	job := mq.NewJob("traced_order", func(ctx context.Context, o order) error {
+		md, _ := mq.MetadataFromContext(ctx)
+		consumes <- consumed{span: trace.SpanContextFromContext(ctx), headers: md.Headers, requestID: mq.RequestIDFromContext(ctx)}
+		// 第一次执行失败，重试消息同样携带链路上下文
+		if first {
+			first = false
+			return errors.New("retry")
+		}
+		return nil
+	})
+	b, q := newBroker(t, newMemoryDriver(t), job)
+	if err := b.Subscribe(q, mq.WithRetryPolicy(mq.FixedRetry{Delay: 10 * time.Millisecond, MaxAttempts: 2})); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "request")
+	defer span.End()
+	ctx = mq.WithRequestID(ctx, "request-1")
+	if err := mq.Dispatch(ctx, b, job, order{ID: "a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		c := receive(t, consumes)
+		if c.headers["traceparent"] == "" {
+			t.Fatalf("delivery %d has no traceparent header", i+1)
+		}
+		if c.span.TraceID() != span.SpanContext().TraceID() {
+			t.Errorf("delivery %d trace id = %s, want %s", i+1, c.span.TraceID(), span.SpanContext().TraceID())
+		}
+		if c.span.SpanID() == span.SpanContext().SpanID() {
+			t.Errorf("delivery %d reuses the producer span", i+1)
+		}
+		if c.requestID != "request-1" {
+			t.Errorf("delivery %d request id = %s, want request-1", i+1, c.requestID)
+		}
+	}
+}
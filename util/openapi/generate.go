@@ -0,0 +1,236 @@
+package openapi
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-framework/util/route"
+	"go-framework/util/xhttp"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	JSONPath = "/openapi.json"
+	UIPath   = "/swagger"
+
+	mimeJSON = "application/json"
+)
+
+var responderType = reflect.TypeOf((*xhttp.Responder)(nil)).Elem()
+
+// Generate 根据 gin 路由表生成文档。通过 route.Register 注册的类型化路由会带上请求参数与响应结构，
+// 其余路由仅包含路径参数与通用响应结构
+func Generate(routes gin.RoutesInfo, info Info) *Document {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]*PathItem),
+	}
+	b := newSchemaBuilder()
+
+	sorted := make(gin.RoutesInfo, len(routes))
+	copy(sorted, routes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Path != sorted[j].Path {
+			return sorted[i].Path < sorted[j].Path
+		}
+		return sorted[i].Method < sorted[j].Method
+	})
+
+	for _, ri := range sorted {
+		if ri.Path == JSONPath || ri.Path == UIPath {
+			continue
+		}
+		path, pathParams := convertPath(ri.Path)
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = &PathItem{}
+			doc.Paths[path] = item
+		}
+		setOperation(item, ri.Method, b.operation(ri.Method, ri.Path, pathParams))
+	}
+
+	doc.Components.Schemas = b.schemas
+	return doc
+}
+
+func (b *schemaBuilder) operation(method, fullPath string, pathParams []string) *Operation {
+	op := &Operation{
+		OperationID: operationID(method, fullPath),
+		Responses:   make(map[string]*Response),
+	}
+
+	var reqType, respType reflect.Type
+	if rt, ok := route.Lookup(method, fullPath); ok {
+		op.Summary = rt.Summary
+		op.Description = rt.Description
+		op.Tags = rt.Tags
+		reqType = rt.Handler.Request()
+		respType = rt.Handler.Response()
+	}
+
+	documented := make(map[string]bool)
+	if reqType != nil {
+		for reqType.Kind() == reflect.Ptr {
+			reqType = reqType.Elem()
+		}
+		if reqType.Kind() == reflect.Struct {
+			b.request(op, method, reqType, documented)
+		}
+	}
+	for _, name := range pathParams {
+		if documented[name] {
+			continue
+		}
+		op.Parameters = append(op.Parameters, &Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+
+	op.Responses["200"] = &Response{
+		Description: xhttp.SuccessMessage,
+		Content:     map[string]*MediaType{mimeJSON: {Schema: b.envelope(respType)}},
+	}
+	return op
+}
+
+// request uri 标签对应路径参数，form 标签对应查询参数，非 GET/DELETE/HEAD 请求的其余字段对应 JSON 请求体
+func (b *schemaBuilder) request(op *Operation, method string, t reflect.Type, documented map[string]bool) {
+	body := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	hasBody := method != http.MethodGet && method != http.MethodDelete && method != http.MethodHead
+
+	b.fields(t, func(f field) {
+		uri, form, jsonTag := f.tagName("uri"), f.tagName("form"), f.Tag.Get("json")
+		if uri != "" {
+			documented[uri] = true
+			op.Parameters = append(op.Parameters, &Parameter{
+				Name: uri, In: "path", Required: true,
+				Description: f.Tag.Get("label"), Schema: b.fieldSchema(f),
+			})
+		}
+		if form != "" {
+			op.Parameters = append(op.Parameters, &Parameter{
+				Name: form, In: "query", Required: f.required(),
+				Description: f.Tag.Get("label"), Schema: b.fieldSchema(f),
+			})
+		}
+		if !hasBody || (jsonTag == "" && (uri != "" || form != "")) {
+			return
+		}
+		name := f.jsonName()
+		if name == "" {
+			return
+		}
+		body.Properties[name] = b.fieldSchema(f)
+		if f.required() {
+			body.Required = append(body.Required, name)
+		}
+	})
+
+	if len(body.Properties) > 0 {
+		op.RequestBody = &RequestBody{
+			Required: len(body.Required) > 0,
+			Content:  map[string]*MediaType{mimeJSON: {Schema: body}},
+		}
+	}
+}
+
+// envelope xhttp.RespData 响应结构，分页数据（xhttp.Page）的 data 为列表并带有 meta.pagination
+func (b *schemaBuilder) envelope(respType reflect.Type) *Schema {
+	data := &Schema{}
+	var meta *Schema
+	if respType != nil {
+		if item, ok := pageItem(respType); ok {
+			data = &Schema{Type: "array", Items: b.schema(item)}
+			meta = &Schema{Type: "object", Properties: map[string]*Schema{"pagination": b.pagination()}}
+		} else {
+			data = b.schema(respType)
+		}
+	}
+
+	s := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"code":    {Type: "integer", Format: "int32", Description: "业务状态码"},
+			"status":  {Type: "integer", Format: "int32", Description: "错误状态码，成功时为 0"},
+			"message": {Type: "string", Description: "提示信息"},
+			"data":    data,
+		},
+		Required: []string{"code", "status", "message", "data"},
+	}
+	if meta != nil {
+		s.Properties["meta"] = meta
+		s.Required = append(s.Required, "meta")
+	}
+	return s
+}
+
+func (b *schemaBuilder) pagination() *Schema {
+	const name = "xhttp.Pagination"
+	if _, ok := b.schemas[name]; !ok {
+		integer := func(desc string) *Schema { return &Schema{Type: "integer", Format: "int32", Description: desc} }
+		b.schemas[name] = &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"count":      integer("当前页条数"),
+				"total":      integer("总条数"),
+				"page":       integer("当前页码"),
+				"page_size":  integer("每页条数"),
+				"total_page": integer("总页数"),
+			},
+			Required: []string{"count", "total", "page", "page_size", "total_page"},
+		}
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// pageItem 识别 xhttp.Page 形式的分页数据并返回列表元素类型
+func pageItem(t reflect.Type) (reflect.Type, bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || !reflect.PtrTo(t).Implements(responderType) {
+		return nil, false
+	}
+	list, ok := t.FieldByName("List")
+	if !ok || list.Type.Kind() != reflect.Slice {
+		return nil, false
+	}
+	return list.Type.Elem(), true
+}
+
+// convertPath 将 gin 的 :name 与 *name 路径参数转换为 {name}
+func convertPath(path string) (string, []string) {
+	var params []string
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if len(seg) > 1 && (seg[0] == ':' || seg[0] == '*') {
+			params = append(params, seg[1:])
+			segments[i] = "{" + seg[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+func operationID(method, path string) string {
+	replacer := strings.NewReplacer("/", "_", ":", "", "*", "", "-", "_", ".", "_")
+	return strings.ToLower(method) + strings.TrimRight(replacer.Replace(path), "_")
+}
+
+func setOperation(item *PathItem, method string, op *Operation) {
+	switch method {
+	case http.MethodGet:
+		item.Get = op
+	case http.MethodPut:
+		item.Put = op
+	case http.MethodPost:
+		item.Post = op
+	case http.MethodDelete:
+		item.Delete = op
+	case http.MethodOptions:
+		item.Options = op
+	case http.MethodHead:
+		item.Head = op
+	case http.MethodPatch:
+		item.Patch = op
+	}
+}
@@ -0,0 +1,32 @@
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+//go:embed swagger.html
+var swaggerHTML string
+
+// Register 注册文档接口 /openapi.json，swaggerUI 为 true 时同时注册 Swagger UI 页面 /swagger
+func Register(r gin.IRoutes, doc *Document, swaggerUI bool) error {
+	spec, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	r.GET(JSONPath, func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", spec)
+	})
+
+	if swaggerUI {
+		page := []byte(strings.Replace(swaggerHTML, "{{SPEC_URL}}", JSONPath, 1))
+		r.GET(UIPath, func(c *gin.Context) {
+			c.Data(http.StatusOK, "text/html; charset=utf-8", page)
+		})
+	}
+	return nil
+}
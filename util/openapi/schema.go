@@ -0,0 +1,242 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType        = reflect.TypeOf(time.Time{})
+	rawMessageType  = reflect.TypeOf(json.RawMessage{})
+	jsonMarshalType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// schemaBuilder 将 Go 类型转换为 Schema，具名结构体放入 components 并以 $ref 引用
+type schemaBuilder struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+func (b *schemaBuilder) schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: b.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Implements(jsonMarshalType) || reflect.PtrTo(t).Implements(jsonMarshalType) {
+			return &Schema{}
+		}
+		if t.Name() == "" {
+			return b.object(t)
+		}
+		return b.ref(t)
+	default:
+		return &Schema{}
+	}
+}
+
+func (b *schemaBuilder) ref(t reflect.Type) *Schema {
+	if name, ok := b.names[t]; ok {
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+
+	name := b.uniqueName(t)
+	b.names[t] = name
+	// 先占位再生成，避免自引用类型无限递归
+	b.schemas[name] = &Schema{}
+	*b.schemas[name] = *b.object(t)
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func (b *schemaBuilder) uniqueName(t reflect.Type) string {
+	name := t.Name()
+	if pkg := t.PkgPath(); pkg != "" {
+		name = pkg[strings.LastIndex(pkg, "/")+1:] + "." + name
+	}
+	// 泛型类型名包含完整包路径，替换为合法的组件名
+	name = strings.NewReplacer("[", "_", "]", "", "/", "_", "*", "", ",", "_", " ", "").Replace(name)
+
+	base, i := name, 1
+	for {
+		if _, ok := b.schemas[name]; !ok {
+			return name
+		}
+		i++
+		name = base + strconv.Itoa(i)
+	}
+}
+
+// object 按 json 标签生成结构体的属性，匿名嵌入的结构体字段展开到当前层级
+func (b *schemaBuilder) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	b.fields(t, func(f field) {
+		name := f.jsonName()
+		if name == "" {
+			return
+		}
+		prop := b.fieldSchema(f)
+		s.Properties[name] = prop
+		if f.required() {
+			s.Required = append(s.Required, name)
+		}
+	})
+	return s
+}
+
+func (b *schemaBuilder) fieldSchema(f field) *Schema {
+	prop := b.schema(f.Type)
+	// $ref 不允许同级属性，引用类型不附加描述与约束
+	if prop.Ref != "" {
+		return prop
+	}
+	prop.Description = f.Tag.Get("label")
+	applyBinding(prop, f.Tag.Get("binding"))
+	return prop
+}
+
+type field struct {
+	reflect.StructField
+}
+
+func (b *schemaBuilder) fields(t reflect.Type, fn func(field)) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous {
+			ft := f.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && f.Tag.Get("json") == "" {
+				b.fields(ft, fn)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		fn(field{f})
+	}
+}
+
+func (f field) jsonName() string {
+	return tagName(f.Tag.Get("json"), f.Name)
+}
+
+func (f field) tagName(key string) string {
+	return tagName(f.Tag.Get(key), "")
+}
+
+func (f field) required() bool {
+	for _, rule := range strings.Split(f.Tag.Get("binding"), ",") {
+		if rule == "required" {
+			return true
+		}
+	}
+	return false
+}
+
+func tagName(tag, fallback string) string {
+	if tag == "-" {
+		return ""
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		return fallback
+	}
+	return name
+}
+
+// applyBinding 将常用的 binding 校验规则映射为 Schema 约束
+func applyBinding(s *Schema, rules string) {
+	if rules == "" {
+		return
+	}
+	for _, rule := range strings.Split(rules, ",") {
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case "email":
+			s.Format = "email"
+		case "url", "uri":
+			s.Format = "uri"
+		case "uuid":
+			s.Format = "uuid"
+		case "oneof":
+			for _, v := range strings.Fields(value) {
+				s.Enum = append(s.Enum, v)
+			}
+		case "min", "gte":
+			setBound(s, value, true)
+		case "max", "lte":
+			setBound(s, value, false)
+		case "len":
+			setBound(s, value, true)
+			setBound(s, value, false)
+		}
+	}
+}
+
+func setBound(s *Schema, value string, lower bool) {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return
+	}
+	switch s.Type {
+	case "integer", "number":
+		if lower {
+			s.Minimum = &n
+		} else {
+			s.Maximum = &n
+		}
+	case "string":
+		i := int(n)
+		if lower {
+			s.MinLength = &i
+		} else {
+			s.MaxLength = &i
+		}
+	case "array":
+		i := int(n)
+		if lower {
+			s.MinItems = &i
+		} else {
+			s.MaxItems = &i
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package route
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"go-framework/util/xhttp"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+)
+
+type ginContextKey struct{}
+
+// Handle 创建类型化处理函数：绑定路径、查询与请求体参数到 Req，
+// 使用 binding.Validator 校验后调用 fn，返回值通过 xhttp.Data、错误通过 xhttp.Error 渲染，
+// 若 Resp 实现了 xhttp.Response 则直接渲染，实现了 xhttp.Responder（如 xhttp.Page）则按其转换结果渲染
+func Handle[Req, Resp any](fn func(ctx context.Context, req *Req) (*Resp, error)) Handler {
+	return Handler{
+		request:  reflect.TypeOf((*Req)(nil)).Elem(),
+		response: reflect.TypeOf((*Resp)(nil)).Elem(),
+		handle: func(c *gin.Context) {
+			req := new(Req)
+			if err := Bind(c, req); err != nil {
+				c.JSON(http.StatusOK, xhttp.Error(err))
+				return
+			}
+
+			ctx := context.WithValue(c.Request.Context(), ginContextKey{}, c)
+			resp, err := fn(ctx, req)
+			if err != nil {
+				c.JSON(http.StatusOK, xhttp.Error(err))
+				return
+			}
+
+			switch r := any(resp).(type) {
+			case xhttp.Response:
+				if resp != nil {
+					c.JSON(http.StatusOK, r)
+					return
+				}
+			case xhttp.Responder:
+				if resp != nil {
+					c.JSON(http.StatusOK, r.Response())
+					return
+				}
+			}
+			c.JSON(http.StatusOK, xhttp.Data(resp))
+		},
+	}
+}
+
+// GinContext 从类型化处理函数的 ctx 中取出 gin.Context，用于读写请求头等场景
+func GinContext(ctx context.Context) (*gin.Context, bool) {
+	c, ok := ctx.Value(ginContextKey{}).(*gin.Context)
+	return c, ok
+}
+
+// Bind 依次绑定路径参数（uri 标签）、查询参数（form 标签）与请求体，全部绑定后统一校验。
+// 请求体按 Content-Type 解析 JSON 或表单
+func Bind(c *gin.Context, obj any) error {
+	if len(c.Params) > 0 {
+		params := make(map[string][]string, len(c.Params))
+		for _, p := range c.Params {
+			params[p.Key] = []string{p.Value}
+		}
+		if err := binding.MapFormWithTag(obj, params, "uri"); err != nil {
+			return err
+		}
+	}
+
+	if err := binding.MapFormWithTag(obj, c.Request.URL.Query(), "form"); err != nil {
+		return err
+	}
+
+	if err := bindBody(c, obj); err != nil {
+		return err
+	}
+
+	if binding.Validator == nil {
+		return nil
+	}
+	return binding.Validator.ValidateStruct(obj)
+}
+
+func bindBody(c *gin.Context, obj any) error {
+	if c.Request.Body == nil || c.Request.ContentLength == 0 {
+		return nil
+	}
+
+	contentType, _, _ := mime.ParseMediaType(c.ContentType())
+	switch contentType {
+	case binding.MIMEJSON:
+		body, err := bodyBytes(c)
+		if err != nil {
+			return err
+		}
+		if len(body) == 0 {
+			return nil
+		}
+		return json.Unmarshal(body, obj)
+	case binding.MIMEPOSTForm, binding.MIMEMultipartPOSTForm:
+		if err := c.Request.ParseMultipartForm(32 << 20); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+			return err
+		}
+		return binding.MapFormWithTag(obj, c.Request.PostForm, "form")
+	default:
+		return nil
+	}
+}
+
+// bodyBytes 读取请求体并缓存到 gin.BodyBytesKey，与 ShouldBindBodyWith 共用缓存，避免中间件读取后无法再次读取
+func bodyBytes(c *gin.Context) ([]byte, error) {
+	if cb, ok := c.Get(gin.BodyBytesKey); ok {
+		if cbb, ok := cb.([]byte); ok {
+			return cbb, nil
+		}
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+	c.Set(gin.BodyBytesKey, body)
+	return body, nil
+}
@@ -0,0 +1,92 @@
+package route
+
+import (
+	"github.com/gin-gonic/gin"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var (
+	tableLock sync.RWMutex
+	table     = make(map[string]Route)
+)
+
+// Controller 控制器通过 Routes 声明自身的路由
+type Controller interface {
+	Routes() []Route
+}
+
+// Route 路由声明，Summary、Description 与 Tags 用于生成接口文档
+type Route struct {
+	Method      string
+	Path        string
+	Middlewares []gin.HandlerFunc
+	Handler     Handler
+	Summary     string
+	Description string
+	Tags        []string
+}
+
+// Handler 路由处理函数，通过 Handle 创建类型化处理函数，通过 Raw 包装 gin 原生处理函数
+type Handler struct {
+	handle   gin.HandlerFunc
+	request  reflect.Type
+	response reflect.Type
+}
+
+// Raw 包装 gin 原生处理函数，自行负责参数绑定与响应渲染
+func Raw(h gin.HandlerFunc) Handler {
+	return Handler{handle: h}
+}
+
+// Request 请求参数类型，Raw 处理函数为 nil
+func (h Handler) Request() reflect.Type {
+	return h.request
+}
+
+// Response 响应数据类型，Raw 处理函数为 nil
+func (h Handler) Response() reflect.Type {
+	return h.response
+}
+
+// Register 将控制器声明的路由注册到 gin 路由
+func Register(r gin.IRoutes, controllers ...Controller) {
+	for _, controller := range controllers {
+		for _, rt := range controller.Routes() {
+			if rt.Handler.handle == nil {
+				panic("route: " + rt.Method + " " + rt.Path + " has no handler")
+			}
+			handlers := make([]gin.HandlerFunc, 0, len(rt.Middlewares)+1)
+			handlers = append(handlers, rt.Middlewares...)
+			handlers = append(handlers, rt.Handler.handle)
+			r.Handle(rt.Method, rt.Path, handlers...)
+
+			rt.Path = joinPath(r, rt.Path)
+			tableLock.Lock()
+			table[rt.Method+" "+rt.Path] = rt
+			tableLock.Unlock()
+		}
+	}
+}
+
+// Lookup 按请求方法与完整路由路径查找已注册的路由声明
+func Lookup(method, fullPath string) (Route, bool) {
+	tableLock.RLock()
+	defer tableLock.RUnlock()
+	rt, ok := table[method+" "+fullPath]
+	return rt, ok
+}
+
+func joinPath(r gin.IRoutes, relativePath string) string {
+	group, ok := r.(interface{ BasePath() string })
+	if !ok {
+		return relativePath
+	}
+	finalPath := path.Join(group.BasePath(), relativePath)
+	if strings.HasSuffix(relativePath, "/") && !strings.HasSuffix(finalPath, "/") {
+		return finalPath + "/"
+	}
+	return finalPath
+}
@@ -2,6 +2,7 @@ package tracer
 
 import (
 	"context"
+	"fmt"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
@@ -15,7 +16,7 @@ import (
 )
 
 type Tracer struct {
-	closerFunc func()
+	provider *sdktrace.TracerProvider
 }
 
 const (
@@ -23,35 +24,41 @@ const (
 )
 
 func NewOpentelemetry(serviceName, env, endpoint, urlPath string) *Tracer {
+	t, err := New(serviceName, env, endpoint, urlPath)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	return t
+}
+
+// New 初始化链路追踪并设置为全局 TracerProvider，失败时返回错误
+func New(serviceName, env, endpoint, urlPath string) (*Tracer, error) {
 	ctx := context.Background()
 
-	var traceExporter *otlptrace.Exporter
-	var batchSpanProcessor sdktrace.SpanProcessor
+	_, batchSpanProcessor, err := newHTTPExporterAndSpanProcessor(ctx, endpoint, urlPath)
+	if err != nil {
+		return nil, err
+	}
 
-	traceExporter, batchSpanProcessor = newHTTPExporterAndSpanProcessor(ctx, endpoint, urlPath)
+	res, err := newResource(ctx, serviceName, env)
+	if err != nil {
+		return nil, err
+	}
 
 	traceProvider := sdktrace.NewTracerProvider(
 		sdktrace.WithSampler(sdktrace.AlwaysSample()),
-		sdktrace.WithResource(newResource(ctx, serviceName, env)),
+		sdktrace.WithResource(res),
 		sdktrace.WithSpanProcessor(batchSpanProcessor),
 	)
 
 	otel.SetTracerProvider(traceProvider)
 	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
 
-	return &Tracer{
-		closerFunc: func() {
-			cxt, cancel := context.WithTimeout(ctx, time.Second)
-			defer cancel()
-			if err := traceExporter.Shutdown(cxt); err != nil {
-				otel.Handle(err)
-			}
-		},
-	}
+	return &Tracer{provider: traceProvider}, nil
 }
 
 // 设置应用资源
-func newResource(ctx context.Context, serviceName, env string) *resource.Resource {
+func newResource(ctx context.Context, serviceName, env string) (*resource.Resource, error) {
 	hostName, _ := os.Hostname()
 
 	r, err := resource.New(
@@ -69,12 +76,12 @@ func newResource(ctx context.Context, serviceName, env string) *resource.Resourc
 	)
 
 	if err != nil {
-		log.Fatalf("%s: %v", "Failed to create OpenTelemetry resource", err)
+		return nil, fmt.Errorf("%s: %w", "Failed to create OpenTelemetry resource", err)
 	}
-	return r
+	return r, nil
 }
 
-func newHTTPExporterAndSpanProcessor(ctx context.Context, endpoint, urlPath string) (*otlptrace.Exporter, sdktrace.SpanProcessor) {
+func newHTTPExporterAndSpanProcessor(ctx context.Context, endpoint, urlPath string) (*otlptrace.Exporter, sdktrace.SpanProcessor, error) {
 	traceExporter, err := otlptrace.New(ctx, otlptracehttp.NewClient(
 		otlptracehttp.WithEndpoint(endpoint),
 		otlptracehttp.WithURLPath(urlPath),
@@ -82,16 +89,25 @@ func newHTTPExporterAndSpanProcessor(ctx context.Context, endpoint, urlPath stri
 		otlptracehttp.WithCompression(1)))
 
 	if err != nil {
-		log.Fatalf("%s: %v", "Failed to create the OpenTelemetry trace exporter", err)
+		return nil, nil, fmt.Errorf("%s: %w", "Failed to create the OpenTelemetry trace exporter", err)
 	}
 
 	batchSpanProcessor := sdktrace.NewBatchSpanProcessor(traceExporter)
 
-	return traceExporter, batchSpanProcessor
+	return traceExporter, batchSpanProcessor, nil
+}
+
+// Shutdown 刷出缓冲中的 span 并关闭导出器
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	return t.provider.Shutdown(ctx)
 }
 
 func (t *Tracer) Close() {
-	t.closerFunc()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := t.Shutdown(ctx); err != nil {
+		otel.Handle(err)
+	}
 }
 
 // InitOpenTelemetry OpenTelemetry 初始化方法
@@ -0,0 +1,24 @@
+package xhttp
+
+// Responder 可自行转换为统一响应结构的数据
+type Responder interface {
+	Response() Response
+}
+
+// Page 分页数据，作为类型化处理函数的返回值时按 Paginate 渲染
+type Page[T any] struct {
+	List     []T
+	Total    int
+	Page     int
+	PageSize int
+}
+
+// NewPage 创建分页数据
+func NewPage[T any](list []T, total, page, pageSize int) *Page[T] {
+	return &Page[T]{List: list, Total: total, Page: page, PageSize: pageSize}
+}
+
+// Response 转换为分页响应
+func (p *Page[T]) Response() Response {
+	return Paginate(p.List, p.Total, p.Page, p.PageSize)
+}
@@ -29,6 +29,11 @@ func (l *Log) With(a ...zap.Field) *Log {
 	return l
 }
 
+// WithFields 创建携带附加字段的日志实例，与 With 不同，不修改原实例
+func (l *Log) WithFields(fields ...zap.Field) *Log {
+	return &Log{Logger: l.Logger.With(fields...), filter: l.filter}
+}
+
 func (l *Log) Log(level Level, a ...any) {
 	l.Logger.Sugar().Log(zapcore.Level(level), l.translateFields(a...))
 }
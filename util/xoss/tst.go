@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/aliyun/alibaba-cloud-sdk-go/services/sts"
+	"go-framework/util/helper"
 	"time"
 )
 
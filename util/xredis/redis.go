@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/go-redis/redis/v8"
+	"go-framework/util/metrics"
 	"log"
 	"time"
 )
@@ -30,16 +31,30 @@ func (c *RedisClient) Conn(name string) *redis.Client {
 	return c.client[name]
 }
 
+// Clients 获取所有Redis客户端，key 为别名
+func (c *RedisClient) Clients() map[string]*redis.Client {
+	return c.client
+}
+
 // NewClient 初始化多个Redis客户端
 func NewClient(c interface{}) *RedisClient {
-	cByte, err := json.Marshal(c)
+	client, err := Open(c)
 	if err != nil {
 		panic(err)
 	}
+	return client
+}
+
+// Open 初始化多个Redis客户端，连接失败时关闭已建立的连接并返回错误
+func Open(c interface{}) (*RedisClient, error) {
+	cByte, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
 	var configs []Config
 	err = json.Unmarshal(cByte, &configs)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	clients := make(map[string]*redis.Client)
@@ -54,13 +69,16 @@ func NewClient(c interface{}) *RedisClient {
 			MinIdleConns: 3,
 			WriteTimeout: 3 * time.Second,
 		}
-		clients[v.Alias], err = connect(options)
+		client, err := connect(options)
 		if err != nil {
-			panic(err)
+			_ = (&RedisClient{client: clients}).Close()
+			return nil, fmt.Errorf("redis %s connect %s: %w", v.Alias, add, err)
 		}
+		client.AddHook(metrics.NewRedisHook(v.Alias))
+		clients[v.Alias] = client
 	}
 
-	return &RedisClient{client: clients}
+	return &RedisClient{client: clients}, nil
 }
 
 func connect(options *redis.Options) (*redis.Client, error) {
@@ -71,20 +89,23 @@ func connect(options *redis.Options) (*redis.Client, error) {
 	pong, err := client.Ping(ctx).Result()
 	if err != nil {
 		// 若某个实例连接失败，需根据需求决定是立即返回错误还是继续尝试连接其他实例
+		_ = client.Close()
 		return nil, err
 	}
 	if pong != "PONG" {
+		_ = client.Close()
 		return nil, errors.New("unexpected PONG response")
 	}
 	return client, nil
 }
 
-func (c *RedisClient) Close() {
-	for _, client := range c.client {
-		err := client.Close()
-		if err != nil {
-			fmt.Println(err)
-			return
+// Close 关闭所有Redis客户端
+func (c *RedisClient) Close() error {
+	var errs []error
+	for alias, client := range c.client {
+		if err := client.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("redis %s: %w", alias, err))
 		}
 	}
+	return errors.Join(errs...)
 }
@@ -21,6 +21,15 @@ type DBConfig struct {
 	MaxIdleConn  int      `json:"max_idle_conn"`
 	MaxOpenConn  int      `json:"max_open_conn"`
 	MaxLifeTime  int      `json:"max_life_time"`
+	Resolver     Resolver `json:"resolver"`
+}
+
+// Resolver 读写分离配置
+type Resolver struct {
+	Policy        string `json:"policy"`
+	Weights       []int  `json:"weights"`
+	HealthCheck   int    `json:"health_check"`
+	FailThreshold int    `json:"fail_threshold"`
 }
 
 func Marshal(v interface{}) []byte {
@@ -2,9 +2,12 @@ package databese
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"go-framework/util/types"
 	"go-framework/util/xsql/config"
+	"go-framework/util/xsql/resolver"
 	"go-framework/util/xsql/transaction"
 	"go.mongodb.org/mongo-driver/mongo"
 	"gorm.io/gorm"
@@ -13,42 +16,80 @@ import (
 type Engine struct {
 	Gorm  map[string]*gorm.DB
 	Mongo map[string]*mongo.Database
+	txLog transaction.Log
 }
 
 type DatabaseClient interface {
 	Name() string
-	Connect(c map[string]config.DBConfig)
+	Connect(c map[string]config.DBConfig) error
 	ConnType(database string) bool
 	Result(c *Engine)
 }
 
-func (e *Engine) Close() {
-	e.gormClose()
-	e.mongodbClose()
+// Close 关闭所有数据库连接
+func (e *Engine) Close() error {
+	return errors.Join(e.gormClose(), e.mongodbClose())
 }
 
-func (e *Engine) gormClose() {
-	for _, g := range e.Gorm {
+func (e *Engine) gormClose() error {
+	var errs []error
+	// 别名为 default 的连接同时以库名注册，避免重复关闭
+	closed := make(map[*sql.DB]struct{})
+	for name, g := range e.Gorm {
 		db, err := g.DB()
-		if err != nil || db.Ping() != nil {
-			fmt.Println(err)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("gorm %s: %w", name, err))
 			continue
 		}
-		err = db.Close()
-		if err != nil {
-			fmt.Println(err)
+		if _, ok := closed[db]; ok {
+			continue
+		}
+		closed[db] = struct{}{}
+		// 读写分离插件的从库连接
+		if err = resolver.Close(g); err != nil {
+			errs = append(errs, fmt.Errorf("gorm %s: %w", name, err))
+		}
+		if err = db.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("gorm %s: %w", name, err))
 		}
 	}
+	return errors.Join(errs...)
 }
 
-func (e *Engine) mongodbClose() {
-	for _, m := range e.Mongo {
+func (e *Engine) mongodbClose() error {
+	var errs []error
+	for name, m := range e.Mongo {
 		err := m.Client().Disconnect(context.Background())
 		if err != nil {
-			fmt.Println(err)
-			return
+			errs = append(errs, fmt.Errorf("mongodb %s: %w", name, err))
 		}
 	}
+	return errors.Join(errs...)
+}
+
+// SetTransactionLog 设置多库事务的提交日志，未设置时多库事务不记录提交过程
+func (r *Engine) SetTransactionLog(log transaction.Log) {
+	r.txLog = log
+}
+
+// WithTransaction 在事务中执行 fn，未指定数据库时使用 default；fn 中通过 ctx 访问的仓储自动加入事务，
+// 嵌套调用时已在事务中的库创建保存点，不能使用外层事务未开启的库；fn 返回错误或 panic 时回滚
+//
+//	err := svc.DBEngine.WithTransaction(ctx, func(ctx context.Context) error {
+//		if err := orderRepo.Create(ctx, &order); err != nil {
+//			return err
+//		}
+//		return svc.Outbox.SendJobMessage(ctx, nil, job.ShopJob, job.ShopPayload{ShopId: 1})
+//	}, config.DBDefault)
+func (r *Engine) WithTransaction(ctx context.Context, fn func(ctx context.Context) error, dbs ...types.DB) error {
+	names := make([]string, 0, len(dbs))
+	for _, db := range dbs {
+		names = append(names, string(db))
+	}
+	if len(names) == 0 {
+		names = append(names, "default")
+	}
+	return transaction.Run(ctx, r.Gorm, r.txLog, fn, names...)
 }
 
 // NewTransaction 创建一个新的事务上下文的 DBRepository 实例
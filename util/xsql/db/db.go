@@ -41,7 +41,9 @@ func (db *DB) InitDatabases() (*databese.Engine, error) {
 		if configs[name] == nil {
 			continue
 		}
-		client.Connect(configs[name])
+		if err := client.Connect(configs[name]); err != nil {
+			return nil, err
+		}
 		client.Result(db.engine)
 	}
 
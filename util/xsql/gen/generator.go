@@ -0,0 +1,328 @@
+package gen
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Header 生成文件的首行，重新生成时覆盖带有该首行的文件
+const Header = "// Code generated by cmd gen. DO NOT EDIT."
+
+const (
+	modelDir      = "internal/model"
+	repositoryDir = "internal/repository"
+	containerDir  = "internal/container/repository"
+	containerFile = "repository_container.gen.go"
+)
+
+// typeImports Go 类型需要导入的包
+var typeImports = map[string]string{
+	"time.":      "time",
+	"decimal.":   "github.com/shopspring/decimal",
+	"primitive.": "go.mongodb.org/mongo-driver/bson/primitive",
+}
+
+// Generator 根据表结构生成模型、数据访问对象，并注册到 internal/container/repository。
+// *.gen.go 每次生成时覆盖，同目录下的 <name>_model.go、<name>_repository.go 只在不存在时创建，用于编写自定义方法
+type Generator struct {
+	Root   string // 项目根目录
+	Module string // go.mod 中的模块名
+}
+
+// NewGenerator 读取项目根目录中 go.mod 的模块名
+func NewGenerator(root string) (*Generator, error) {
+	f, err := os.Open(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "module ") {
+			module := strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module")), `"`)
+			return &Generator{Root: root, Module: module}, nil
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("gen: module is not found in go.mod")
+}
+
+// genField 模板中的结构体字段
+type genField struct {
+	Name    string
+	Type    string
+	Tag     string
+	Comment string
+}
+
+// genData 模板数据
+type genData struct {
+	Header      string
+	Module      string
+	Package     string // 模型包名，如 user_model
+	RepoPkg     string // 数据访问对象包名，如 user_repository
+	Name        string // 结构体名，如 User
+	DB          string
+	Table       string
+	Comment     string
+	Mongo       bool
+	Imports     []string // 模型文件或容器文件导入的包
+	RepoImports []string // 数据访问对象文件导入的包
+	Fields      []genField
+	Registered  []registered
+}
+
+// Generate 生成表对应的代码，name 为空时使用表名，db 为连接别名，返回写入的文件
+func (g *Generator) Generate(t *Table, db, name string) ([]string, error) {
+	if name == "" {
+		name = t.Name
+	}
+	base := SnakeCase(name)
+	if base == "" {
+		return nil, fmt.Errorf("gen: invalid name %q", name)
+	}
+	d := &genData{
+		Header:  Header,
+		Module:  g.Module,
+		Package: base + "_model",
+		RepoPkg: base + "_repository",
+		Name:    CamelCase(base),
+		DB:      db,
+		Table:   t.Name,
+		Comment: oneLine(t.Comment),
+		Mongo:   t.Mongo,
+		Fields:  fields(t),
+	}
+	d.Imports = sorted(append(imports(d.Fields), g.Module+"/internal/model", g.Module+"/util/xsql/databese"))
+	d.RepoImports = sorted([]string{g.Module + "/" + modelDir + "/" + d.Package, g.Module + "/internal/repository", g.Module + "/util/xlog"})
+
+	modelPath := filepath.Join(g.Root, modelDir, d.Package)
+	repoPath := filepath.Join(g.Root, repositoryDir, d.RepoPkg)
+	files := []struct {
+		path      string
+		tmpl      *template.Template
+		overwrite bool
+	}{
+		{filepath.Join(modelPath, base+".gen.go"), modelTemplate, true},
+		{filepath.Join(modelPath, base+"_model.go"), modelStubTemplate, false},
+		{filepath.Join(repoPath, base+"_repository.gen.go"), repositoryTemplate, true},
+		{filepath.Join(repoPath, base+"_repository.go"), repositoryStubTemplate, false},
+	}
+
+	var written []string
+	for _, f := range files {
+		ok, err := g.write(f.path, f.tmpl, d, f.overwrite)
+		if err != nil {
+			return written, err
+		}
+		if ok {
+			written = append(written, f.path)
+		}
+	}
+	return written, nil
+}
+
+// registered 已生成的数据访问对象
+type registered struct {
+	Field       string // 容器中的字段名，如 UserRepository
+	RepoPkg     string
+	RepoImport  string
+	Model       string // 模型构造函数，如 user_model.NewUserModel
+	ModelImport string
+}
+
+// Register 扫描 internal/repository 下所有生成的数据访问对象，重新生成容器注册文件
+func (g *Generator) Register() (string, error) {
+	matches, err := filepath.Glob(filepath.Join(g.Root, repositoryDir, "*_repository", "*_repository.gen.go"))
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(matches)
+
+	d := &genData{Header: Header, Module: g.Module}
+	for _, file := range matches {
+		list, err := g.scan(file)
+		if err != nil {
+			return "", err
+		}
+		d.Registered = append(d.Registered, list...)
+	}
+	seen := make(map[string]bool)
+	for _, r := range d.Registered {
+		for _, path := range []string{r.RepoImport, r.ModelImport} {
+			if !seen[path] {
+				seen[path] = true
+				d.Imports = append(d.Imports, path)
+			}
+		}
+	}
+	d.Imports = sorted(append(d.Imports, g.Module+"/util/xlog", g.Module+"/util/xsql/databese"))
+
+	path := filepath.Join(g.Root, containerDir, containerFile)
+	_, err = g.write(path, containerTemplate, d, true)
+	return path, err
+}
+
+// scan 解析生成的数据访问对象文件，查找 New<Name>Repository(model *<pkg>.<Name>Model, log *xlog.Log) 形式的构造函数
+func (g *Generator) scan(file string) ([]registered, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), file, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	importsByName := make(map[string]string)
+	for _, spec := range f.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		name := filepath.Base(path)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		importsByName[name] = path
+	}
+	repoImport := g.Module + "/" + filepath.ToSlash(filepath.Join(repositoryDir, filepath.Base(filepath.Dir(file))))
+
+	var list []registered
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil || !strings.HasPrefix(fn.Name.Name, "New") || !strings.HasSuffix(fn.Name.Name, "Repository") {
+			continue
+		}
+		params := fn.Type.Params.List
+		if len(params) != 2 || len(params[0].Names) > 1 {
+			continue
+		}
+		star, ok := params[0].Type.(*ast.StarExpr)
+		if !ok {
+			continue
+		}
+		sel, ok := star.X.(*ast.SelectorExpr)
+		if !ok {
+			continue
+		}
+		pkg, ok := sel.X.(*ast.Ident)
+		if !ok || importsByName[pkg.Name] == "" {
+			continue
+		}
+		list = append(list, registered{
+			Field:       strings.TrimPrefix(fn.Name.Name, "New"),
+			RepoPkg:     f.Name.Name,
+			RepoImport:  repoImport,
+			Model:       pkg.Name + ".New" + sel.Sel.Name,
+			ModelImport: importsByName[pkg.Name],
+		})
+	}
+	return list, nil
+}
+
+// write 渲染模板并格式化后写入文件，overwrite 为 false 时文件已存在则跳过，返回是否写入
+func (g *Generator) write(path string, tmpl *template.Template, d *genData, overwrite bool) (bool, error) {
+	if _, err := os.Stat(path); err == nil {
+		if !overwrite {
+			return false, nil
+		}
+		if ok, err := generated(path); err != nil {
+			return false, err
+		} else if !ok {
+			return false, fmt.Errorf("gen: %s is not generated by cmd gen, refuse to overwrite", path)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, d); err != nil {
+		return false, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return false, fmt.Errorf("gen: format %s: %w\n%s", path, err, buf.String())
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return false, err
+	}
+	return true, os.WriteFile(path, src, 0644)
+}
+
+// generated 文件是否由 cmd gen 生成
+func generated(path string) (bool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	return bytes.HasPrefix(content, []byte(Header)), nil
+}
+
+// fields 表字段对应的结构体字段，字段名重复时添加序号
+func fields(t *Table) []genField {
+	list := make([]genField, 0, len(t.Columns))
+	seen := make(map[string]int)
+	for _, c := range t.Columns {
+		name := CamelCase(c.Name)
+		if seen[name]++; seen[name] > 1 {
+			name += strconv.Itoa(seen[name])
+		}
+		list = append(list, genField{Name: name, Type: c.GoType, Tag: tag(t, c), Comment: oneLine(c.Comment)})
+	}
+	return list
+}
+
+// tag 结构体标签，mysql 等使用 gorm 标签，mongodb 使用 bson 标签，json 使用字段名（mongodb 的 _id 为 id）
+func tag(t *Table, c Column) string {
+	jsonName := c.Name
+	if t.Mongo {
+		bson := c.Name
+		if c.OmitEmpty || c.PrimaryKey {
+			bson += ",omitempty"
+		}
+		if c.Name == "_id" {
+			jsonName = "id"
+		}
+		return fmt.Sprintf("`bson:%q json:%q`", bson, jsonName)
+	}
+
+	gorm := "column:" + c.Name
+	if c.PrimaryKey {
+		gorm += ";primaryKey"
+	}
+	if c.AutoIncrement {
+		gorm += ";autoIncrement"
+	}
+	return fmt.Sprintf("`gorm:%q json:%q`", gorm, jsonName)
+}
+
+func imports(list []genField) []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, f := range list {
+		for prefix, path := range typeImports {
+			if strings.Contains(f.Type, prefix) && !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths
+}
+
+func sorted(list []string) []string {
+	sort.Strings(list)
+	return list
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
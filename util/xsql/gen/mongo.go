@@ -0,0 +1,141 @@
+package gen
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const defaultSample = 100
+
+// field 采样文档中的字段
+type field struct {
+	name     string
+	types    map[string]bool
+	present  int
+	nullable bool
+}
+
+// InspectMongo 随机采样集合中的文档推断字段类型，字段按首次出现的顺序排列，
+// 类型不一致的字段为 interface{}，部分文档缺少的字段添加 omitempty
+func InspectMongo(ctx context.Context, coll *mongo.Collection, sample int) (*Table, error) {
+	if sample <= 0 {
+		sample = defaultSample
+	}
+	cursor, err := coll.Aggregate(ctx, mongo.Pipeline{{{Key: "$sample", Value: bson.D{{Key: "size", Value: sample}}}}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var fields []*field
+	byName := make(map[string]*field)
+	docs := 0
+	for cursor.Next(ctx) {
+		var doc bson.D
+		if err = cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		docs++
+		for _, e := range doc {
+			f, ok := byName[e.Key]
+			if !ok {
+				f = &field{name: e.Key, types: make(map[string]bool)}
+				byName[e.Key] = f
+				fields = append(fields, f)
+			}
+			f.present++
+			if e.Value == nil {
+				f.nullable = true
+				continue
+			}
+			f.types[mongoType(e.Value)] = true
+		}
+	}
+	if err = cursor.Err(); err != nil {
+		return nil, err
+	}
+	if docs == 0 {
+		return nil, fmt.Errorf("gen: collection %s is empty or not found", coll.Name())
+	}
+
+	t := &Table{Name: coll.Name(), Mongo: true}
+	for _, f := range fields {
+		c := Column{
+			Name:       f.name,
+			GoType:     mergeTypes(f.types),
+			Nullable:   f.nullable,
+			PrimaryKey: f.name == "_id",
+			OmitEmpty:  f.present < docs,
+		}
+		c.Type = c.GoType
+		if c.Nullable && c.GoType != "interface{}" && c.GoType[0] != '[' && c.GoType[0] != 'm' {
+			c.GoType = "*" + c.GoType
+		}
+		t.Columns = append(t.Columns, c)
+	}
+	return t, nil
+}
+
+// mongoType bson 值对应的 Go 类型，嵌套文档为 map[string]interface{}
+func mongoType(v interface{}) string {
+	switch v := v.(type) {
+	case primitive.ObjectID:
+		return "primitive.ObjectID"
+	case string:
+		return "string"
+	case int32:
+		return "int32"
+	case int64:
+		return "int64"
+	case float64:
+		return "float64"
+	case bool:
+		return "bool"
+	case primitive.DateTime, primitive.Timestamp:
+		return "time.Time"
+	case primitive.Decimal128:
+		return "primitive.Decimal128"
+	case primitive.Binary:
+		return "[]byte"
+	case bson.D, bson.M:
+		return "map[string]interface{}"
+	case bson.A:
+		types := make(map[string]bool)
+		for _, item := range v {
+			if item != nil {
+				types[mongoType(item)] = true
+			}
+		}
+		if len(types) == 0 {
+			return "[]interface{}"
+		}
+		return "[]" + mergeTypes(types)
+	default:
+		return "interface{}"
+	}
+}
+
+// mergeTypes 合并同一字段的多个类型，整数合并为 int64，整数与浮点数合并为 float64
+func mergeTypes(types map[string]bool) string {
+	switch len(types) {
+	case 0:
+		return "interface{}"
+	case 1:
+		for t := range types {
+			return t
+		}
+	}
+	numeric := map[string]bool{"int32": true, "int64": true, "float64": true}
+	for t := range types {
+		if !numeric[t] {
+			return "interface{}"
+		}
+	}
+	if types["float64"] {
+		return "float64"
+	}
+	return "int64"
+}
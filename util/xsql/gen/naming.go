@@ -0,0 +1,56 @@
+package gen
+
+import (
+	"strings"
+	"unicode"
+)
+
+// initialisms 驼峰命名时全部大写的缩写
+var initialisms = map[string]bool{
+	"API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true, "HTML": true, "HTTP": true, "HTTPS": true,
+	"ID": true, "IP": true, "JSON": true, "OK": true, "SQL": true, "TCP": true, "TTL": true, "UDP": true,
+	"UID": true, "UI": true, "URI": true, "URL": true, "UUID": true, "XML": true,
+}
+
+// SnakeCase 转换为小写下划线命名，用于包名与文件名，如 UserOrder、user-order 转换为 user_order
+func SnakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case unicode.IsUpper(r):
+			if i > 0 && b.Len() > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		default:
+			if b.Len() > 0 && !strings.HasSuffix(b.String(), "_") {
+				b.WriteByte('_')
+			}
+		}
+	}
+	return strings.Trim(b.String(), "_")
+}
+
+// CamelCase 转换为导出的驼峰命名，缩写全部大写，如 user_id 转换为 UserID，以数字开头时添加 F 前缀
+func CamelCase(name string) string {
+	var b strings.Builder
+	for _, word := range strings.Split(SnakeCase(name), "_") {
+		if word == "" {
+			continue
+		}
+		if upper := strings.ToUpper(word); initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	s := b.String()
+	if s == "" || unicode.IsDigit(rune(s[0])) {
+		s = "F" + s
+	}
+	return s
+}
@@ -0,0 +1,234 @@
+package gen
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"strings"
+)
+
+// Column 表字段
+type Column struct {
+	Name          string
+	Type          string // 数据库中的类型
+	GoType        string
+	Nullable      bool
+	PrimaryKey    bool
+	AutoIncrement bool
+	OmitEmpty     bool // mongodb 中部分文档缺少该字段
+	Comment       string
+}
+
+// Table 表或集合的结构
+type Table struct {
+	Name    string
+	Comment string
+	Columns []Column
+	Mongo   bool
+}
+
+// column 字段查询结果
+type column struct {
+	Name          string
+	Type          string
+	Nullable      bool
+	PrimaryKey    bool
+	AutoIncrement bool
+	Comment       string
+}
+
+const (
+	mysqlColumns = `SELECT COLUMN_NAME AS name, COLUMN_TYPE AS type, IS_NULLABLE = 'YES' AS nullable,
+	COLUMN_KEY = 'PRI' AS primary_key, EXTRA LIKE '%auto_increment%' AS auto_increment, COLUMN_COMMENT AS comment
+FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION`
+	mysqlComment = `SELECT TABLE_COMMENT FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?`
+
+	postgresColumns = `SELECT c.column_name AS name, c.udt_name AS type, c.is_nullable = 'YES' AS nullable,
+	EXISTS (SELECT 1 FROM information_schema.table_constraints tc
+		JOIN information_schema.key_column_usage k ON k.constraint_name = tc.constraint_name AND k.table_schema = tc.table_schema AND k.table_name = tc.table_name
+		WHERE tc.constraint_type = 'PRIMARY KEY' AND tc.table_schema = c.table_schema AND tc.table_name = c.table_name AND k.column_name = c.column_name) AS primary_key,
+	(COALESCE(c.column_default, '') LIKE 'nextval(%' OR c.is_identity = 'YES') AS auto_increment,
+	COALESCE(col_description(format('%I.%I', c.table_schema, c.table_name)::regclass, c.ordinal_position), '') AS comment
+FROM information_schema.columns c WHERE c.table_schema = current_schema() AND c.table_name = ? ORDER BY c.ordinal_position`
+	postgresComment = `SELECT COALESCE(obj_description(format('%I.%I', current_schema(), ?)::regclass, 'pg_class'), '')`
+
+	clickhouseColumns = `SELECT name, type, is_in_primary_key AS primary_key, comment
+FROM system.columns WHERE database = currentDatabase() AND table = ? ORDER BY position`
+	clickhouseComment = `SELECT comment FROM system.tables WHERE database = currentDatabase() AND name = ?`
+)
+
+// Inspect 通过 information_schema（clickhouse 为 system.columns）读取表结构，支持 mysql、postgres、clickhouse
+func Inspect(db *gorm.DB, table string) (*Table, error) {
+	driver := db.Dialector.Name()
+	var columns []column
+	var commentSQL string
+	switch driver {
+	case "mysql":
+		commentSQL = mysqlComment
+		if err := db.Raw(mysqlColumns, table).Scan(&columns).Error; err != nil {
+			return nil, err
+		}
+	case "postgres":
+		commentSQL = postgresComment
+		if err := db.Raw(postgresColumns, table).Scan(&columns).Error; err != nil {
+			return nil, err
+		}
+	case "clickhouse":
+		commentSQL = clickhouseComment
+		var rows []struct {
+			Name       string
+			Type       string
+			PrimaryKey uint8
+			Comment    string
+		}
+		if err := db.Raw(clickhouseColumns, table).Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			columns = append(columns, column{
+				Name:       row.Name,
+				Type:       row.Type,
+				Nullable:   strings.HasPrefix(unwrap(row.Type, "LowCardinality("), "Nullable("),
+				PrimaryKey: row.PrimaryKey == 1,
+				Comment:    row.Comment,
+			})
+		}
+	default:
+		return nil, fmt.Errorf("gen: unsupported driver %s", driver)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("gen: table %s not found", table)
+	}
+
+	t := &Table{Name: table}
+	if err := db.Raw(commentSQL, table).Scan(&t.Comment).Error; err != nil {
+		return nil, err
+	}
+	for _, c := range columns {
+		t.Columns = append(t.Columns, Column{
+			Name:          c.Name,
+			Type:          c.Type,
+			GoType:        goType(driver, c.Type, c.Nullable),
+			Nullable:      c.Nullable,
+			PrimaryKey:    c.PrimaryKey,
+			AutoIncrement: c.AutoIncrement,
+			Comment:       c.Comment,
+		})
+	}
+	return t, nil
+}
+
+// goType 数据库类型对应的 Go 类型，可为空的字段使用指针
+func goType(driver, dbType string, nullable bool) string {
+	var t string
+	switch driver {
+	case "mysql":
+		t = mysqlType(dbType)
+	case "postgres":
+		t = postgresType(dbType)
+	default:
+		t = clickhouseType(dbType)
+	}
+	if nullable && !strings.HasPrefix(t, "[]") {
+		t = "*" + t
+	}
+	return t
+}
+
+func mysqlType(dbType string) string {
+	t := strings.ToLower(dbType)
+	unsigned := strings.Contains(t, "unsigned")
+	base := t
+	if i := strings.IndexAny(t, "( "); i >= 0 {
+		base = t[:i]
+	}
+	switch base {
+	case "tinyint":
+		if strings.HasPrefix(t, "tinyint(1)") {
+			return "bool"
+		}
+		return sign(unsigned, "int8")
+	case "smallint", "year":
+		return sign(unsigned, "int16")
+	case "mediumint", "int", "integer":
+		return sign(unsigned, "int32")
+	case "bigint":
+		return sign(unsigned, "int64")
+	case "float":
+		return "float32"
+	case "double", "real":
+		return "float64"
+	case "decimal", "numeric":
+		return "decimal.Decimal"
+	case "date", "datetime", "timestamp":
+		return "time.Time"
+	case "binary", "varbinary", "blob", "tinyblob", "mediumblob", "longblob", "bit":
+		return "[]byte"
+	default:
+		return "string"
+	}
+}
+
+func postgresType(dbType string) string {
+	switch dbType {
+	case "int2":
+		return "int16"
+	case "int4":
+		return "int32"
+	case "int8":
+		return "int64"
+	case "float4":
+		return "float32"
+	case "float8":
+		return "float64"
+	case "numeric":
+		return "decimal.Decimal"
+	case "bool":
+		return "bool"
+	case "date", "timestamp", "timestamptz":
+		return "time.Time"
+	case "bytea":
+		return "[]byte"
+	default:
+		return "string"
+	}
+}
+
+// clickhouseType 可为空由 Nullable(...) 判断，此处去掉 Nullable 与 LowCardinality
+func clickhouseType(dbType string) string {
+	t := unwrap(unwrap(dbType, "LowCardinality("), "Nullable(")
+	if strings.HasPrefix(t, "Array(") && strings.HasSuffix(t, ")") {
+		return "[]" + strings.TrimPrefix(clickhouseType(t[len("Array("):len(t)-1]), "*")
+	}
+
+	base := t
+	if i := strings.Index(t, "("); i >= 0 {
+		base = t[:i]
+	}
+	switch base {
+	case "Int8", "Int16", "Int32", "Int64", "UInt8", "UInt16", "UInt32", "UInt64", "Float32", "Float64":
+		return strings.ToLower(base)
+	case "Bool":
+		return "bool"
+	case "Decimal", "Decimal32", "Decimal64", "Decimal128":
+		return "decimal.Decimal"
+	case "Date", "Date32", "DateTime", "DateTime64":
+		return "time.Time"
+	default:
+		return "string"
+	}
+}
+
+// unwrap 去掉类型的包装，如 Nullable(String) 去掉 Nullable( 后为 String
+func unwrap(t, wrapper string) string {
+	if strings.HasPrefix(t, wrapper) && strings.HasSuffix(t, ")") {
+		return t[len(wrapper) : len(t)-1]
+	}
+	return t
+}
+
+func sign(unsigned bool, t string) string {
+	if unsigned {
+		return "u" + t
+	}
+	return t
+}
@@ -0,0 +1,96 @@
+package gen
+
+import "text/template"
+
+var modelTemplate = template.Must(template.New("model").Parse(`{{.Header}}
+
+package {{.Package}}
+
+import (
+{{- range .Imports}}
+	"{{.}}"
+{{- end}}
+)
+
+// {{.Name}} {{if .Comment}}{{.Comment}}{{else}}{{.Table}}{{end}}
+type {{.Name}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}} {{.Tag}}{{if .Comment}} // {{.Comment}}{{end}}
+{{- end}}
+}
+{{if not .Mongo}}
+func ({{.Name}}) TableName() string {
+	return "{{.Table}}"
+}
+{{end}}
+type {{.Name}}Model struct {
+	model.{{if .Mongo}}MongoDBModel{{else}}DBModel{{end}}
+}
+
+func New{{.Name}}Model(db *databese.Engine) *{{.Name}}Model {
+	return &{{.Name}}Model{*model.New{{if .Mongo}}MongoDBModel{{else}}DBModel{{end}}(db, "{{.DB}}", "{{.Table}}")}
+}
+`))
+
+var modelStubTemplate = template.Must(template.New("model_stub").Parse(`package {{.Package}}
+
+// {{.Name}}、{{.Name}}Model 的自定义方法写在此文件中，重新生成时不会覆盖
+`))
+
+var repositoryTemplate = template.Must(template.New("repository").Parse(`{{.Header}}
+
+package {{.RepoPkg}}
+
+import (
+{{- range .RepoImports}}
+	"{{.}}"
+{{- end}}
+)
+{{if .Mongo}}
+type {{.Name}}Repository struct {
+	*repository.MongoDBRepository
+}
+
+func New{{.Name}}Repository(model *{{.Package}}.{{.Name}}Model, log *xlog.Log) *{{.Name}}Repository {
+	return &{{.Name}}Repository{repository.NewMongoDBRepository(model, log)}
+}
+{{else}}
+type {{.Name}}Repository struct {
+	*repository.Repository[{{.Package}}.{{.Name}}]
+}
+
+func New{{.Name}}Repository(model *{{.Package}}.{{.Name}}Model, log *xlog.Log) *{{.Name}}Repository {
+	return &{{.Name}}Repository{repository.NewRepository[{{.Package}}.{{.Name}}](model, log)}
+}
+{{end}}`))
+
+var repositoryStubTemplate = template.Must(template.New("repository_stub").Parse(`package {{.RepoPkg}}
+
+// {{.Name}}Repository 的自定义方法写在此文件中，重新生成时不会覆盖
+`))
+
+var containerTemplate = template.Must(template.New("container").Parse(`{{.Header}}
+
+package repository
+
+import (
+{{- range .Imports}}
+	"{{.}}"
+{{- end}}
+)
+
+// Generated cmd gen 生成的数据访问对象
+type Generated struct {
+{{- range .Registered}}
+	{{.Field}} *{{.RepoPkg}}.{{.Field}}
+{{- end}}
+}
+
+func registerGenerated(db *databese.Engine, log *xlog.Log) Generated {
+	return Generated{
+{{- range .Registered}}
+		{{.Field}}: {{.RepoPkg}}.New{{.Field}}({{.Model}}(db), log),
+{{- end}}
+	}
+}
+`))
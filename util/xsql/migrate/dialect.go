@@ -0,0 +1,46 @@
+package migrate
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+)
+
+// dialect 版本表在不同数据库中的语句
+type dialect struct {
+	name string
+	// tx 是否支持在事务中执行 DDL
+	tx bool
+}
+
+func newDialect(db *gorm.DB) (dialect, error) {
+	switch name := db.Dialector.Name(); name {
+	case "mysql":
+		return dialect{name: name}, nil
+	case "postgres":
+		return dialect{name: name, tx: true}, nil
+	case "clickhouse":
+		return dialect{name: name}, nil
+	default:
+		return dialect{}, fmt.Errorf("migrate: unsupported driver %s", name)
+	}
+}
+
+// createTable 创建版本表
+func (d dialect) createTable(table string) string {
+	switch d.name {
+	case "clickhouse":
+		return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (version String, name String, checksum String, applied_at DateTime) ENGINE = MergeTree ORDER BY version", table)
+	case "postgres":
+		return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (version VARCHAR(32) PRIMARY KEY, name VARCHAR(255) NOT NULL, checksum VARCHAR(64) NOT NULL, applied_at TIMESTAMP NOT NULL)", table)
+	default:
+		return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (version VARCHAR(32) PRIMARY KEY, name VARCHAR(255) NOT NULL, checksum VARCHAR(64) NOT NULL, applied_at DATETIME NOT NULL)", table)
+	}
+}
+
+// deleteVersion 删除版本记录，clickhouse 同步等待 mutation 完成
+func (d dialect) deleteVersion(table string) string {
+	if d.name == "clickhouse" {
+		return fmt.Sprintf("ALTER TABLE %s DELETE WHERE version = ? SETTINGS mutations_sync = 1", table)
+	}
+	return fmt.Sprintf("DELETE FROM %s WHERE version = ?", table)
+}
@@ -0,0 +1,207 @@
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"gorm.io/gorm"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// VersionLayout 版本号格式，创建迁移时以当前时间生成
+const VersionLayout = "20060102150405"
+
+// Func Go 迁移函数，db 在支持事务 DDL 的数据库（postgres）中为事务连接
+type Func func(ctx context.Context, db *gorm.DB) error
+
+// Migration 迁移，SQL 迁移与 Go 迁移二选一
+type Migration struct {
+	Version  string
+	Name     string
+	UpSQL    string
+	DownSQL  string
+	Up       Func
+	Down     Func
+	Checksum string // SQL 迁移为 up 文件内容的 sha256，Go 迁移为空
+}
+
+// ID 迁移标识，如 20240101120000_create_users
+func (m *Migration) ID() string {
+	return m.Version + "_" + m.Name
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string][]*Migration)
+)
+
+// Register 注册 Go 迁移，db 为连接别名，通常在迁移文件的 init 中调用
+func Register(db, version, name string, up, down Func) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[db] = append(registry[db], &Migration{Version: version, Name: name, Up: up, Down: down})
+}
+
+// Registered 连接别名下注册的 Go 迁移
+func Registered(db string) []*Migration {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	return append([]*Migration(nil), registry[db]...)
+}
+
+var (
+	// fileName 迁移文件名：<version>_<name>.up.sql、<version>_<name>.down.sql
+	fileName  = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+	validName = regexp.MustCompile(`^\w+$`)
+)
+
+// Load 读取目录中的 SQL 迁移并合并 Go 迁移，按版本号排序，目录不存在时只返回 Go 迁移
+func Load(dir string, goMigrations []*Migration) ([]*Migration, error) {
+	byVersion := make(map[string]*Migration)
+	for _, m := range goMigrations {
+		if _, ok := byVersion[m.Version]; ok {
+			return nil, fmt.Errorf("migrate: duplicate version %s", m.Version)
+		}
+		byVersion[m.Version] = m
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, name, direction := match[1], match[2], match[3]
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		}
+		if m.Name != name || m.Up != nil || m.Down != nil {
+			return nil, fmt.Errorf("migrate: duplicate version %s", version)
+		}
+		if direction == "up" {
+			m.UpSQL = string(content)
+			m.Checksum = checksum(content)
+		} else {
+			m.DownSQL = string(content)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == nil && m.Checksum == "" {
+			return nil, fmt.Errorf("migrate: up migration of %s is not found", m.ID())
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// CheckName 检查迁移名称，只允许字母、数字与下划线
+func CheckName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("migrate: invalid name %q, only letters, digits and underscores are allowed", name)
+	}
+	return nil
+}
+
+// Create 在目录中创建空的 up、down 迁移文件，返回创建的文件路径
+func Create(dir, version, name string) ([]string, error) {
+	if err := CheckName(name); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, direction := range []string{"up", "down"} {
+		path := filepath.Join(dir, fmt.Sprintf("%s_%s.%s.sql", version, name, direction))
+		content := fmt.Sprintf("-- %s %s\n", name, direction)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return files, err
+		}
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// statements 按分号拆分 SQL，忽略引号与注释中的分号，只有注释的语句不执行
+func statements(sql string) []string {
+	var (
+		list  []string
+		buf   strings.Builder
+		code  bool
+		quote rune
+	)
+	runes := []rune(sql)
+	flush := func() {
+		if code {
+			list = append(list, strings.TrimSpace(buf.String()))
+		}
+		buf.Reset()
+		code = false
+	}
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote != 0:
+			buf.WriteRune(r)
+			if r == '\\' && i+1 < len(runes) {
+				i++
+				buf.WriteRune(runes[i])
+			} else if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote, code = r, true
+			buf.WriteRune(r)
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for ; i < len(runes) && runes[i] != '\n'; i++ {
+				buf.WriteRune(runes[i])
+			}
+			buf.WriteRune('\n')
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			end := i + 2
+			for end+1 < len(runes) && !(runes[end] == '*' && runes[end+1] == '/') {
+				end++
+			}
+			end = min(end+2, len(runes))
+			buf.WriteString(string(runes[i:end]))
+			i = end - 1
+		case r == ';':
+			flush()
+		default:
+			if !unicode.IsSpace(r) {
+				code = true
+			}
+			buf.WriteRune(r)
+		}
+	}
+	flush()
+	return list
+}
@@ -0,0 +1,366 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-redsync/redsync/v4"
+	"go-framework/util/locker"
+	"go-framework/util/xsql/resolver"
+	"gorm.io/gorm"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTable = "schema_migrations"
+	lockExpiry   = time.Minute
+	lockTries    = 300
+	lockDelay    = time.Second
+)
+
+// 迁移状态
+const (
+	StateApplied  = "applied"  // 已执行
+	StatePending  = "pending"  // 未执行
+	StateModified = "modified" // 已执行，但 up 文件在执行后被修改
+	StateMissing  = "missing"  // 已执行，但迁移文件或 Go 迁移不存在
+)
+
+// ErrModified 已执行的迁移被修改
+var ErrModified = errors.New("migrate: applied migrations were modified")
+
+// Status 迁移状态
+type Status struct {
+	Version   string
+	Name      string
+	State     string
+	AppliedAt *time.Time
+}
+
+// applied 版本表中的记录
+type applied struct {
+	Version   string
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// Migrator 单个数据库连接的迁移执行器
+type Migrator struct {
+	db         *gorm.DB
+	dialect    dialect
+	table      string
+	migrations []*Migration
+	mutex      *locker.Mutex
+	lockKey    string
+	force      bool
+	logf       func(format string, args ...interface{})
+}
+
+// Option 迁移执行器选项
+type Option func(m *Migrator)
+
+// WithTable 设置版本表名，默认 schema_migrations
+func WithTable(table string) Option {
+	return func(m *Migrator) {
+		if table != "" {
+			m.table = table
+		}
+	}
+}
+
+// WithLocker 设置分布式锁，多个实例同时执行迁移时依次执行，key 通常包含应用名与连接别名
+func WithLocker(mutex *locker.Mutex, key string) Option {
+	return func(m *Migrator) {
+		m.mutex = mutex
+		m.lockKey = key
+	}
+}
+
+// WithForce 已执行的迁移被修改时仍继续执行 up
+func WithForce(force bool) Option {
+	return func(m *Migrator) {
+		m.force = force
+	}
+}
+
+// WithLogf 设置执行过程的输出
+func WithLogf(logf func(format string, args ...interface{})) Option {
+	return func(m *Migrator) {
+		m.logf = logf
+	}
+}
+
+// NewMigrator 创建迁移执行器，migrations 需按版本号排序（见 Load），支持 mysql、postgres、clickhouse
+func NewMigrator(db *gorm.DB, migrations []*Migration, opts ...Option) (*Migrator, error) {
+	d, err := newDialect(db)
+	if err != nil {
+		return nil, err
+	}
+	m := &Migrator{
+		db:         db,
+		dialect:    d,
+		table:      defaultTable,
+		migrations: migrations,
+		logf:       func(string, ...interface{}) {},
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m, nil
+}
+
+// Status 所有迁移的状态，按版本号排序，已执行但不存在的迁移排在最后
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if err := m.createTable(ctx); err != nil {
+		return nil, err
+	}
+	records, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]Status, 0, len(m.migrations))
+	for _, mg := range m.migrations {
+		s := Status{Version: mg.Version, Name: mg.Name, State: StatePending}
+		if rec, ok := records[mg.Version]; ok {
+			s.State, s.AppliedAt = StateApplied, &rec.AppliedAt
+			if rec.Checksum != mg.Checksum {
+				s.State = StateModified
+			}
+			delete(records, mg.Version)
+		}
+		list = append(list, s)
+	}
+	for _, rec := range sortApplied(records) {
+		appliedAt := rec.AppliedAt
+		list = append(list, Status{Version: rec.Version, Name: rec.Name, State: StateMissing, AppliedAt: &appliedAt})
+	}
+	return list, nil
+}
+
+// Up 按版本号依次执行未执行的迁移，steps 为 0 时执行全部，返回执行成功的迁移
+func (m *Migrator) Up(ctx context.Context, steps int) ([]*Migration, error) {
+	var done []*Migration
+	err := m.locked(ctx, func(ctx context.Context) error {
+		records, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		var modified []string
+		var list []*Migration
+		for _, mg := range m.migrations {
+			rec, ok := records[mg.Version]
+			if !ok {
+				list = append(list, mg)
+			} else if rec.Checksum != mg.Checksum {
+				modified = append(modified, mg.ID())
+			}
+		}
+		if len(modified) != 0 && !m.force {
+			return fmt.Errorf("%w: %s", ErrModified, strings.Join(modified, ", "))
+		}
+		if steps > 0 && steps < len(list) {
+			list = list[:steps]
+		}
+		done, err = m.up(ctx, list)
+		return err
+	})
+	return done, err
+}
+
+// Down 按版本号倒序回滚最近执行的迁移，steps 小于 1 时回滚 1 个，返回回滚成功的迁移
+func (m *Migrator) Down(ctx context.Context, steps int) ([]*Migration, error) {
+	var done []*Migration
+	err := m.locked(ctx, func(ctx context.Context) error {
+		var err error
+		done, err = m.down(ctx, steps)
+		return err
+	})
+	return done, err
+}
+
+// Redo 回滚最近执行的 steps 个迁移后重新执行，steps 小于 1 时为 1
+func (m *Migrator) Redo(ctx context.Context, steps int) ([]*Migration, error) {
+	var done []*Migration
+	err := m.locked(ctx, func(ctx context.Context) error {
+		list, err := m.down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+			list[i], list[j] = list[j], list[i]
+		}
+		done, err = m.up(ctx, list)
+		return err
+	})
+	return done, err
+}
+
+func (m *Migrator) up(ctx context.Context, list []*Migration) ([]*Migration, error) {
+	var done []*Migration
+	for _, mg := range list {
+		m.logf("migrating %s", mg.ID())
+		start := time.Now()
+		if err := m.run(ctx, mg, true); err != nil {
+			return done, fmt.Errorf("migrate: up %s: %w", mg.ID(), err)
+		}
+		m.logf("migrated %s (%s)", mg.ID(), time.Since(start).Round(time.Millisecond))
+		done = append(done, mg)
+	}
+	return done, nil
+}
+
+func (m *Migrator) down(ctx context.Context, steps int) ([]*Migration, error) {
+	if steps < 1 {
+		steps = 1
+	}
+	records, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[string]*Migration, len(m.migrations))
+	for _, mg := range m.migrations {
+		byVersion[mg.Version] = mg
+	}
+
+	sorted := sortApplied(records)
+	var done []*Migration
+	for i := len(sorted) - 1; i >= 0 && len(done) < steps; i-- {
+		rec := sorted[i]
+		mg, ok := byVersion[rec.Version]
+		if !ok {
+			return done, fmt.Errorf("migrate: down %s_%s: migration not found", rec.Version, rec.Name)
+		}
+		m.logf("rolling back %s", mg.ID())
+		if err = m.run(ctx, mg, false); err != nil {
+			return done, fmt.Errorf("migrate: down %s: %w", mg.ID(), err)
+		}
+		m.logf("rolled back %s", mg.ID())
+		done = append(done, mg)
+	}
+	return done, nil
+}
+
+// run 执行迁移并更新版本表，支持事务 DDL 的数据库在同一事务中执行
+func (m *Migrator) run(ctx context.Context, mg *Migration, up bool) error {
+	exec := func(db *gorm.DB) error {
+		if up {
+			if err := migrate(ctx, db, mg.Up, mg.UpSQL); err != nil {
+				return err
+			}
+			return db.Exec(fmt.Sprintf("INSERT INTO %s (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)", m.table),
+				mg.Version, mg.Name, mg.Checksum, time.Now()).Error
+		}
+
+		// 未定义回滚或回滚 SQL 为空时不删除版本记录，避免回滚未生效却被标记为未执行
+		if mg.Down == nil && (mg.Up != nil || len(statements(mg.DownSQL)) == 0) {
+			return errors.New("down migration is not defined")
+		}
+		if err := migrate(ctx, db, mg.Down, mg.DownSQL); err != nil {
+			return err
+		}
+		return db.Exec(m.dialect.deleteVersion(m.table), mg.Version).Error
+	}
+
+	db := m.db.WithContext(ctx)
+	if m.dialect.tx {
+		return db.Transaction(exec)
+	}
+	return exec(db)
+}
+
+// migrate 执行 Go 迁移或逐条执行 SQL
+func migrate(ctx context.Context, db *gorm.DB, fn Func, sql string) error {
+	if fn != nil {
+		return fn(ctx, db)
+	}
+	for _, stmt := range statements(sql) {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// locked 持有分布式锁时创建版本表并执行 fn，执行期间定时续期；续期失败时取消传给 fn 的 ctx 并返回错误
+func (m *Migrator) locked(ctx context.Context, fn func(ctx context.Context) error) error {
+	if m.mutex == nil {
+		if err := m.createTable(ctx); err != nil {
+			return err
+		}
+		return fn(ctx)
+	}
+
+	if err := m.mutex.Lock(m.lockKey, redsync.WithExpiry(lockExpiry), redsync.WithTries(lockTries), redsync.WithRetryDelay(lockDelay)); err != nil {
+		return fmt.Errorf("migrate: acquire lock %s: %w", m.lockKey, err)
+	}
+	lockCtx, cancel := context.WithCancelCause(ctx)
+	stop := make(chan struct{})
+	defer func() {
+		close(stop)
+		cancel(nil)
+		_, _ = m.mutex.Unlock()
+	}()
+	go func() {
+		ticker := time.NewTicker(lockExpiry / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				ok, err := m.mutex.Extend()
+				if err == nil && !ok {
+					err = redsync.ErrExtendFailed
+				}
+				if err != nil {
+					cancel(fmt.Errorf("migrate: extend lock %s: %w", m.lockKey, err))
+					return
+				}
+			}
+		}
+	}()
+
+	err := m.createTable(lockCtx)
+	if err == nil {
+		err = fn(lockCtx)
+	}
+	// 锁已失效时其他实例可能同时执行迁移，中止并返回续期失败的原因
+	if cause := context.Cause(lockCtx); cause != nil && ctx.Err() == nil {
+		return errors.Join(err, cause)
+	}
+	return err
+}
+
+func (m *Migrator) createTable(ctx context.Context) error {
+	return m.db.WithContext(ctx).Exec(m.dialect.createTable(m.table)).Error
+}
+
+// applied 查询版本表，从主库查询，避免从库延迟导致重复执行或遗漏回滚
+func (m *Migrator) applied(ctx context.Context) (map[string]*applied, error) {
+	var rows []*applied
+	err := m.db.WithContext(resolver.ForceMaster(ctx)).Raw(fmt.Sprintf("SELECT version, name, checksum, applied_at FROM %s", m.table)).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	records := make(map[string]*applied, len(rows))
+	for _, row := range rows {
+		records[row.Version] = row
+	}
+	return records, nil
+}
+
+func sortApplied(records map[string]*applied) []*applied {
+	list := make([]*applied, 0, len(records))
+	for _, rec := range records {
+		list = append(list, rec)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Version < list[j].Version
+	})
+	return list
+}
@@ -26,14 +26,15 @@ func (m *MongoDB) Name() string {
 	return "mongodb"
 }
 
-func (m *MongoDB) Connect(c map[string]config.DBConfig) {
+func (m *MongoDB) Connect(c map[string]config.DBConfig) error {
 	m.c = c
 	for _, dbConfig := range m.c {
 		err := m.connect(dbConfig)
 		if err != nil {
-			panic(fmt.Sprintf("The database %s connection failed， error: %s", dbConfig.Database, err))
+			return fmt.Errorf("the database %s connection failed, error: %w", dbConfig.Database, err)
 		}
 	}
+	return nil
 }
 
 func (m *MongoDB) connect(c config.DBConfig) error {
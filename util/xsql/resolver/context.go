@@ -0,0 +1,50 @@
+package resolver
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type forceMasterKey struct{}
+
+type sessionKey struct{}
+
+// session 会话中是否执行过写操作
+type session struct {
+	written atomic.Bool
+}
+
+// ForceMaster 返回的 ctx 中的读操作使用主库，用于对复制延迟敏感的查询
+func ForceMaster(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceMasterKey{}, true)
+}
+
+// NewSession 创建读写会话，会话中执行写操作之后的读操作使用主库，保证读到自己的写入；
+// 通常每个请求创建一个会话，ctx 中已有会话时直接返回
+func NewSession(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(sessionKey{}).(*session); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, sessionKey{}, &session{})
+}
+
+// useMaster 读操作是否使用主库
+func useMaster(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	if force, _ := ctx.Value(forceMasterKey{}).(bool); force {
+		return true
+	}
+	s, ok := ctx.Value(sessionKey{}).(*session)
+	return ok && s.written.Load()
+}
+
+func markWritten(ctx context.Context) {
+	if ctx == nil {
+		return
+	}
+	if s, ok := ctx.Value(sessionKey{}).(*session); ok {
+		s.written.Store(true)
+	}
+}
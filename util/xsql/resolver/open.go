@@ -0,0 +1,72 @@
+package resolver
+
+import (
+	"database/sql"
+	"go-framework/util/xsql/config"
+	"gorm.io/gorm"
+	"time"
+)
+
+const defaultHealthCheck = 10 * time.Second
+
+// Primary 主连接地址，未配置 host 时使用第一个主库
+func Primary(c config.DBConfig) string {
+	if c.Host == "" && len(c.Sources) > 0 {
+		return c.Sources[0]
+	}
+	return c.Host
+}
+
+// Use 按连接配置为 db 注册读写分离插件，未配置主库与从库时不注册；open 根据地址创建对应驱动的 Dialector
+func Use(db *gorm.DB, c config.DBConfig, open func(host string) gorm.Dialector) error {
+	primary := Primary(c)
+	rc := Config{FailThreshold: c.Resolver.FailThreshold}
+	for _, host := range c.Sources {
+		if host != primary {
+			rc.Sources = append(rc.Sources, Target{Host: host, Dialector: open(host)})
+		}
+	}
+	for i, host := range c.Replicas {
+		t := Target{Host: host, Dialector: open(host)}
+		if i < len(c.Resolver.Weights) {
+			t.Weight = c.Resolver.Weights[i]
+		}
+		rc.Replicas = append(rc.Replicas, t)
+	}
+	if len(rc.Sources) == 0 && len(rc.Replicas) == 0 {
+		return nil
+	}
+
+	var err error
+	if rc.Policy, err = NewPolicy(c.Resolver.Policy); err != nil {
+		return err
+	}
+	switch {
+	case c.Resolver.HealthCheck == 0:
+		rc.HealthCheck = defaultHealthCheck
+	case c.Resolver.HealthCheck > 0:
+		rc.HealthCheck = time.Duration(c.Resolver.HealthCheck) * time.Second
+	}
+	return db.Use(New(rc))
+}
+
+// DBs 主连接与读写分离插件中所有节点的连接池
+func DBs(db *gorm.DB) ([]*sql.DB, error) {
+	primary, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	dbs := []*sql.DB{primary}
+	if r, ok := db.Config.Plugins[Name].(*Resolver); ok {
+		dbs = append(dbs, r.DBs()...)
+	}
+	return dbs, nil
+}
+
+// Close 关闭读写分离插件，未注册时不执行任何操作
+func Close(db *gorm.DB) error {
+	if r, ok := db.Config.Plugins[Name].(*Resolver); ok {
+		return r.Close()
+	}
+	return nil
+}
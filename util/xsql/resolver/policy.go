@@ -0,0 +1,91 @@
+package resolver
+
+import (
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+)
+
+// 负载均衡策略
+const (
+	PolicyRandom     = "random"
+	PolicyRoundRobin = "round_robin"
+	PolicyWeighted   = "weighted"
+	PolicyLeastConn  = "least_conn"
+)
+
+// Policy 从可用节点中选择一个，nodes 不为空
+type Policy interface {
+	Resolve(nodes []*Node) *Node
+}
+
+// PolicyFunc 函数形式的负载均衡策略
+type PolicyFunc func(nodes []*Node) *Node
+
+func (f PolicyFunc) Resolve(nodes []*Node) *Node {
+	return f(nodes)
+}
+
+// NewPolicy 根据名称创建负载均衡策略，名称为空时为 random
+func NewPolicy(name string) (Policy, error) {
+	switch name {
+	case "", PolicyRandom:
+		return RandomPolicy(), nil
+	case PolicyRoundRobin:
+		return RoundRobinPolicy(), nil
+	case PolicyWeighted:
+		return WeightedPolicy(), nil
+	case PolicyLeastConn:
+		return LeastConnPolicy(), nil
+	default:
+		return nil, fmt.Errorf("resolver: unknown policy %s", name)
+	}
+}
+
+// RandomPolicy 随机选择
+func RandomPolicy() Policy {
+	return PolicyFunc(func(nodes []*Node) *Node {
+		return nodes[rand.Intn(len(nodes))]
+	})
+}
+
+// RoundRobinPolicy 轮询选择，节点被摘除时从剩余节点中继续轮询
+func RoundRobinPolicy() Policy {
+	var i uint64
+	return PolicyFunc(func(nodes []*Node) *Node {
+		return nodes[(atomic.AddUint64(&i, 1)-1)%uint64(len(nodes))]
+	})
+}
+
+// WeightedPolicy 按权重随机选择，权重小于 1 的节点按 1 计算
+func WeightedPolicy() Policy {
+	return PolicyFunc(func(nodes []*Node) *Node {
+		total := 0
+		for _, n := range nodes {
+			total += n.weight()
+		}
+		r := rand.Intn(total)
+		for _, n := range nodes {
+			if r -= n.weight(); r < 0 {
+				return n
+			}
+		}
+		return nodes[len(nodes)-1]
+	})
+}
+
+// LeastConnPolicy 选择使用中连接数最少的节点，数量相同时随机选择
+func LeastConnPolicy() Policy {
+	return PolicyFunc(func(nodes []*Node) *Node {
+		offset := rand.Intn(len(nodes))
+		var best *Node
+		least := 0
+		for i := range nodes {
+			n := nodes[(offset+i)%len(nodes)]
+			if inUse := n.InUse(); best == nil || inUse < least {
+				best, least = n, inUse
+			}
+		}
+		return best
+	})
+}
@@ -0,0 +1,279 @@
+package resolver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Name 插件名称
+const Name = "xsql:resolver"
+
+const (
+	defaultFailThreshold = 3
+	pingTimeout          = 3 * time.Second
+)
+
+// Target 主库或从库的连接
+type Target struct {
+	Host      string
+	Dialector gorm.Dialector
+	Weight    int // weighted 策略的权重
+}
+
+// Config 读写分离配置
+type Config struct {
+	Sources       []Target // 主连接以外的主库，写操作在主连接与主库间负载均衡
+	Replicas      []Target // 从库，读操作在从库间负载均衡，没有可用从库时使用主库
+	Policy        Policy   // 负载均衡策略，默认 random
+	HealthCheck   time.Duration
+	FailThreshold int // 连续检查失败次数达到后摘除，恢复后自动加入，默认 3
+}
+
+// Node 连接池节点
+type Node struct {
+	Host    string
+	Weight  int
+	pool    gorm.ConnPool
+	db      *sql.DB
+	primary bool
+	healthy atomic.Bool
+	fails   int
+}
+
+// InUse 使用中的连接数
+func (n *Node) InUse() int {
+	return n.db.Stats().InUse
+}
+
+// Healthy 节点是否可用
+func (n *Node) Healthy() bool {
+	return n.healthy.Load()
+}
+
+func (n *Node) weight() int {
+	return max(n.Weight, 1)
+}
+
+// Resolver 读写分离插件：写操作、事务、加锁查询、ForceMaster 与同一会话中写操作之后的读操作使用主库，
+// 其余读操作使用从库；定时检查节点健康状态，连续失败的节点被摘除
+type Resolver struct {
+	config   Config
+	db       *gorm.DB
+	sources  []*Node
+	replicas []*Node
+	stop     chan struct{}
+	wg       sync.WaitGroup
+	once     sync.Once
+}
+
+// New 创建读写分离插件，通过 db.Use 注册
+func New(c Config) *Resolver {
+	if c.Policy == nil {
+		c.Policy = RandomPolicy()
+	}
+	if c.FailThreshold <= 0 {
+		c.FailThreshold = defaultFailThreshold
+	}
+	return &Resolver{config: c, stop: make(chan struct{})}
+}
+
+func (r *Resolver) Name() string {
+	return Name
+}
+
+func (r *Resolver) Initialize(db *gorm.DB) error {
+	r.db = db
+	primary, err := db.DB()
+	if err != nil {
+		return err
+	}
+	r.sources = append(r.sources, newNode("primary", 0, db.Config.ConnPool, primary, true))
+
+	sources, err := r.open(r.config.Sources)
+	r.sources = append(r.sources, sources...)
+	if err == nil {
+		r.replicas, err = r.open(r.config.Replicas)
+	}
+	if err != nil {
+		_ = r.Close()
+		return err
+	}
+
+	db.Callback().Create().Before("*").Register(Name, r.write)
+	db.Callback().Update().Before("*").Register(Name, r.write)
+	db.Callback().Delete().Before("*").Register(Name, r.write)
+	db.Callback().Query().Before("*").Register(Name, r.read)
+	db.Callback().Row().Before("*").Register(Name, r.read)
+	db.Callback().Raw().Before("*").Register(Name, r.raw)
+
+	if r.config.HealthCheck > 0 {
+		r.wg.Add(1)
+		go r.check()
+	}
+	return nil
+}
+
+func (r *Resolver) open(targets []Target) ([]*Node, error) {
+	nodes := make([]*Node, 0, len(targets))
+	for _, t := range targets {
+		conn, err := gorm.Open(t.Dialector, &gorm.Config{Logger: r.db.Logger})
+		if err != nil {
+			return nodes, fmt.Errorf("resolver: open %s: %w", t.Host, err)
+		}
+		db, err := conn.DB()
+		if err != nil {
+			return nodes, fmt.Errorf("resolver: open %s: %w", t.Host, err)
+		}
+		nodes = append(nodes, newNode(t.Host, t.Weight, conn.Config.ConnPool, db, false))
+	}
+	return nodes, nil
+}
+
+func newNode(host string, weight int, pool gorm.ConnPool, db *sql.DB, primary bool) *Node {
+	n := &Node{Host: host, Weight: weight, pool: pool, db: db, primary: primary}
+	n.healthy.Store(true)
+	return n
+}
+
+// DBs 主连接以外所有节点的连接池
+func (r *Resolver) DBs() []*sql.DB {
+	var dbs []*sql.DB
+	for _, n := range append(r.sources, r.replicas...) {
+		if !n.primary {
+			dbs = append(dbs, n.db)
+		}
+	}
+	return dbs
+}
+
+// Close 停止健康检查并关闭主连接以外的连接池
+func (r *Resolver) Close() error {
+	r.once.Do(func() {
+		close(r.stop)
+	})
+	r.wg.Wait()
+
+	var errs []error
+	for _, db := range r.DBs() {
+		if err := db.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Resolver) write(db *gorm.DB) {
+	markWritten(db.Statement.Context)
+	if !inTransaction(db) {
+		db.Statement.ConnPool = r.resolve(r.sources)
+	}
+}
+
+func (r *Resolver) read(db *gorm.DB) {
+	if db.Statement.SQL.Len() > 0 {
+		r.raw(db)
+		return
+	}
+	if inTransaction(db) {
+		return
+	}
+	_, locking := db.Statement.Clauses["FOR"]
+	if locking || useMaster(db.Statement.Context) {
+		db.Statement.ConnPool = r.resolve(r.sources)
+		return
+	}
+	db.Statement.ConnPool = r.replica()
+}
+
+// raw 原生 SQL 以 SELECT 开头且不加锁时为读操作
+func (r *Resolver) raw(db *gorm.DB) {
+	sql := strings.ToUpper(strings.TrimSpace(db.Statement.SQL.String()))
+	if !strings.HasPrefix(sql, "SELECT") || strings.HasSuffix(sql, "FOR UPDATE") || strings.HasSuffix(sql, "FOR SHARE") ||
+		strings.HasSuffix(sql, "LOCK IN SHARE MODE") {
+		r.write(db)
+		return
+	}
+	if inTransaction(db) {
+		return
+	}
+	if useMaster(db.Statement.Context) {
+		db.Statement.ConnPool = r.resolve(r.sources)
+		return
+	}
+	db.Statement.ConnPool = r.replica()
+}
+
+// replica 没有可用从库时使用主库
+func (r *Resolver) replica() gorm.ConnPool {
+	if healthy := available(r.replicas); len(healthy) > 0 {
+		return r.config.Policy.Resolve(healthy).pool
+	}
+	return r.resolve(r.sources)
+}
+
+// resolve 主库全部不可用时仍在全部主库中选择
+func (r *Resolver) resolve(nodes []*Node) gorm.ConnPool {
+	if len(nodes) == 1 {
+		return nodes[0].pool
+	}
+	healthy := available(nodes)
+	if len(healthy) == 0 {
+		healthy = nodes
+	}
+	return r.config.Policy.Resolve(healthy).pool
+}
+
+func available(nodes []*Node) []*Node {
+	healthy := make([]*Node, 0, len(nodes))
+	for _, n := range nodes {
+		if n.Healthy() {
+			healthy = append(healthy, n)
+		}
+	}
+	return healthy
+}
+
+func inTransaction(db *gorm.DB) bool {
+	_, ok := db.Statement.ConnPool.(gorm.TxCommitter)
+	return ok
+}
+
+// check 定时检查节点，连续失败 FailThreshold 次后摘除，检查成功后恢复
+func (r *Resolver) check() {
+	defer r.wg.Done()
+	ticker := time.NewTicker(r.config.HealthCheck)
+	defer ticker.Stop()
+	nodes := append(append([]*Node(nil), r.sources...), r.replicas...)
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			for _, n := range nodes {
+				r.ping(n)
+			}
+		}
+	}
+}
+
+func (r *Resolver) ping(n *Node) {
+	ctx, cancel := context.WithTimeout(context.Background(), min(pingTimeout, r.config.HealthCheck))
+	defer cancel()
+	if err := n.db.PingContext(ctx); err != nil {
+		if n.fails++; n.fails >= r.config.FailThreshold && n.healthy.Swap(false) {
+			r.db.Logger.Error(ctx, "resolver: %s is ejected after %d failed checks: %v", n.Host, n.fails, err)
+		}
+		return
+	}
+	n.fails = 0
+	if !n.healthy.Swap(true) {
+		r.db.Logger.Info(ctx, "resolver: %s is recovered", n.Host)
+	}
+}
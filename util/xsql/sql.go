@@ -10,23 +10,28 @@ import (
 )
 
 func NewClient(c interface{}) *databese.Engine {
-	cByte, err := json.Marshal(c)
+	engine, err := Open(c)
 	if err != nil {
 		panic(err)
 	}
 
-	databases := make(map[string]config.DBConfig)
-	err = json.Unmarshal(cByte, &databases)
+	return engine
+}
+
+// Open 初始化数据库连接，连接失败时返回错误
+func Open(c interface{}) (*databese.Engine, error) {
+	cByte, err := json.Marshal(c)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	engine, err := db.NewDB(databases).InitDatabases()
+	databases := make(map[string]config.DBConfig)
+	err = json.Unmarshal(cByte, &databases)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	return engine
+	return db.NewDB(databases).InitDatabases()
 }
 
 // SetNotifier 设置钉钉通知
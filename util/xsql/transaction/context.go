@@ -0,0 +1,57 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"go-framework/util/types"
+	"gorm.io/gorm"
+)
+
+// ErrNotInTransaction 上下文中没有指定数据库的事务
+var ErrNotInTransaction = errors.New("transaction: db is not in transaction")
+
+type contextKey struct{}
+
+// compensation 补偿函数，tx 为注册时数据库对应的事务连接
+type compensation struct {
+	tx *gorm.DB
+	fn func(ctx context.Context) error
+}
+
+// NewContext 将事务写入上下文，仓储在该上下文中的操作自动加入事务
+func NewContext(ctx context.Context, tx *Transaction) context.Context {
+	return context.WithValue(ctx, contextKey{}, tx)
+}
+
+// FromContext 上下文中的事务
+func FromContext(ctx context.Context) (*Transaction, bool) {
+	tx, ok := ctx.Value(contextKey{}).(*Transaction)
+	return tx, ok && tx != nil
+}
+
+// DB 上下文中指定连接别名的事务连接，不在事务中时返回 false
+func DB(ctx context.Context, name string) (*gorm.DB, bool) {
+	tx, ok := FromContext(ctx)
+	if !ok || tx.Tx == nil {
+		return nil, false
+	}
+	db, ok := tx.Tx[name]
+	return db, ok && db != nil
+}
+
+// OnCompensate 注册补偿函数：多库事务中 db 已提交而其他库提交失败时，按注册的逆序调用已提交库的补偿函数。
+// 嵌套事务回滚到保存点时其中注册的补偿函数一并丢弃
+func OnCompensate(ctx context.Context, db types.DB, fn func(ctx context.Context) error) error {
+	tx, ok := FromContext(ctx)
+	if !ok {
+		return ErrNotInTransaction
+	}
+	conn, ok := tx.Tx[string(db)]
+	if !ok || conn == nil {
+		return ErrNotInTransaction
+	}
+	tx.mu.Lock()
+	tx.compensations = append(tx.compensations, compensation{tx: conn, fn: fn})
+	tx.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,75 @@
+package transaction
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+const defaultLogTable = "tx_log"
+
+// 多库事务提交状态
+const (
+	StatusCommitting       = "committing"        // 开始逐库提交，长时间停留在该状态说明提交过程中进程退出
+	StatusCommitted        = "committed"         // 全部提交成功
+	StatusCompensated      = "compensated"       // 部分提交失败，已提交的库补偿成功
+	StatusCompensateFailed = "compensate_failed" // 部分提交失败，补偿失败或未注册补偿函数，需人工处理
+	StatusRolledBack       = "rolled_back"       // 第一个库提交失败，全部回滚
+)
+
+// Record 多库事务的提交记录
+type Record struct {
+	ID        string    `gorm:"primaryKey;size:32" json:"id"`
+	DBs       string    `gorm:"column:dbs;size:255" json:"dbs"` // 参与的数据库，逗号分隔
+	Committed string    `gorm:"size:255" json:"committed"`      // 已提交的数据库，逗号分隔
+	Status    string    `gorm:"size:32;not null;index:idx_tx_log_status,priority:1" json:"status"`
+	Error     string    `gorm:"type:text" json:"error"`
+	CreatedAt time.Time `gorm:"index:idx_tx_log_status,priority:2" json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Log 多库事务的提交日志，尽力记录两阶段提交的过程，用于发现部分提交的事务
+type Log interface {
+	// Save 写入或更新提交记录
+	Save(ctx context.Context, rec *Record) error
+	// Unfinished 创建时间早于 before 且未完成或补偿失败的记录，可由定时任务调用并告警
+	Unfinished(ctx context.Context, before time.Time) ([]*Record, error)
+}
+
+var _ Log = (*SQLLog)(nil)
+
+// SQLLog 基于数据库表的提交日志，使用独立连接写入，不加入业务事务
+type SQLLog struct {
+	db    *gorm.DB
+	table string
+}
+
+// NewSQLLog 创建提交日志，table 为空时使用 tx_log
+func NewSQLLog(db *gorm.DB, table string) *SQLLog {
+	if table == "" {
+		table = defaultLogTable
+	}
+	return &SQLLog{db: db, table: table}
+}
+
+// Migrate 创建或更新提交日志表
+func (l *SQLLog) Migrate() error {
+	return l.db.Table(l.table).AutoMigrate(&Record{})
+}
+
+// Save 写入或更新提交记录
+func (l *SQLLog) Save(ctx context.Context, rec *Record) error {
+	rec.UpdatedAt = time.Now()
+	return l.db.WithContext(ctx).Table(l.table).Clauses(clause.OnConflict{UpdateAll: true}).Create(rec).Error
+}
+
+// Unfinished 创建时间早于 before 且未完成或补偿失败的记录
+func (l *SQLLog) Unfinished(ctx context.Context, before time.Time) ([]*Record, error) {
+	var records []*Record
+	err := l.db.WithContext(ctx).Table(l.table).
+		Where("status IN ? AND created_at < ?", []string{StatusCommitting, StatusCompensateFailed}, before).
+		Order("created_at").
+		Find(&records).Error
+	return records, err
+}
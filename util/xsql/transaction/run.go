@@ -0,0 +1,214 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/segmentio/ksuid"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// member 参与事务的连接，同一连接注册了多个别名时只开启一个事务
+type member struct {
+	names     []string
+	tx        *gorm.DB
+	savepoint string // 非空时为加入上层事务创建的保存点
+}
+
+// Run 在事务中执行 fn，conns 为连接别名到连接的映射，fn 中通过 ctx 访问的仓储自动加入事务。
+// 上下文中已有该库的事务时创建保存点（嵌套事务），否则开启新事务并在 fn 返回后提交；
+// 嵌套事务只能使用上层事务已开启的库，使用其他库时返回错误，避免其先于上层事务单独提交；
+// fn 返回错误或 panic 时回滚。多个库同时提交时逐库提交，设置 log 时记录提交过程，
+// 部分提交失败时回滚未提交的库并调用已提交库的补偿函数
+func Run(ctx context.Context, conns map[string]*gorm.DB, log Log, fn func(ctx context.Context) error, names ...string) (err error) {
+	parent, _ := FromContext(ctx)
+	t := &Transaction{Tx: make(map[string]*gorm.DB)}
+	if parent != nil {
+		t.depth = parent.depth + 1
+		for name, tx := range parent.Tx {
+			t.Tx[name] = tx
+		}
+	}
+
+	members, err := begin(ctx, t, conns, names)
+	if err != nil {
+		return err
+	}
+
+	panicked := true
+	defer func() {
+		if panicked {
+			_ = rollback(members)
+		}
+	}()
+
+	err = fn(NewContext(ctx, t))
+	panicked = false
+	if err != nil {
+		return errors.Join(err, rollback(members))
+	}
+
+	var owned []*member
+	for _, m := range members {
+		if m.savepoint == "" {
+			owned = append(owned, m)
+		}
+	}
+	// 加入上层事务的库由上层提交，补偿函数一并交给上层
+	if parent != nil {
+		parent.mu.Lock()
+		for _, c := range t.compensations {
+			if !ownedBy(owned, c.tx) {
+				parent.compensations = append(parent.compensations, c)
+			}
+		}
+		parent.mu.Unlock()
+	}
+	return commit(ctx, t, owned, log)
+}
+
+// begin 为每个库开启事务或创建保存点，失败时回滚已开启的事务
+func begin(ctx context.Context, t *Transaction, conns map[string]*gorm.DB, names []string) ([]*member, error) {
+	var members []*member
+	joined := make(map[*gorm.DB]*member)
+	for _, name := range names {
+		if tx, ok := t.Tx[name]; ok {
+			if m, ok := joined[tx]; ok {
+				m.names = append(m.names, name)
+				continue
+			}
+			m := &member{names: []string{name}, tx: tx, savepoint: fmt.Sprintf("sp_%d", t.depth)}
+			if err := tx.SavePoint(m.savepoint).Error; err != nil {
+				return nil, errors.Join(err, rollback(members))
+			}
+			joined[tx] = m
+			members = append(members, m)
+			continue
+		}
+
+		if t.depth > 0 {
+			return nil, errors.Join(fmt.Errorf("transaction: db %s is not opened by the outer transaction", name), rollback(members))
+		}
+		conn, ok := conns[name]
+		if !ok || conn == nil {
+			return nil, errors.Join(fmt.Errorf("transaction: db %s not found", name), rollback(members))
+		}
+		tx := conn.WithContext(ctx).Begin()
+		if tx.Error != nil {
+			return nil, errors.Join(tx.Error, rollback(members))
+		}
+		m := &member{names: []string{name}, tx: tx}
+		t.Tx[name] = tx
+		// 同一连接的其他别名（如 default 与库名）共用该事务
+		for alias, c := range conns {
+			if c == conn && alias != name {
+				t.Tx[alias] = tx
+			}
+		}
+		joined[tx] = m
+		members = append(members, m)
+	}
+	return members, nil
+}
+
+// rollback 回滚开启的事务，保存点回滚到创建时的状态
+func rollback(members []*member) error {
+	var errs []error
+	for _, m := range members {
+		if m.savepoint != "" {
+			errs = append(errs, m.tx.RollbackTo(m.savepoint).Error)
+		} else {
+			errs = append(errs, m.tx.Rollback().Error)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// commit 逐库提交，第一个库之后的提交失败时回滚其余的库并补偿已提交的库
+func commit(ctx context.Context, t *Transaction, owned []*member, log Log) error {
+	if len(owned) == 0 {
+		return nil
+	}
+	if len(owned) == 1 {
+		return owned[0].tx.Commit().Error
+	}
+
+	var rec *Record
+	if log != nil {
+		rec = &Record{ID: ksuid.New().String(), DBs: joinNames(owned), Status: StatusCommitting, CreatedAt: time.Now()}
+		if err := log.Save(ctx, rec); err != nil {
+			return errors.Join(fmt.Errorf("transaction: save log: %w", err), rollback(owned))
+		}
+	}
+
+	for i, m := range owned {
+		err := m.tx.Commit().Error
+		if err == nil {
+			continue
+		}
+
+		err = errors.Join(fmt.Errorf("transaction: commit %s: %w", m.names[0], err), rollback(owned[i+1:]))
+		status := StatusRolledBack
+		if i > 0 {
+			status = StatusCompensated
+			if cerr := compensate(ctx, t, owned[:i]); cerr != nil {
+				status = StatusCompensateFailed
+				err = errors.Join(err, cerr)
+			}
+		}
+		if rec != nil {
+			rec.Committed, rec.Status, rec.Error = joinNames(owned[:i]), status, err.Error()
+			_ = log.Save(context.WithoutCancel(ctx), rec)
+		}
+		return err
+	}
+
+	if rec != nil {
+		// 已全部提交，更新记录失败时该记录由 Unfinished 查出，需人工确认
+		rec.Committed, rec.Status = rec.DBs, StatusCommitted
+		_ = log.Save(context.WithoutCancel(ctx), rec)
+	}
+	return nil
+}
+
+// compensate 按注册的逆序调用已提交库的补偿函数，已提交的库没有补偿函数时返回错误
+func compensate(ctx context.Context, t *Transaction, committed []*member) error {
+	ctx = context.WithoutCancel(ctx)
+	var errs []error
+	found := make(map[*gorm.DB]bool)
+	for i := len(t.compensations) - 1; i >= 0; i-- {
+		c := t.compensations[i]
+		if !ownedBy(committed, c.tx) {
+			continue
+		}
+		found[c.tx] = true
+		if err := c.fn(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("transaction: compensate: %w", err))
+		}
+	}
+	for _, m := range committed {
+		if !found[m.tx] {
+			errs = append(errs, fmt.Errorf("transaction: %s committed without compensation", m.names[0]))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func ownedBy(owned []*member, tx *gorm.DB) bool {
+	for _, m := range owned {
+		if m.tx == tx {
+			return true
+		}
+	}
+	return false
+}
+
+func joinNames(members []*member) string {
+	names := make([]string, 0, len(members))
+	for _, m := range members {
+		names = append(names, m.names[0])
+	}
+	return strings.Join(names, ",")
+}
@@ -2,10 +2,15 @@ package transaction
 
 import (
 	"gorm.io/gorm"
+	"sync"
 )
 
 type Transaction struct {
 	Tx map[string]*gorm.DB
+
+	depth         int
+	mu            sync.Mutex
+	compensations []compensation
 }
 
 // Commit 提交事务
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"go-framework/util/xsql/config"
 	"go-framework/util/xsql/log"
+	"go-framework/util/xsql/resolver"
 	"gorm.io/driver/clickhouse"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -25,16 +26,24 @@ func NewDB() *DB {
 // Conn 初始化数据库连接
 func (db *DB) Conn(config config.DBConfig) (*gorm.DB, error) {
 	db.config = config
-	dns := db.generateDSN(db.config.Host)
-
-	gormDB, err := gorm.Open(clickhouse.Open(dns), &gorm.Config{
+	gormDB, err := gorm.Open(db.dialect(resolver.Primary(db.config)), &gorm.Config{
 		Logger: log.NewLogger(logger.Default.LogMode(logger.Info)),
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	return gormDB, err
+	// 读写分离
+	if err = resolver.Use(gormDB, db.config, db.dialect); err != nil {
+		return nil, err
+	}
+
+	return gormDB, nil
+}
+
+// dialect 根据主机地址创建方言
+func (db *DB) dialect(host string) gorm.Dialector {
+	return clickhouse.Open(db.generateDSN(host))
 }
 
 // generateDSN 生成数据库的DSN字符串
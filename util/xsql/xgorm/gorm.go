@@ -2,8 +2,10 @@ package xgorm
 
 import (
 	"fmt"
+	"go-framework/util/metrics"
 	"go-framework/util/xsql/config"
 	"go-framework/util/xsql/databese"
+	"go-framework/util/xsql/resolver"
 	"go-framework/util/xsql/xgorm/clickhouse"
 	"go-framework/util/xsql/xgorm/mysql"
 	"go-framework/util/xsql/xgorm/postgresql"
@@ -48,7 +50,7 @@ func (g *Gorm) Name() string {
 	return "gorm"
 }
 
-func (g *Gorm) Connect(c map[string]config.DBConfig) {
+func (g *Gorm) Connect(c map[string]config.DBConfig) error {
 	g.c = c
 	databases := make(map[string]*gorm.DB)
 	var maxIdleConn int
@@ -57,20 +59,29 @@ func (g *Gorm) Connect(c map[string]config.DBConfig) {
 	for _, dbConfig := range g.c {
 		database := g.DB[dbConfig.Driver]
 		if database == nil {
-			panic(fmt.Sprintf("The database type %s is currently not supported. The database name is %s", dbConfig.Driver, dbConfig.Database))
+			return fmt.Errorf("the database type %s is currently not supported. The database name is %s", dbConfig.Driver, dbConfig.Database)
 		}
 		conn, err := database.Conn(dbConfig)
 		if err != nil {
-			panic(fmt.Sprintf("The database %s connection failed， error: %s", dbConfig.Database, err))
+			return fmt.Errorf("the database %s connection failed, error: %w", dbConfig.Database, err)
 		}
 
+		name := dbConfig.Database
 		if dbConfig.Alias != "" {
-			databases[dbConfig.Alias] = conn
-		} else {
-			databases[dbConfig.Database] = conn
+			name = dbConfig.Alias
+		}
+		databases[name] = conn
+
+		// 语句耗时指标，与链路追踪插件并列
+		if err = conn.Use(metrics.NewGormPlugin(name)); err != nil {
+			return fmt.Errorf("the database %s register metrics plugin failed, error: %w", dbConfig.Database, err)
 		}
 
-		sqlDB, err := conn.DB()
+		// 读写分离的主库与从库使用相同的连接池配置
+		sqlDBs, err := resolver.DBs(conn)
+		if err != nil {
+			return fmt.Errorf("the database %s connection failed, error: %w", dbConfig.Database, err)
+		}
 
 		maxIdleConn = defaultMaxIdleConn
 		maxOpenConn = defaultMaxOpenConn
@@ -87,14 +98,16 @@ func (g *Gorm) Connect(c map[string]config.DBConfig) {
 			maxLifeTime = dbConfig.MaxLifeTime
 		}
 
-		// SetMaxIdleConns 用于设置连接池中空闲连接的最大数量。
-		sqlDB.SetMaxIdleConns(maxIdleConn)
+		for _, sqlDB := range sqlDBs {
+			// SetMaxIdleConns 用于设置连接池中空闲连接的最大数量。
+			sqlDB.SetMaxIdleConns(maxIdleConn)
 
-		// SetMaxOpenConns 设置打开数据库连接的最大数量。
-		sqlDB.SetMaxOpenConns(maxOpenConn)
+			// SetMaxOpenConns 设置打开数据库连接的最大数量。
+			sqlDB.SetMaxOpenConns(maxOpenConn)
 
-		// SetConnMaxLifetime 设置了连接可复用的最大时间。
-		sqlDB.SetConnMaxLifetime(time.Second * time.Duration(maxLifeTime))
+			// SetConnMaxLifetime 设置了连接可复用的最大时间。
+			sqlDB.SetConnMaxLifetime(time.Second * time.Duration(maxLifeTime))
+		}
 
 		if dbConfig.Alias == "default" {
 			databases[dbConfig.Database] = conn
@@ -102,6 +115,7 @@ func (g *Gorm) Connect(c map[string]config.DBConfig) {
 	}
 
 	g.client = databases
+	return nil
 }
 
 func (g *Gorm) ConnType(database string) bool {